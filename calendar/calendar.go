@@ -0,0 +1,255 @@
+package calendar
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/georgiwritescode/vacation-tool/types"
+)
+
+// DateLayout is the format used for vacation dates across the API and the web forms.
+const DateLayout = "2006-01-02"
+
+// ParseInputDate parses a date given in a request, form or file, which must
+// be exactly YYYY-MM-DD.
+func ParseInputDate(s string) (time.Time, error) {
+	t, err := time.Parse(DateLayout, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", s)
+	}
+	return t, nil
+}
+
+// DateOnly cuts a date read from the database down to its day part. With
+// parseTime enabled the MySQL driver hands DATE columns back as RFC3339
+// timestamps.
+func DateOnly(s string) string {
+	if len(s) > len(DateLayout) {
+		return s[:len(DateLayout)]
+	}
+	return s
+}
+
+// ParseDate parses a date read from the database, ignoring anything after the
+// day part as DateOnly does. Input is parsed with ParseInputDate instead.
+func ParseDate(s string) (time.Time, error) {
+	s = DateOnly(s)
+
+	t, err := time.Parse(DateLayout, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", s)
+	}
+	return t, nil
+}
+
+// NextDay is the day after date, both in DateLayout format. Stores bound
+// date ranges with it as "before the next day" to include the whole last day.
+func NextDay(date string) (string, error) {
+	t, err := ParseDate(date)
+	if err != nil {
+		return "", err
+	}
+	return t.AddDate(0, 0, 1).Format(DateLayout), nil
+}
+
+// parseField is ParseInputDate for an input field, failing with a validation error.
+func parseField(field, s string) (time.Time, error) {
+	t, err := ParseInputDate(s)
+	if err != nil {
+		return time.Time{}, types.Invalid(field, "%v", err)
+	}
+	return t, nil
+}
+
+// Schedule marks the weekdays someone works, indexed by time.Weekday.
+type Schedule [7]bool
+
+// FullWeek is the Monday to Friday schedule of a full-time employee.
+var FullWeek = Schedule{time.Monday: true, time.Tuesday: true, time.Wednesday: true, time.Thursday: true, time.Friday: true}
+
+var weekdayNames = [7]string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// ParseSchedule parses a comma-separated list of weekdays such as "mon,tue,wed,thu".
+// An empty list is the full Monday to Friday week.
+func ParseSchedule(workDays string) (Schedule, error) {
+	if strings.TrimSpace(workDays) == "" {
+		return FullWeek, nil
+	}
+
+	var schedule Schedule
+	for _, name := range strings.Split(workDays, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		found := false
+		for day, weekday := range weekdayNames {
+			if strings.HasPrefix(name, weekday) {
+				schedule[day], found = true, true
+			}
+		}
+		if !found {
+			return Schedule{}, types.Invalid("work_days", "invalid work day %q, expected mon to sun", name)
+		}
+	}
+	return schedule, nil
+}
+
+// Works reports whether day is one of the schedule's working weekdays.
+func (s Schedule) Works(day time.Time) bool {
+	return s[day.Weekday()]
+}
+
+// String formats the schedule the way ParseSchedule reads it, Monday first.
+func (s Schedule) String() string {
+	var days []string
+	for i := 1; i <= 7; i++ {
+		if day := i % 7; s[day] {
+			days = append(days, weekdayNames[day])
+		}
+	}
+	return strings.Join(days, ",")
+}
+
+// Holidays holds the observed public holidays, keyed by DateLayout date.
+type Holidays map[string]*types.Holiday
+
+// NewHolidays builds the holiday set from calendar rows. When a region and its
+// country both have a row for the same date, the region's row wins, so a manual
+// override with Observed false on "DE-BY" cancels a "DE" holiday for that region.
+func NewHolidays(rows []*types.Holiday) Holidays {
+	winner := make(map[string]*types.Holiday)
+	for _, row := range rows {
+		if current, ok := winner[row.Date]; !ok || len(row.Calendar) > len(current.Calendar) {
+			winner[row.Date] = row
+		}
+	}
+
+	holidays := make(Holidays)
+	for date, row := range winner {
+		if row.Observed {
+			holidays[date] = row
+		}
+	}
+	return holidays
+}
+
+// Contains reports whether day is an observed holiday.
+func (h Holidays) Contains(day time.Time) bool {
+	return h[day.Format(DateLayout)] != nil
+}
+
+// Scopes returns the calendars observed by someone assigned to code, country first:
+// "DE-BY" observes both "DE" and "DE-BY".
+func Scopes(code string) []string {
+	if code == "" {
+		return nil
+	}
+
+	scopes := []string{}
+	parts := strings.Split(code, "-")
+	for i := range parts {
+		scopes = append(scopes, strings.Join(parts[:i+1], "-"))
+	}
+	return scopes
+}
+
+// ForUser loads the user's work schedule and the holidays observed by their
+// calendar between fromDate and toDate.
+func ForUser(ctx context.Context, users types.UserStore, holidays types.HolidayStore, personID int, fromDate, toDate string) (Schedule, Holidays, error) {
+	if _, err := parseField("fromDate", fromDate); err != nil {
+		return Schedule{}, nil, err
+	}
+	if _, err := parseField("toDate", toDate); err != nil {
+		return Schedule{}, nil, err
+	}
+
+	user, err := users.FindById(ctx, personID)
+	if err != nil {
+		return Schedule{}, nil, err
+	}
+
+	schedule, err := ParseSchedule(user.WorkDays)
+	if err != nil {
+		return Schedule{}, nil, err
+	}
+
+	rows, err := holidays.FindBetween(ctx, Scopes(user.HolidayCalendar), fromDate, toDate)
+	if err != nil {
+		return Schedule{}, nil, err
+	}
+
+	return schedule, NewHolidays(rows), nil
+}
+
+// WorkingDays counts the days between from and to, both inclusive, that the
+// schedule works, skipping the given holidays.
+func WorkingDays(from, to time.Time, schedule Schedule, holidays Holidays) int {
+	days := 0
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		if schedule.Works(day) && !holidays.Contains(day) {
+			days++
+		}
+	}
+	return days
+}
+
+// CountWorkingDays is WorkingDays for dates in DateLayout format.
+func CountWorkingDays(fromDate, toDate string, schedule Schedule, holidays Holidays) (int, error) {
+	from, err := parseField("fromDate", fromDate)
+	if err != nil {
+		return 0, err
+	}
+	to, err := parseField("toDate", toDate)
+	if err != nil {
+		return 0, err
+	}
+	if to.Before(from) {
+		return 0, types.Invalid("toDate", "toDate %s is before fromDate %s", toDate, fromDate)
+	}
+
+	return WorkingDays(from, to, schedule, holidays), nil
+}
+
+// ResolveDaysUsed derives DaysUsed from the vacation's date range, the owner's
+// work schedule and their holidays. Half days and hours are only allowed on
+// single-day requests and count as the matching fraction of a day. A
+// client-supplied count is only accepted when it matches the calendar.
+func ResolveDaysUsed(vacation *types.Vacation, schedule Schedule, holidays Holidays) error {
+	workingDays, err := CountWorkingDays(vacation.FromDate, vacation.ToDate, schedule, holidays)
+	if err != nil {
+		return err
+	}
+	if workingDays == 0 {
+		return types.Invalid("toDate", "no working days between %s and %s", vacation.FromDate, vacation.ToDate)
+	}
+
+	days := float64(workingDays)
+	switch vacation.Portion {
+	case "":
+		vacation.Hours = 0
+	case types.PortionAM, types.PortionPM, types.PortionHours:
+		from, _ := ParseInputDate(vacation.FromDate)
+		to, _ := ParseInputDate(vacation.ToDate)
+		if !from.Equal(to) {
+			return types.Invalid("portion", "portion %q is only allowed on single-day requests", vacation.Portion)
+		}
+		days = 0.5
+		if vacation.Portion == types.PortionHours {
+			if vacation.Hours <= 0 || vacation.Hours > types.HoursPerDay {
+				return types.Invalid("hours", "hours must be between 0 and %d", types.HoursPerDay)
+			}
+			days = types.RoundDays(vacation.Hours / types.HoursPerDay)
+		} else {
+			vacation.Hours = 0
+		}
+	default:
+		return types.Invalid("portion", "portion must be %q, %q or %q", types.PortionAM, types.PortionPM, types.PortionHours)
+	}
+
+	if vacation.DaysUsed != 0 && types.RoundDays(vacation.DaysUsed) != days {
+		return types.Invalid("daysUsed", "daysUsed is %g but %s to %s has %g working days", vacation.DaysUsed, vacation.FromDate, vacation.ToDate, days)
+	}
+
+	vacation.DaysUsed = days
+	return nil
+}
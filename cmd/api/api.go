@@ -1,11 +1,27 @@
 package api
 
 import (
-	"database/sql"
+	"fmt"
 	"log"
 	"net/http"
+	"strings"
+	"time"
 
+	"github.com/georgiwritescode/vacation-tool/configs"
+	"github.com/georgiwritescode/vacation-tool/db"
 	"github.com/georgiwritescode/vacation-tool/middleware"
+	"github.com/georgiwritescode/vacation-tool/oidc"
+	"github.com/georgiwritescode/vacation-tool/policy"
+	"github.com/georgiwritescode/vacation-tool/service/accrual"
+	"github.com/georgiwritescode/vacation-tool/service/audit"
+	"github.com/georgiwritescode/vacation-tool/service/auth"
+	"github.com/georgiwritescode/vacation-tool/service/department"
+	"github.com/georgiwritescode/vacation-tool/service/holiday"
+	"github.com/georgiwritescode/vacation-tool/service/leavetype"
+	"github.com/georgiwritescode/vacation-tool/service/ledger"
+	"github.com/georgiwritescode/vacation-tool/service/rollover"
+	"github.com/georgiwritescode/vacation-tool/service/rule"
+	"github.com/georgiwritescode/vacation-tool/service/team"
 	"github.com/georgiwritescode/vacation-tool/service/user"
 	"github.com/georgiwritescode/vacation-tool/service/vacation"
 	"github.com/georgiwritescode/vacation-tool/service/web"
@@ -13,10 +29,10 @@ import (
 
 type ApiServer struct {
 	addr string
-	db   *sql.DB
+	db   *db.DB
 }
 
-func NewApiServer(addr string, db *sql.DB) *ApiServer {
+func NewApiServer(addr string, db *db.DB) *ApiServer {
 	return &ApiServer{
 		addr: addr,
 		db:   db,
@@ -26,21 +42,71 @@ func NewApiServer(addr string, db *sql.DB) *ApiServer {
 func (s *ApiServer) Run() error {
 	router := http.NewServeMux()
 
+	auditStore := audit.NewStore(s.db)
+	auditHandler := audit.NewHandler(auditStore)
+	auditHandler.RegisterRoutes(router)
+
 	userStore := user.NewStore(s.db)
-	userHandler := user.NewHandler(userStore)
+	access := policy.New(userStore)
+	userHandler := user.NewHandler(userStore, access)
 	userHandler.RegisterRoutes(router)
 
+	authStore := auth.NewStore(s.db, audit.Record)
+	authHandler := auth.NewHandler(authStore)
+	authHandler.RegisterRoutes(router)
+
+	leaveTypeStore := leavetype.NewStore(s.db)
+	leaveTypeHandler := leavetype.NewHandler(leaveTypeStore)
+	leaveTypeHandler.RegisterRoutes(router)
+
+	ledgerStore := ledger.NewStore(s.db)
+	ledgerHandler := ledger.NewHandler(ledgerStore, leaveTypeStore, access)
+	ledgerHandler.RegisterRoutes(router)
+
+	accrualStore := accrual.NewStore(s.db)
+	accrualHandler := accrual.NewHandler(accrualStore)
+	accrualHandler.RegisterRoutes(router)
+
+	rolloverStore := rollover.NewStore(s.db)
+	rolloverHandler := rollover.NewHandler(rolloverStore)
+	rolloverHandler.RegisterRoutes(router)
+
+	departmentStore := department.NewStore(s.db)
+	departmentHandler := department.NewHandler(departmentStore)
+	departmentHandler.RegisterRoutes(router)
+
+	teamStore := team.NewStore(s.db)
+	teamHandler := team.NewHandler(teamStore)
+	teamHandler.RegisterRoutes(router)
+
+	holidayStore := holiday.NewStore(s.db)
+	holidayHandler := holiday.NewHandler(holidayStore)
+	holidayHandler.RegisterRoutes(router)
+
+	ruleStore := rule.NewStore(s.db)
+	ruleHandler := rule.NewHandler(ruleStore)
+	ruleHandler.RegisterRoutes(router)
+
 	vacationStore := vacation.NewStore(s.db)
-	vacationHandler := vacation.NewHandler(vacationStore)
+	ruleEvaluator := rule.NewEvaluator(ruleStore, userStore, vacationStore, holidayStore)
+	vacationHandler := vacation.NewHandler(vacationStore, userStore, holidayStore, leaveTypeStore, ruleEvaluator, access)
 	vacationHandler.RegisterRoutes(router)
 
-	webHandler := web.NewHandler(userStore, vacationStore)
+	sso, err := newSSO()
+	if err != nil {
+		return err
+	}
+
+	webHandler := web.NewHandler(userStore, vacationStore, holidayStore, teamStore, leaveTypeStore, authStore, ruleEvaluator, access, auditStore, sso)
 	webHandler.RegisterRoutes(router)
 
 	log.Println("Server listening on port", s.addr)
 
 	stack := middleware.CreateStack(
+		middleware.RequestID,
 		middleware.Loogging,
+		middleware.Auth(authStore, userStore, append(auth.PublicRoutes, web.PublicRoutes...)...),
+		middleware.CSRF,
 	)
 
 	server := http.Server{
@@ -50,3 +116,31 @@ func (s *ApiServer) Run() error {
 
 	return server.ListenAndServe()
 }
+
+// newSSO configures single sign-on from the OIDC_* settings, or returns nil
+// when no issuer is set.
+func newSSO() (*auth.SSO, error) {
+	if configs.Envs.OIDCIssuer == "" {
+		return nil, nil
+	}
+
+	groupRoles, err := auth.ParseGroupRoles(configs.Envs.OIDCGroupRoles)
+	if err != nil {
+		return nil, fmt.Errorf("OIDC_GROUP_ROLES: %v", err)
+	}
+
+	client := oidc.New(oidc.Config{
+		Issuer:       configs.Envs.OIDCIssuer,
+		ClientID:     configs.Envs.OIDCClientID,
+		ClientSecret: configs.Envs.OIDCClientSecret,
+		RedirectURL:  configs.Envs.OIDCRedirectURL,
+		Scopes:       strings.Fields(configs.Envs.OIDCScopes),
+	}, &http.Client{Timeout: 10 * time.Second})
+
+	return &auth.SSO{
+		Client:      client,
+		Provision:   configs.Envs.OIDCProvision,
+		GroupsClaim: configs.Envs.OIDCGroupsClaim,
+		GroupRoles:  groupRoles,
+	}, nil
+}
@@ -1,34 +1,60 @@
 package main
 
 import (
-	"database/sql"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
 	"log"
+	"os"
+	"time"
 
+	"github.com/georgiwritescode/vacation-tool/calendar"
 	"github.com/georgiwritescode/vacation-tool/cmd/api"
 	"github.com/georgiwritescode/vacation-tool/configs"
 	"github.com/georgiwritescode/vacation-tool/db"
-
-	"github.com/go-sql-driver/mysql"
+	"github.com/georgiwritescode/vacation-tool/db/migrations"
+	"github.com/georgiwritescode/vacation-tool/service/accrual"
+	"github.com/georgiwritescode/vacation-tool/service/audit"
+	"github.com/georgiwritescode/vacation-tool/service/auth"
+	"github.com/georgiwritescode/vacation-tool/service/user"
 )
 
 func main() {
-	cfg := mysql.Config{
-		User:                 configs.Envs.DBUser,
-		Passwd:               configs.Envs.DBPassword,
-		Addr:                 configs.Envs.DBAddress,
-		DBName:               configs.Envs.DBName,
-		Net:                  "tcp",
-		AllowNativePasswords: true,
-		ParseTime:            true,
-	}
+	memory := configs.Envs.DBDriver == db.DriverMemory
 
-	db, err := db.MariaDBStorage(cfg)
+	db, err := db.Open(configs.Envs)
 	if err != nil {
 		log.Fatal(err)
 	}
 
 	initStorage(db)
 
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(db, os.Args[2:])
+		return
+	}
+
+	if memory {
+		// An in-memory database starts out empty every time.
+		if _, err := migrations.New(db).Up(); err != nil {
+			log.Fatal(err)
+		}
+	} else if err := migrations.New(db).Check(); err != nil {
+		log.Fatal(err)
+	}
+
+	if configs.Envs.AdminEmail != "" {
+		if err := auth.Bootstrap(context.Background(), auth.NewStore(db, audit.Record), user.NewStore(db), configs.Envs.AdminEmail, configs.Envs.AdminPassword); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "accrue" {
+		runAccrue(db, os.Args[2:])
+		return
+	}
+
 	server := api.NewApiServer(configs.Envs.Port, db)
 
 	if err := server.Run(); err != nil {
@@ -37,7 +63,7 @@ func main() {
 
 }
 
-func initStorage(db *sql.DB) {
+func initStorage(db *db.DB) {
 	err := db.Ping()
 	if err != nil {
 		log.Fatal(err)
@@ -45,3 +71,67 @@ func initStorage(db *sql.DB) {
 
 	log.Println("Connected to database")
 }
+
+// runAccrue implements `vt accrue [--as-of YYYY-MM-DD]`, printing the credits as JSON.
+func runAccrue(db *db.DB, args []string) {
+	flags := flag.NewFlagSet("accrue", flag.ExitOnError)
+	asOf := flags.String("as-of", time.Now().Format(calendar.DateLayout), "accrue entitlements earned up to this date (YYYY-MM-DD)")
+	flags.Parse(args)
+
+	credits, err := accrual.NewStore(db).Accrue(context.Background(), *asOf)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("Accrued %d balance entries as of %s", len(credits), *asOf)
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(credits); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// runMigrate implements `vt migrate up|down|status`: up applies the pending
+// migrations, down reverts the latest one and status lists them all.
+func runMigrate(db *db.DB, args []string) {
+	if len(args) != 1 {
+		log.Fatal("usage: vt migrate up|down|status")
+	}
+	migrator := migrations.New(db)
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up()
+		for _, m := range applied {
+			log.Printf("Applied migration %d %s", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("Schema is at version %d", migrations.Latest)
+	case "down":
+		reverted, err := migrator.Down()
+		if err != nil {
+			log.Fatal(err)
+		}
+		if reverted == nil {
+			log.Println("No migrations to revert")
+			return
+		}
+		log.Printf("Reverted migration %d %s", reverted.Version, reverted.Name)
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, s := range statuses {
+			state := "pending"
+			if s.Applied {
+				state = "applied"
+			}
+			fmt.Printf("%04d %-30s %s\n", s.Version, s.Name, state)
+		}
+	default:
+		log.Fatalf("unknown migrate command %q, expected up, down or status", args[0])
+	}
+}
@@ -0,0 +1,50 @@
+// Command storetest runs the store conformance suite against the storage
+// backend DB_DRIVER configures, applying any pending migrations first:
+//
+//	DB_DRIVER=memory go run ./cmd/storetest
+//
+// It takes the flags of `go test`, such as -test.run, and exits with status 1
+// when a test fails.
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/georgiwritescode/vacation-tool/configs"
+	"github.com/georgiwritescode/vacation-tool/db"
+	"github.com/georgiwritescode/vacation-tool/db/migrations"
+	"github.com/georgiwritescode/vacation-tool/db/storetest"
+	"github.com/georgiwritescode/vacation-tool/service/user"
+	"github.com/georgiwritescode/vacation-tool/service/vacation"
+)
+
+func main() {
+	database, err := db.Open(configs.Envs)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if _, err := migrations.New(database).Up(); err != nil {
+		log.Fatal(err)
+	}
+
+	stores := storetest.Stores{
+		Users:     user.NewStore(database),
+		Vacations: vacation.NewStore(database),
+	}
+
+	tests := make([]testing.InternalTest, len(storetest.Tests))
+	for i, test := range storetest.Tests {
+		tests[i] = testing.InternalTest{Name: test.Name, F: func(t *testing.T) {
+			test.Run(t, stores)
+		}}
+	}
+
+	fmt.Printf("Running the store conformance suite against %s\n", configs.Envs.DBDriver)
+	// Report every test, not only the failures; -test.run still narrows the suite
+	os.Args = append([]string{os.Args[0], "-test.v"}, os.Args[1:]...)
+	testing.Main(regexp.MatchString, tests, nil, nil)
+}
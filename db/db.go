@@ -1,17 +1,283 @@
+// Package db opens the database the stores run on and smooths over the SQL
+// dialects they support: the stores write MySQL and DB rewrites the few
+// constructs other databases spell differently.
 package db
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
 	"log"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/georgiwritescode/vacation-tool/configs"
 	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	_ "modernc.org/sqlite"
 )
 
-func MariaDBStorage(cfg mysql.Config) (*sql.DB, error) {
+// Dialect is the SQL flavour of a database.
+type Dialect string
+
+const (
+	MySQL    Dialect = "mysql"
+	SQLite   Dialect = "sqlite"
+	Postgres Dialect = "postgres"
+)
+
+// The storage backends DB_DRIVER chooses from. Memory is SQLite holding the
+// database in memory, migrated on open and gone when the process exits.
+const (
+	DriverMySQL    = "mysql"
+	DriverSQLite   = "sqlite"
+	DriverMemory   = "memory"
+	DriverPostgres = "postgres"
+)
+
+// Rebind rewrites a query written for MySQL for the dialect. SQLite has no
+// row locks, so FOR UPDATE is dropped; its transactions take the write lock
+// when they begin instead. Postgres numbers its placeholders, and its LIKE
+// is case-sensitive where MySQL's collation is not.
+func (d Dialect) Rebind(query string) string {
+	switch d {
+	case SQLite:
+		query = strings.ReplaceAll(query, " FOR UPDATE", "")
+	case Postgres:
+		query = numberPlaceholders(strings.ReplaceAll(query, " LIKE ", " ILIKE "))
+	}
+	return query
+}
+
+// numberPlaceholders turns the ? placeholders of query into $1, $2 and so
+// on, leaving question marks inside string literals alone.
+func numberPlaceholders(query string) string {
+	var b strings.Builder
+	n, quoted := 0, false
+	for _, r := range query {
+		switch {
+		case r == '\'':
+			quoted = !quoted
+		case r == '?' && !quoted:
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// OnConflict turns an INSERT into an update of the row it collides with on
+// the unique columns keys; the assignments follow it. Excluded names the
+// value the INSERT tried to write to a column.
+func (d Dialect) OnConflict(keys ...string) string {
+	if d == MySQL {
+		return " ON DUPLICATE KEY UPDATE "
+	}
+	return " ON CONFLICT (" + strings.Join(keys, ", ") + ") DO UPDATE SET "
+}
+
+// Excluded is the value an INSERT tried to write to column, for use in the
+// assignments after OnConflict.
+func (d Dialect) Excluded(column string) string {
+	if d == MySQL {
+		return "VALUES(" + column + ")"
+	}
+	return "excluded." + column
+}
+
+// DB is a database handle that knows its dialect. ExecContext, QueryContext
+// and QueryRowContext rebind their query for it; everything else is the
+// embedded *sql.DB, whose Exec, Query, QueryRow and Begin skip Rebind and
+// are not for the stores.
+type DB struct {
+	*sql.DB
+	Dialect Dialect
+	// QueryTimeout bounds the queries of one store call; zero leaves them
+	// to the caller's context alone.
+	QueryTimeout time.Duration
+}
+
+// WithTimeout derives the context a store call runs its queries in from the
+// caller's, giving up after QueryTimeout. The store call defers cancel.
+func (db *DB) WithTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if db.QueryTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, db.QueryTimeout)
+}
+
+func (db *DB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return db.DB.ExecContext(ctx, db.Dialect.Rebind(query), args...)
+}
+
+func (db *DB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return db.DB.QueryContext(ctx, db.Dialect.Rebind(query), args...)
+}
+
+func (db *DB) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return db.DB.QueryRowContext(ctx, db.Dialect.Rebind(query), args...)
+}
+
+// InsertContext runs an INSERT into a table with an id column and returns
+// the id the row got.
+func (db *DB) InsertContext(ctx context.Context, query string, args ...any) (int64, error) {
+	if db.Dialect == Postgres {
+		return returningID(db.QueryRowContext(ctx, query+" RETURNING id", args...))
+	}
+	return lastInsertID(db.ExecContext(ctx, query, args...))
+}
+
+// BeginTx starts a transaction whose queries all run in ctx. It is rolled
+// back if ctx is done before it commits.
+func (db *DB) BeginTx(ctx context.Context) (*Tx, error) {
+	tx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &Tx{Tx: tx, Dialect: db.Dialect, ctx: ctx}, nil
+}
+
+// Tx is a transaction that rebinds its queries like DB. They run in the
+// context it began with, so code handed a Tx needs no context of its own.
+type Tx struct {
+	*sql.Tx
+	Dialect Dialect
+	ctx     context.Context
+}
+
+// Context is the context the transaction's queries run in.
+func (tx *Tx) Context() context.Context {
+	return tx.ctx
+}
+
+func (tx *Tx) Exec(query string, args ...any) (sql.Result, error) {
+	return tx.Tx.ExecContext(tx.ctx, tx.Dialect.Rebind(query), args...)
+}
+
+func (tx *Tx) Query(query string, args ...any) (*sql.Rows, error) {
+	return tx.Tx.QueryContext(tx.ctx, tx.Dialect.Rebind(query), args...)
+}
+
+func (tx *Tx) QueryRow(query string, args ...any) *sql.Row {
+	return tx.Tx.QueryRowContext(tx.ctx, tx.Dialect.Rebind(query), args...)
+}
+
+// Insert is DB.InsertContext within the transaction.
+func (tx *Tx) Insert(query string, args ...any) (int64, error) {
+	if tx.Dialect == Postgres {
+		return returningID(tx.QueryRow(query+" RETURNING id", args...))
+	}
+	return lastInsertID(tx.Exec(query, args...))
+}
+
+// Postgres drivers have no LastInsertId; the id comes back through
+// RETURNING instead.
+func returningID(row *sql.Row) (int64, error) {
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+func lastInsertID(res sql.Result, err error) (int64, error) {
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// NullIfEmpty is s as a query argument, NULL when s is empty. Nullable DATE
+// and DATETIME columns take it rather than NULLIF of the empty string, which
+// Postgres types as text and will not store in them.
+func NullIfEmpty(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// Open connects to the storage backend cfg.DBDriver names. Memory databases
+// still need migrating before use.
+func Open(cfg configs.Config) (*DB, error) {
+	db, err := open(cfg)
+	if err != nil {
+		return nil, err
+	}
+	db.QueryTimeout = cfg.DBQueryTimeout
+	return db, nil
+}
+
+func open(cfg configs.Config) (*DB, error) {
+	switch cfg.DBDriver {
+	case DriverMySQL:
+		return MariaDBStorage(mysql.Config{
+			User:                 cfg.DBUser,
+			Passwd:               cfg.DBPassword,
+			Addr:                 cfg.DBAddress,
+			DBName:               cfg.DBName,
+			Net:                  "tcp",
+			AllowNativePasswords: true,
+			ParseTime:            true,
+		})
+	case DriverSQLite:
+		return SQLiteStorage(cfg.DBPath)
+	case DriverMemory:
+		return MemoryStorage()
+	case DriverPostgres:
+		return PostgresStorage(cfg.PostgresDSN)
+	}
+	return nil, fmt.Errorf("unknown DB_DRIVER %q, expected %s, %s, %s or %s", cfg.DBDriver, DriverMySQL, DriverPostgres, DriverSQLite, DriverMemory)
+}
+
+func MariaDBStorage(cfg mysql.Config) (*DB, error) {
 
 	db, err := sql.Open("mysql", cfg.FormatDSN())
 	if err != nil {
 		log.Fatal(err)
 	}
-	return db, nil
+	return &DB{DB: db, Dialect: MySQL}, nil
+}
+
+// PostgresStorage connects to the Postgres database dsn names, as a URL or
+// key=value pairs. Sessions run in UTC unless the DSN sets a timezone, so
+// CURRENT_TIMESTAMP defaults agree with the UTC times the stores write.
+func PostgresStorage(dsn string) (*DB, error) {
+	cfg, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := cfg.RuntimeParams["timezone"]; !ok {
+		cfg.RuntimeParams["timezone"] = "UTC"
+	}
+	return &DB{DB: stdlib.OpenDB(*cfg), Dialect: Postgres}, nil
+}
+
+// sqliteOptions enforce foreign keys, wait for locks rather than failing,
+// begin every transaction with the write lock so read-then-write
+// transactions cannot deadlock, and write times in a format SQLite's own
+// date functions read.
+const sqliteOptions = "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_txlock=immediate&_time_format=sqlite"
+
+// SQLiteStorage opens the SQLite database file at path, creating it if
+// missing.
+func SQLiteStorage(path string) (*DB, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?"+sqliteOptions+"&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, err
+	}
+	return &DB{DB: db, Dialect: SQLite}, nil
+}
+
+// MemoryStorage opens an empty in-memory SQLite database. It lives on a
+// single connection, as every connection to :memory: gets a database of its
+// own.
+func MemoryStorage() (*DB, error) {
+	db, err := sql.Open("sqlite", ":memory:?"+sqliteOptions)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+	db.SetConnMaxLifetime(0)
+	db.SetConnMaxIdleTime(0)
+	return &DB{DB: db, Dialect: SQLite}, nil
 }
@@ -0,0 +1,357 @@
+// Package migrations keeps the database schema in numbered SQL files embedded
+// in the binary, NNNN_name.up.sql applying a change and NNNN_name.down.sql
+// reverting it. The versions applied are recorded in schema_migrations.
+//
+// Every dialect has its own directory of migrations, named after it, and
+// every directory has the same versions.
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/georgiwritescode/vacation-tool/db"
+)
+
+//go:embed mysql/*.sql postgres/*.sql sqlite/*.sql
+var files embed.FS
+
+// Migration is one numbered schema change.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status is a migration and whether it has been applied.
+type Status struct {
+	Migration
+	Applied bool
+}
+
+// dialects are the embedded migrations of every dialect; a broken set fails
+// every start.
+var dialects = mustLoad(db.MySQL, db.Postgres, db.SQLite)
+
+// Latest is the schema version this build of the code expects.
+var Latest = dialects[db.MySQL][len(dialects[db.MySQL])-1].Version
+
+func mustLoad(names ...db.Dialect) map[db.Dialect][]Migration {
+	sets := make(map[db.Dialect][]Migration, len(names))
+	for _, dialect := range names {
+		fsys, err := fs.Sub(files, string(dialect))
+		if err != nil {
+			panic(err)
+		}
+		migrations, err := load(fsys)
+		if err != nil {
+			panic(fmt.Errorf("%s migrations: %w", dialect, err))
+		}
+		if first, ok := sets[names[0]]; ok {
+			if err := same(first, migrations); err != nil {
+				panic(fmt.Errorf("%s migrations differ from %s: %w", dialect, names[0], err))
+			}
+		}
+		sets[dialect] = migrations
+	}
+	return sets
+}
+
+// same checks that two dialects have migrations of the same versions and names.
+func same(a, b []Migration) error {
+	if len(a) != len(b) {
+		return fmt.Errorf("%d migrations instead of %d", len(b), len(a))
+	}
+	for i := range a {
+		if a[i].Name != b[i].Name {
+			return fmt.Errorf("migration %d is named %q instead of %q", a[i].Version, b[i].Name, a[i].Name)
+		}
+	}
+	return nil
+}
+
+// load reads the migrations from fsys, ordered by version. Every version
+// needs both its up and down file, and versions count up from 1 without gaps.
+func load(fsys fs.FS) ([]Migration, error) {
+	names, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, name := range names {
+		base, direction, ok := strings.Cut(strings.TrimSuffix(name, ".sql"), ".")
+		number, label, found := strings.Cut(base, "_")
+		version, err := strconv.Atoi(number)
+		if !ok || !found || err != nil || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("migration %s is not named NNNN_name.up.sql or NNNN_name.down.sql", name)
+		}
+
+		body, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+
+		m := byVersion[version]
+		if m == nil {
+			m = &Migration{Version: version, Name: label}
+			byVersion[version] = m
+		}
+		if m.Name != label {
+			return nil, fmt.Errorf("migration %d is named both %q and %q", version, m.Name, label)
+		}
+		if direction == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			return nil, fmt.Errorf("migration %d is missing", i+1)
+		}
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d needs both %s and %s", m.Version, fileName(m, "up"), fileName(m, "down"))
+		}
+	}
+	if len(migrations) == 0 {
+		return nil, fmt.Errorf("no migrations found")
+	}
+	return migrations, nil
+}
+
+func fileName(m Migration, direction string) string {
+	return fmt.Sprintf("%04d_%s.%s.sql", m.Version, m.Name, direction)
+}
+
+// Migrator applies and reverts the embedded migrations on a database.
+type Migrator struct {
+	db         *db.DB
+	migrations []Migration
+}
+
+// New returns a migrator applying the migrations of the database's dialect.
+func New(db *db.DB) *Migrator {
+	return &Migrator{db: db, migrations: dialects[db.Dialect]}
+}
+
+// Version is the latest migration applied to the database, 0 for none.
+func (m *Migrator) Version() (int, error) {
+	if err := m.ensureTable(); err != nil {
+		return 0, err
+	}
+
+	var version int
+	err := m.db.QueryRowContext(context.Background(), "SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version)
+	return version, err
+}
+
+// Status lists every migration and whether it has been applied.
+func (m *Migrator) Status() ([]Status, error) {
+	version, err := m.Version()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, len(m.migrations))
+	for i, migration := range m.migrations {
+		statuses[i] = Status{Migration: migration, Applied: migration.Version <= version}
+	}
+	return statuses, nil
+}
+
+// Up applies the migrations not applied yet, in order, and returns them.
+// On Postgres and SQLite each migration and its schema_migrations row commit
+// together, so a failing migration leaves the database as it was. MySQL
+// commits schema changes as it goes, so there a failing migration leaves the
+// statements before the failing one applied; fix it and run Up again.
+func (m *Migrator) Up() ([]Migration, error) {
+	version, err := m.Version()
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	for _, migration := range m.migrations {
+		if migration.Version <= version {
+			continue
+		}
+		err := m.run(migration.Up, "INSERT INTO schema_migrations (version, name) VALUES (?, ?)", migration.Version, migration.Name)
+		if err != nil {
+			return applied, fmt.Errorf("migration %d %s: %w", migration.Version, migration.Name, err)
+		}
+		applied = append(applied, migration)
+	}
+	return applied, nil
+}
+
+// Down reverts the latest applied migration and returns it, or nil when
+// there is nothing to revert. It is as transactional as Up.
+func (m *Migrator) Down() (*Migration, error) {
+	version, err := m.Version()
+	if err != nil || version == 0 {
+		return nil, err
+	}
+	if version > len(m.migrations) {
+		return nil, fmt.Errorf("database is at version %d, newer than this build knows (%d)", version, Latest)
+	}
+
+	migration := m.migrations[version-1]
+	if err := m.run(migration.Down, "DELETE FROM schema_migrations WHERE version = ?", migration.Version); err != nil {
+		return nil, fmt.Errorf("reverting migration %d %s: %w", migration.Version, migration.Name, err)
+	}
+	return &migration, nil
+}
+
+// Check fails unless the database is at exactly the version the code expects.
+func (m *Migrator) Check() error {
+	version, err := m.Version()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case version < Latest:
+		return fmt.Errorf("database schema is at version %d, expected %d: run `vt migrate up`", version, Latest)
+	case version > Latest:
+		return fmt.Errorf("database schema is at version %d, newer than this build expects (%d)", version, Latest)
+	}
+	return nil
+}
+
+func (m *Migrator) ensureTable() error {
+	_, err := m.db.ExecContext(context.Background(), `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version INT PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+)`)
+	return err
+}
+
+// execer runs statements on a database, connection or transaction.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// run runs a migration script and then record, the statement keeping
+// schema_migrations in step, in one transaction where the dialect allows.
+func (m *Migrator) run(script, record string, args ...any) error {
+	ctx := context.Background()
+	record = m.db.Dialect.Rebind(record)
+
+	switch m.db.Dialect {
+	case db.MySQL:
+		return exec(ctx, m.db, script, record, args)
+	case db.SQLite:
+		return m.runSQLite(ctx, script, record, args)
+	}
+
+	tx, err := m.db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := exec(ctx, tx, script, record, args); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// runSQLite is run for SQLite, which rebuilds a table to change its foreign
+// keys. Dropping a table others reference would delete their rows with it, so
+// foreign keys are not enforced during the migration; they are checked before
+// it commits instead. SQLite ignores the pragma inside a transaction, hence
+// the connection of its own.
+func (m *Migrator) runSQLite(ctx context.Context, script, record string, args []any) (err error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "PRAGMA foreign_keys = OFF"); err != nil {
+		return err
+	}
+	defer func() {
+		if _, enableErr := conn.ExecContext(ctx, "PRAGMA foreign_keys = ON"); err == nil {
+			err = enableErr
+		}
+	}()
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := exec(ctx, tx, script, record, args); err != nil {
+		return err
+	}
+
+	rows, err := tx.QueryContext(ctx, "PRAGMA foreign_key_check")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	if rows.Next() {
+		var table, parent string
+		var rowID, key sql.NullInt64
+		if err := rows.Scan(&table, &rowID, &parent, &key); err != nil {
+			return err
+		}
+		return fmt.Errorf("row %d of %s references a missing row of %s", rowID.Int64, table, parent)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// exec runs a migration's statements one at a time, as the driver takes a
+// single statement per call, and then record.
+func exec(ctx context.Context, e execer, script, record string, args []any) error {
+	for _, statement := range statements(script) {
+		if _, err := e.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+	_, err := e.ExecContext(ctx, record, args...)
+	return err
+}
+
+// statements splits a script into its statements. Statements end with a
+// semicolon at the end of a line; lines starting with -- are comments.
+func statements(script string) []string {
+	var statements []string
+	var current strings.Builder
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+
+		current.WriteString(line)
+		current.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, strings.TrimSuffix(strings.TrimSpace(current.String()), ";"))
+			current.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		statements = append(statements, rest)
+	}
+	return statements
+}
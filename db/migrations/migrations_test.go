@@ -0,0 +1,121 @@
+package migrations
+
+import (
+	"context"
+	"testing"
+
+	"github.com/georgiwritescode/vacation-tool/db"
+)
+
+func memory(t *testing.T) *db.DB {
+	t.Helper()
+	database, err := db.MemoryStorage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Close() })
+	return database
+}
+
+func version(t *testing.T, m *Migrator) int {
+	t.Helper()
+	v, err := m.Version()
+	if err != nil {
+		t.Fatalf("Version: %v", err)
+	}
+	return v
+}
+
+func TestUpDownUp(t *testing.T) {
+	m := New(memory(t))
+
+	if _, err := m.Up(); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	if err := m.Check(); err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+
+	for v := Latest; v > 0; v-- {
+		migration, err := m.Down()
+		if err != nil {
+			t.Fatalf("Down from %d: %v", v, err)
+		}
+		if migration.Version != v || version(t, m) != v-1 {
+			t.Fatalf("Down from %d reverted %d to version %d", v, migration.Version, version(t, m))
+		}
+	}
+
+	applied, err := m.Up()
+	if err != nil {
+		t.Fatalf("Up again: %v", err)
+	}
+	if len(applied) != Latest {
+		t.Errorf("Up applied %d migrations, want %d", len(applied), Latest)
+	}
+}
+
+// TestAdoptsInitSQL migrates a database set up with the original init.sql,
+// which has the tables of the initial migration but no schema_migrations.
+func TestAdoptsInitSQL(t *testing.T) {
+	database, ctx := memory(t), context.Background()
+	for _, statement := range statements(dialects[db.SQLite][0].Up) {
+		if _, err := database.ExecContext(ctx, statement); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := database.ExecContext(ctx, "INSERT INTO tbl_users (first_name, last_name, age, email, vacation_days) VALUES ('Ada', 'Lovelace', 36, 'ada@example.com', 18)"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := database.ExecContext(ctx, "INSERT INTO tbl_vacations (label, from_date, to_date, person_id, days_used) VALUES ('Summer', '2024-07-01', '2024-07-02', 1, 2)"); err != nil {
+		t.Fatal(err)
+	}
+
+	m := New(database)
+	if _, err := m.Up(); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+
+	var status, leaveType string
+	if err := database.QueryRowContext(ctx, "SELECT status, leave_type FROM tbl_vacations WHERE person_id = 1").Scan(&status, &leaveType); err != nil {
+		t.Fatal(err)
+	}
+	if status != "approved" || leaveType != "paid" {
+		t.Errorf("existing vacation is %s %s, want approved paid", status, leaveType)
+	}
+
+	// Reverting the migrations that rebuild tables keeps the rows of the
+	// tables referencing them.
+	for version(t, m) > 3 {
+		if _, err := m.Down(); err != nil {
+			t.Fatalf("Down: %v", err)
+		}
+	}
+	var days, vacations int
+	if err := database.QueryRowContext(ctx, "SELECT vacation_days, (SELECT COUNT(*) FROM tbl_vacations) FROM tbl_users WHERE id = 1").Scan(&days, &vacations); err != nil {
+		t.Fatal(err)
+	}
+	if days != 18 || vacations != 1 {
+		t.Errorf("after Down: %d days and %d vacations, want 18 and 1", days, vacations)
+	}
+}
+
+func TestFailingMigrationRollsBack(t *testing.T) {
+	database, ctx := memory(t), context.Background()
+	m := &Migrator{db: database, migrations: []Migration{{
+		Version: 1,
+		Name:    "broken",
+		Up:      "CREATE TABLE tbl_half (id INT);\nINSERT INTO tbl_missing VALUES (1);",
+		Down:    "DROP TABLE tbl_half;",
+	}}}
+
+	if _, err := m.Up(); err == nil {
+		t.Fatal("Up applied a broken migration")
+	}
+	if v := version(t, m); v != 0 {
+		t.Errorf("version %d after a failed migration, want 0", v)
+	}
+	if _, err := database.ExecContext(ctx, "SELECT id FROM tbl_half"); err == nil {
+		t.Error("the failed migration left tbl_half behind")
+	}
+}
@@ -0,0 +1,3 @@
+-- Drops the tables of the initial schema, dependents first.
+DROP TABLE IF EXISTS tbl_vacations;
+DROP TABLE IF EXISTS tbl_users;
@@ -1,5 +1,6 @@
--- Vacation Tool Database Initialization Script
--- This script creates the necessary tables for the vacation management system
+-- Vacation Tool initial schema
+-- The tables of the original init.sql. Both are created only if missing, so
+-- databases set up with init.sql are adopted as version 1 and migrated from there.
 
 -- Create users table
 CREATE TABLE IF NOT EXISTS tbl_users (
@@ -26,4 +27,4 @@ CREATE TABLE IF NOT EXISTS tbl_vacations (
     FOREIGN KEY (person_id) REFERENCES tbl_users(id) ON DELETE CASCADE,
     INDEX idx_person_id (person_id),
     INDEX idx_dates (from_date, to_date)
-) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
\ No newline at end of file
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,18 @@
+-- Adds public holiday calendars and the calendar each user observes.
+
+ALTER TABLE tbl_users ADD COLUMN holiday_calendar VARCHAR(32) NOT NULL DEFAULT '';
+
+-- Create holidays table
+-- calendar is a country code ("DE") or a region code ("DE-BY"); region calendars
+-- also observe their country's holidays. source = 'manual' marks admin overrides.
+CREATE TABLE tbl_holidays (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    calendar VARCHAR(32) NOT NULL,
+    holiday_date DATE NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    observed BOOLEAN NOT NULL DEFAULT TRUE,
+    source VARCHAR(16) NOT NULL DEFAULT 'manual',
+    ts TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_calendar_date (calendar, holiday_date),
+    INDEX idx_holiday_date (holiday_date)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,3 @@
+ALTER TABLE tbl_vacations
+    DROP INDEX idx_person_status,
+    DROP COLUMN status;
@@ -0,0 +1,10 @@
+ALTER TABLE tbl_users
+    DROP FOREIGN KEY fk_users_manager,
+    DROP FOREIGN KEY fk_users_team;
+
+ALTER TABLE tbl_users
+    DROP COLUMN manager_id,
+    DROP COLUMN team_id;
+
+DROP TABLE tbl_teams;
+DROP TABLE tbl_departments;
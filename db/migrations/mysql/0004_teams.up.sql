@@ -0,0 +1,23 @@
+-- Adds departments and teams, and the manager and team of each user.
+
+-- Create departments table
+CREATE TABLE tbl_departments (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(255) NOT NULL UNIQUE,
+    ts TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Create teams table
+CREATE TABLE tbl_teams (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(255) NOT NULL UNIQUE,
+    department_id INT NULL,
+    ts TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (department_id) REFERENCES tbl_departments(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+ALTER TABLE tbl_users
+    ADD COLUMN manager_id INT NULL,
+    ADD COLUMN team_id INT NULL,
+    ADD CONSTRAINT fk_users_manager FOREIGN KEY (manager_id) REFERENCES tbl_users(id) ON DELETE SET NULL,
+    ADD CONSTRAINT fk_users_team FOREIGN KEY (team_id) REFERENCES tbl_teams(id) ON DELETE SET NULL;
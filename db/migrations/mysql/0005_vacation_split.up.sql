@@ -0,0 +1,6 @@
+-- Records how much of each vacation was charged to paid and to unpaid leave,
+-- so deleting or resizing it refunds the right balances.
+
+ALTER TABLE tbl_vacations
+    ADD COLUMN paid_days INT NOT NULL DEFAULT 0,
+    ADD COLUMN non_paid_days INT NOT NULL DEFAULT 0;
@@ -0,0 +1,17 @@
+-- Create ledger table
+-- Append-only record of every balance movement. vacation_days/non_paid_leave on
+-- tbl_users cache the per-user sums of amount for the paid and unpaid leave types.
+-- Neither user_id nor vacation_id has a foreign key: the ledger is history,
+-- and a deleted user or vacation keeps its rows here as it does in the audit log.
+CREATE TABLE tbl_ledger (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    leave_type VARCHAR(32) NOT NULL,
+    amount INT NOT NULL,
+    reason VARCHAR(32) NOT NULL,
+    vacation_id INT NULL,
+    reference VARCHAR(255) NOT NULL DEFAULT '',
+    ts TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_ledger_user (user_id, id),
+    INDEX idx_ledger_vacation (vacation_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,5 @@
+DROP TABLE tbl_rules;
+
+ALTER TABLE tbl_vacations
+    DROP COLUMN flagged,
+    DROP COLUMN flag_reason;
@@ -0,0 +1,22 @@
+-- Adds team capacity and blackout rules, and the flag they put on vacations.
+
+ALTER TABLE tbl_vacations
+    ADD COLUMN flagged BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN flag_reason VARCHAR(1024) NOT NULL DEFAULT '';
+
+-- Create rules table
+-- kind 'max_concurrent' caps absences per day for team_id (NULL = whole company);
+-- kind 'blackout' forbids leave between from_date and to_date. action is 'block' or 'flag'.
+CREATE TABLE tbl_rules (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    kind VARCHAR(32) NOT NULL,
+    label VARCHAR(255) NOT NULL,
+    team_id INT NULL,
+    max_absent INT NOT NULL DEFAULT 0,
+    from_date DATE NULL,
+    to_date DATE NULL,
+    action VARCHAR(16) NOT NULL DEFAULT 'block',
+    ts TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (team_id) REFERENCES tbl_teams(id) ON DELETE CASCADE,
+    INDEX idx_rules_team (team_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,12 @@
+DROP TABLE tbl_balances;
+
+ALTER TABLE tbl_ledger DROP FOREIGN KEY fk_ledger_leave_type;
+ALTER TABLE tbl_ledger DROP INDEX fk_ledger_leave_type;
+
+ALTER TABLE tbl_vacations DROP FOREIGN KEY fk_vacations_leave_type;
+
+ALTER TABLE tbl_vacations
+    DROP COLUMN leave_type,
+    DROP COLUMN document;
+
+DROP TABLE tbl_leave_types;
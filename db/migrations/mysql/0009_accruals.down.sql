@@ -0,0 +1,2 @@
+DROP TABLE tbl_accrual_policies;
+ALTER TABLE tbl_users DROP COLUMN hire_date;
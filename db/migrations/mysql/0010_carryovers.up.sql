@@ -0,0 +1,33 @@
+-- Create carry-over tables
+-- A policy caps the days of leave_type carrying over into the next year for users
+-- whose holiday calendar is in country ('' = every other country); carried days
+-- expire on expires_on ('MM-DD', '' = never). tbl_carryovers records each rollover.
+CREATE TABLE tbl_carryover_policies (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    leave_type VARCHAR(32) NOT NULL,
+    country VARCHAR(8) NOT NULL DEFAULT '',
+    max_days INT NOT NULL DEFAULT 0,
+    expires_on VARCHAR(5) NOT NULL DEFAULT '',
+    ts TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_carryover_policy (leave_type, country),
+    FOREIGN KEY (leave_type) REFERENCES tbl_leave_types(code) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE tbl_carryovers (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    leave_type VARCHAR(32) NOT NULL,
+    year INT NOT NULL,
+    country VARCHAR(8) NOT NULL DEFAULT '',
+    balance INT NOT NULL,
+    carried INT NOT NULL,
+    forfeited INT NOT NULL,
+    expires_on DATE NULL,
+    expiry_done BOOLEAN NOT NULL DEFAULT FALSE,
+    used INT NOT NULL DEFAULT 0,
+    expired INT NOT NULL DEFAULT 0,
+    ts TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_carryover (user_id, leave_type, year),
+    FOREIGN KEY (user_id) REFERENCES tbl_users(id) ON DELETE CASCADE,
+    FOREIGN KEY (leave_type) REFERENCES tbl_leave_types(code)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
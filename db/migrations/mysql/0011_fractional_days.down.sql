@@ -0,0 +1,27 @@
+-- Fractional amounts are rounded to whole days.
+
+ALTER TABLE tbl_carryovers
+    MODIFY COLUMN balance INT NOT NULL,
+    MODIFY COLUMN carried INT NOT NULL,
+    MODIFY COLUMN forfeited INT NOT NULL,
+    MODIFY COLUMN used INT NOT NULL DEFAULT 0,
+    MODIFY COLUMN expired INT NOT NULL DEFAULT 0;
+
+ALTER TABLE tbl_carryover_policies MODIFY COLUMN max_days INT NOT NULL DEFAULT 0;
+
+ALTER TABLE tbl_accrual_policies MODIFY COLUMN annual_days INT NOT NULL;
+
+ALTER TABLE tbl_balances MODIFY COLUMN balance INT NOT NULL DEFAULT 0;
+
+ALTER TABLE tbl_ledger MODIFY COLUMN amount INT NOT NULL;
+
+ALTER TABLE tbl_vacations
+    DROP COLUMN portion,
+    DROP COLUMN hours,
+    MODIFY COLUMN days_used INT NOT NULL DEFAULT 0,
+    MODIFY COLUMN paid_days INT NOT NULL DEFAULT 0,
+    MODIFY COLUMN non_paid_days INT NOT NULL DEFAULT 0;
+
+ALTER TABLE tbl_users
+    MODIFY COLUMN vacation_days INT NOT NULL DEFAULT 20,
+    MODIFY COLUMN non_paid_leave INT NOT NULL DEFAULT 0;
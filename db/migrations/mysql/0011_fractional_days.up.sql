@@ -0,0 +1,28 @@
+-- Counts days in thousandths for half-day and hourly leave, and records which
+-- half or how many hours a vacation takes.
+
+ALTER TABLE tbl_users
+    MODIFY COLUMN vacation_days DECIMAL(9,3) NOT NULL DEFAULT 20,
+    MODIFY COLUMN non_paid_leave DECIMAL(9,3) NOT NULL DEFAULT 0;
+
+ALTER TABLE tbl_vacations
+    MODIFY COLUMN days_used DECIMAL(9,3) NOT NULL DEFAULT 0,
+    MODIFY COLUMN paid_days DECIMAL(9,3) NOT NULL DEFAULT 0,
+    MODIFY COLUMN non_paid_days DECIMAL(9,3) NOT NULL DEFAULT 0,
+    ADD COLUMN portion VARCHAR(8) NOT NULL DEFAULT '',
+    ADD COLUMN hours DECIMAL(5,2) NOT NULL DEFAULT 0;
+
+ALTER TABLE tbl_ledger MODIFY COLUMN amount DECIMAL(9,3) NOT NULL;
+
+ALTER TABLE tbl_balances MODIFY COLUMN balance DECIMAL(9,3) NOT NULL DEFAULT 0;
+
+ALTER TABLE tbl_accrual_policies MODIFY COLUMN annual_days DECIMAL(9,3) NOT NULL;
+
+ALTER TABLE tbl_carryover_policies MODIFY COLUMN max_days DECIMAL(9,3) NOT NULL DEFAULT 0;
+
+ALTER TABLE tbl_carryovers
+    MODIFY COLUMN balance DECIMAL(9,3) NOT NULL,
+    MODIFY COLUMN carried DECIMAL(9,3) NOT NULL,
+    MODIFY COLUMN forfeited DECIMAL(9,3) NOT NULL,
+    MODIFY COLUMN used DECIMAL(9,3) NOT NULL DEFAULT 0,
+    MODIFY COLUMN expired DECIMAL(9,3) NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+ALTER TABLE tbl_users
+    DROP COLUMN work_days,
+    DROP COLUMN fte;
@@ -0,0 +1,20 @@
+-- Create audit log table
+-- One row per change made through the API or the web UI. actor_id has no foreign
+-- key so the history outlives deleted users; before_json and after_json are the
+-- entity's snapshots, NULL for creations and deletions respectively.
+CREATE TABLE tbl_audit_log (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    actor_id INT NULL,
+    actor_email VARCHAR(255) NOT NULL DEFAULT '',
+    action VARCHAR(32) NOT NULL,
+    entity VARCHAR(32) NOT NULL,
+    entity_id VARCHAR(64) NOT NULL DEFAULT '',
+    before_json JSON NULL,
+    after_json JSON NULL,
+    request_id VARCHAR(64) NOT NULL DEFAULT '',
+    ip VARCHAR(45) NOT NULL DEFAULT '',
+    ts TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_audit_entity (entity, entity_id),
+    INDEX idx_audit_actor (actor_id),
+    INDEX idx_audit_ts (ts)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,32 @@
+-- Vacation Tool initial schema, Postgres
+-- The tables of mysql/0001_initial.up.sql. Emails, which MySQL's collation
+-- compares case-insensitively, are CITEXT.
+
+CREATE EXTENSION IF NOT EXISTS citext;
+
+-- Create users table
+CREATE TABLE IF NOT EXISTS tbl_users (
+    id SERIAL PRIMARY KEY,
+    first_name VARCHAR(100) NOT NULL,
+    last_name VARCHAR(100) NOT NULL,
+    age INT NOT NULL,
+    email CITEXT NOT NULL UNIQUE,
+    vacation_days INT NOT NULL DEFAULT 20,
+    non_paid_leave INT NOT NULL DEFAULT 0,
+    ts TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create vacations table
+CREATE TABLE IF NOT EXISTS tbl_vacations (
+    id SERIAL PRIMARY KEY,
+    label VARCHAR(255) NOT NULL,
+    from_date DATE NOT NULL,
+    to_date DATE NOT NULL,
+    person_id INT NOT NULL,
+    ts TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    days_used INT NOT NULL DEFAULT 0,
+    FOREIGN KEY (person_id) REFERENCES tbl_users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_person_id ON tbl_vacations (person_id);
+CREATE INDEX IF NOT EXISTS idx_dates ON tbl_vacations (from_date, to_date);
@@ -0,0 +1,2 @@
+DROP TABLE tbl_holidays;
+ALTER TABLE tbl_users DROP COLUMN holiday_calendar;
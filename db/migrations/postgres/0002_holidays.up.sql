@@ -0,0 +1,19 @@
+-- Adds public holiday calendars and the calendar each user observes.
+
+ALTER TABLE tbl_users ADD COLUMN holiday_calendar VARCHAR(32) NOT NULL DEFAULT '';
+
+-- Create holidays table
+-- calendar is a country code ("DE") or a region code ("DE-BY"); region calendars
+-- also observe their country's holidays. source = 'manual' marks admin overrides.
+CREATE TABLE tbl_holidays (
+    id SERIAL PRIMARY KEY,
+    calendar VARCHAR(32) NOT NULL,
+    holiday_date DATE NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    observed BOOLEAN NOT NULL DEFAULT TRUE,
+    source VARCHAR(16) NOT NULL DEFAULT 'manual',
+    ts TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT uq_calendar_date UNIQUE (calendar, holiday_date)
+);
+
+CREATE INDEX idx_holiday_date ON tbl_holidays (holiday_date);
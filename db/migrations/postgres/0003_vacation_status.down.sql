@@ -0,0 +1,2 @@
+DROP INDEX idx_person_status;
+ALTER TABLE tbl_vacations DROP COLUMN status;
@@ -0,0 +1,6 @@
+ALTER TABLE tbl_users
+    DROP COLUMN manager_id,
+    DROP COLUMN team_id;
+
+DROP TABLE tbl_teams;
+DROP TABLE tbl_departments;
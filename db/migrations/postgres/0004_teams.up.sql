@@ -0,0 +1,22 @@
+-- Adds departments and teams, and the manager and team of each user. Names
+-- MySQL's collation compares case-insensitively are CITEXT.
+
+-- Create departments table
+CREATE TABLE tbl_departments (
+    id SERIAL PRIMARY KEY,
+    name CITEXT NOT NULL UNIQUE,
+    ts TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create teams table
+CREATE TABLE tbl_teams (
+    id SERIAL PRIMARY KEY,
+    name CITEXT NOT NULL UNIQUE,
+    department_id INT NULL,
+    ts TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (department_id) REFERENCES tbl_departments(id) ON DELETE SET NULL
+);
+
+ALTER TABLE tbl_users
+    ADD COLUMN manager_id INT NULL REFERENCES tbl_users(id) ON DELETE SET NULL,
+    ADD COLUMN team_id INT NULL REFERENCES tbl_teams(id) ON DELETE SET NULL;
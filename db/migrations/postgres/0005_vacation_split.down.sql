@@ -0,0 +1,3 @@
+ALTER TABLE tbl_vacations
+    DROP COLUMN paid_days,
+    DROP COLUMN non_paid_days;
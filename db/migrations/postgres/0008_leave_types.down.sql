@@ -0,0 +1,9 @@
+DROP TABLE tbl_balances;
+
+ALTER TABLE tbl_ledger DROP CONSTRAINT fk_ledger_leave_type;
+
+ALTER TABLE tbl_vacations
+    DROP COLUMN leave_type,
+    DROP COLUMN document;
+
+DROP TABLE tbl_leave_types;
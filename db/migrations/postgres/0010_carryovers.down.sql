@@ -0,0 +1,2 @@
+DROP TABLE tbl_carryovers;
+DROP TABLE tbl_carryover_policies;
@@ -0,0 +1,27 @@
+-- Fractional amounts are rounded to whole days.
+
+ALTER TABLE tbl_carryovers
+    ALTER COLUMN balance TYPE INT,
+    ALTER COLUMN carried TYPE INT,
+    ALTER COLUMN forfeited TYPE INT,
+    ALTER COLUMN used TYPE INT,
+    ALTER COLUMN expired TYPE INT;
+
+ALTER TABLE tbl_carryover_policies ALTER COLUMN max_days TYPE INT;
+
+ALTER TABLE tbl_accrual_policies ALTER COLUMN annual_days TYPE INT;
+
+ALTER TABLE tbl_balances ALTER COLUMN balance TYPE INT;
+
+ALTER TABLE tbl_ledger ALTER COLUMN amount TYPE INT;
+
+ALTER TABLE tbl_vacations
+    DROP COLUMN portion,
+    DROP COLUMN hours,
+    ALTER COLUMN days_used TYPE INT,
+    ALTER COLUMN paid_days TYPE INT,
+    ALTER COLUMN non_paid_days TYPE INT;
+
+ALTER TABLE tbl_users
+    ALTER COLUMN vacation_days TYPE INT,
+    ALTER COLUMN non_paid_leave TYPE INT;
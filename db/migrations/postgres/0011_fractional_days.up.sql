@@ -0,0 +1,28 @@
+-- Counts days in thousandths for half-day and hourly leave, and records which
+-- half or how many hours a vacation takes.
+
+ALTER TABLE tbl_users
+    ALTER COLUMN vacation_days TYPE DECIMAL(9,3),
+    ALTER COLUMN non_paid_leave TYPE DECIMAL(9,3);
+
+ALTER TABLE tbl_vacations
+    ALTER COLUMN days_used TYPE DECIMAL(9,3),
+    ALTER COLUMN paid_days TYPE DECIMAL(9,3),
+    ALTER COLUMN non_paid_days TYPE DECIMAL(9,3),
+    ADD COLUMN portion VARCHAR(8) NOT NULL DEFAULT '',
+    ADD COLUMN hours DECIMAL(5,2) NOT NULL DEFAULT 0;
+
+ALTER TABLE tbl_ledger ALTER COLUMN amount TYPE DECIMAL(9,3);
+
+ALTER TABLE tbl_balances ALTER COLUMN balance TYPE DECIMAL(9,3);
+
+ALTER TABLE tbl_accrual_policies ALTER COLUMN annual_days TYPE DECIMAL(9,3);
+
+ALTER TABLE tbl_carryover_policies ALTER COLUMN max_days TYPE DECIMAL(9,3);
+
+ALTER TABLE tbl_carryovers
+    ALTER COLUMN balance TYPE DECIMAL(9,3),
+    ALTER COLUMN carried TYPE DECIMAL(9,3),
+    ALTER COLUMN forfeited TYPE DECIMAL(9,3),
+    ALTER COLUMN used TYPE DECIMAL(9,3),
+    ALTER COLUMN expired TYPE DECIMAL(9,3);
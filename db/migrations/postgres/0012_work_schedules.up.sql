@@ -0,0 +1,5 @@
+-- Adds each user's working days ('' = Monday to Friday) and FTE percentage.
+
+ALTER TABLE tbl_users
+    ADD COLUMN work_days VARCHAR(32) NOT NULL DEFAULT '',
+    ADD COLUMN fte INT NOT NULL DEFAULT 100;
@@ -0,0 +1,31 @@
+-- Adds password logins, web sessions and personal API tokens.
+
+ALTER TABLE tbl_users ADD COLUMN password_hash VARCHAR(255) NOT NULL DEFAULT '';
+
+-- Create sessions table
+-- Web UI logins; token_hash is the SHA-256 of the secret in the session cookie.
+CREATE TABLE tbl_sessions (
+    token_hash CHAR(64) PRIMARY KEY,
+    user_id INT NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    ts TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES tbl_users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_sessions_expiry ON tbl_sessions (expires_at);
+
+-- Create API tokens table
+-- Personal tokens for /api/v1; scopes is a comma-separated list of 'read' and 'write'.
+-- Revoked tokens are kept with revoked_at set.
+CREATE TABLE tbl_api_tokens (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    scopes VARCHAR(64) NOT NULL,
+    expires_at TIMESTAMP NULL,
+    last_used_at TIMESTAMP NULL,
+    revoked_at TIMESTAMP NULL,
+    ts TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES tbl_users(id) ON DELETE CASCADE
+);
@@ -0,0 +1,37 @@
+-- Vacation Tool initial schema, SQLite
+-- The tables of mysql/0001_initial.up.sql. Columns keep their MySQL types so
+-- dates and timestamps scan the same; text compared case-insensitively by
+-- MySQL's collation is declared NOCASE.
+--
+-- SQLite cannot add or drop a foreign key with ALTER TABLE, so later
+-- migrations rebuild a table to do so: they create it anew, copy the rows
+-- over, drop the old table and rename the new one. The migrator turns
+-- foreign key enforcement off while a migration runs and checks the keys
+-- before it commits.
+
+-- Create users table
+CREATE TABLE IF NOT EXISTS tbl_users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    first_name VARCHAR(100) NOT NULL,
+    last_name VARCHAR(100) NOT NULL,
+    age INT NOT NULL,
+    email VARCHAR(255) NOT NULL UNIQUE COLLATE NOCASE,
+    vacation_days INT NOT NULL DEFAULT 20,
+    non_paid_leave INT NOT NULL DEFAULT 0,
+    ts TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create vacations table
+CREATE TABLE IF NOT EXISTS tbl_vacations (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    label VARCHAR(255) NOT NULL,
+    from_date DATE NOT NULL,
+    to_date DATE NOT NULL,
+    person_id INT NOT NULL,
+    ts TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    days_used INT NOT NULL DEFAULT 0,
+    FOREIGN KEY (person_id) REFERENCES tbl_users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_person_id ON tbl_vacations (person_id);
+CREATE INDEX IF NOT EXISTS idx_dates ON tbl_vacations (from_date, to_date);
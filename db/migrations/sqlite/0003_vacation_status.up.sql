@@ -0,0 +1,8 @@
+-- Adds the approval status of vacations. Vacations booked before approvals
+-- existed had their days deducted on booking, so they count as approved.
+
+ALTER TABLE tbl_vacations ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'pending';
+
+CREATE INDEX idx_person_status ON tbl_vacations (person_id, status);
+
+UPDATE tbl_vacations SET status = 'approved';
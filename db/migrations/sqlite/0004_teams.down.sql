@@ -0,0 +1,22 @@
+-- Rebuilds tbl_users without manager_id and team_id, as SQLite cannot drop
+-- columns with a foreign key.
+CREATE TABLE tbl_users_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    first_name VARCHAR(100) NOT NULL,
+    last_name VARCHAR(100) NOT NULL,
+    age INT NOT NULL,
+    email VARCHAR(255) NOT NULL UNIQUE COLLATE NOCASE,
+    vacation_days INT NOT NULL DEFAULT 20,
+    non_paid_leave INT NOT NULL DEFAULT 0,
+    ts TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    holiday_calendar VARCHAR(32) NOT NULL DEFAULT ''
+);
+
+INSERT INTO tbl_users_new (id, first_name, last_name, age, email, vacation_days, non_paid_leave, ts, holiday_calendar)
+    SELECT id, first_name, last_name, age, email, vacation_days, non_paid_leave, ts, holiday_calendar FROM tbl_users;
+
+DROP TABLE tbl_users;
+ALTER TABLE tbl_users_new RENAME TO tbl_users;
+
+DROP TABLE tbl_teams;
+DROP TABLE tbl_departments;
@@ -0,0 +1,20 @@
+-- Adds departments and teams, and the manager and team of each user.
+
+-- Create departments table
+CREATE TABLE tbl_departments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(255) NOT NULL UNIQUE COLLATE NOCASE,
+    ts TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create teams table
+CREATE TABLE tbl_teams (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(255) NOT NULL UNIQUE COLLATE NOCASE,
+    department_id INT NULL,
+    ts TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (department_id) REFERENCES tbl_departments(id) ON DELETE SET NULL
+);
+
+ALTER TABLE tbl_users ADD COLUMN manager_id INT NULL REFERENCES tbl_users(id) ON DELETE SET NULL;
+ALTER TABLE tbl_users ADD COLUMN team_id INT NULL REFERENCES tbl_teams(id) ON DELETE SET NULL;
@@ -0,0 +1,2 @@
+ALTER TABLE tbl_vacations DROP COLUMN paid_days;
+ALTER TABLE tbl_vacations DROP COLUMN non_paid_days;
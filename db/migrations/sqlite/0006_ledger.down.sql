@@ -0,0 +1 @@
+DROP TABLE tbl_ledger;
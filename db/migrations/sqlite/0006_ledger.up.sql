@@ -0,0 +1,18 @@
+-- Create ledger table
+-- Append-only record of every balance movement. vacation_days/non_paid_leave on
+-- tbl_users cache the per-user sums of amount for the paid and unpaid leave types.
+-- Neither user_id nor vacation_id has a foreign key: the ledger is history,
+-- and a deleted user or vacation keeps its rows here as it does in the audit log.
+CREATE TABLE tbl_ledger (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INT NOT NULL,
+    leave_type VARCHAR(32) NOT NULL,
+    amount INT NOT NULL,
+    reason VARCHAR(32) NOT NULL,
+    vacation_id INT NULL,
+    reference VARCHAR(255) NOT NULL DEFAULT '',
+    ts TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_ledger_user ON tbl_ledger (user_id, id);
+CREATE INDEX idx_ledger_vacation ON tbl_ledger (vacation_id);
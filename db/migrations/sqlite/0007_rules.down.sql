@@ -0,0 +1,4 @@
+DROP TABLE tbl_rules;
+
+ALTER TABLE tbl_vacations DROP COLUMN flagged;
+ALTER TABLE tbl_vacations DROP COLUMN flag_reason;
@@ -0,0 +1,51 @@
+DROP TABLE tbl_balances;
+
+-- Rebuild tbl_ledger without the foreign key on leave_type.
+CREATE TABLE tbl_ledger_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INT NOT NULL,
+    leave_type VARCHAR(32) NOT NULL,
+    amount INT NOT NULL,
+    reason VARCHAR(32) NOT NULL,
+    vacation_id INT NULL,
+    reference VARCHAR(255) NOT NULL DEFAULT '',
+    ts TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO tbl_ledger_new (id, user_id, leave_type, amount, reason, vacation_id, reference, ts)
+    SELECT id, user_id, leave_type, amount, reason, vacation_id, reference, ts FROM tbl_ledger;
+
+DROP TABLE tbl_ledger;
+ALTER TABLE tbl_ledger_new RENAME TO tbl_ledger;
+
+CREATE INDEX idx_ledger_user ON tbl_ledger (user_id, id);
+CREATE INDEX idx_ledger_vacation ON tbl_ledger (vacation_id);
+
+-- Rebuild tbl_vacations without leave_type and document.
+CREATE TABLE tbl_vacations_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    label VARCHAR(255) NOT NULL,
+    from_date DATE NOT NULL,
+    to_date DATE NOT NULL,
+    person_id INT NOT NULL,
+    ts TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    days_used INT NOT NULL DEFAULT 0,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    paid_days INT NOT NULL DEFAULT 0,
+    non_paid_days INT NOT NULL DEFAULT 0,
+    flagged BOOLEAN NOT NULL DEFAULT FALSE,
+    flag_reason VARCHAR(1024) NOT NULL DEFAULT '',
+    FOREIGN KEY (person_id) REFERENCES tbl_users(id) ON DELETE CASCADE
+);
+
+INSERT INTO tbl_vacations_new (id, label, from_date, to_date, person_id, ts, days_used, status, paid_days, non_paid_days, flagged, flag_reason)
+    SELECT id, label, from_date, to_date, person_id, ts, days_used, status, paid_days, non_paid_days, flagged, flag_reason FROM tbl_vacations;
+
+DROP TABLE tbl_vacations;
+ALTER TABLE tbl_vacations_new RENAME TO tbl_vacations;
+
+CREATE INDEX idx_person_id ON tbl_vacations (person_id);
+CREATE INDEX idx_dates ON tbl_vacations (from_date, to_date);
+CREATE INDEX idx_person_status ON tbl_vacations (person_id, status);
+
+DROP TABLE tbl_leave_types;
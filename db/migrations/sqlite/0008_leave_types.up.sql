@@ -0,0 +1,59 @@
+-- Adds configurable leave types with their own balances. Existing vacations
+-- were paid vacation.
+
+-- Create leave types table
+-- fallback names the type charged once a balance runs out (NULL = the booking fails);
+-- unlimited types are never capped by their balance.
+CREATE TABLE tbl_leave_types (
+    code VARCHAR(32) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    fallback VARCHAR(32) NULL,
+    unlimited BOOLEAN NOT NULL DEFAULT FALSE,
+    requires_approval BOOLEAN NOT NULL DEFAULT TRUE,
+    requires_document BOOLEAN NOT NULL DEFAULT FALSE,
+    ts TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (fallback) REFERENCES tbl_leave_types(code) ON DELETE SET NULL
+);
+
+INSERT INTO tbl_leave_types (code, name, fallback, unlimited, requires_approval, requires_document) VALUES
+    ('unpaid', 'Unpaid leave', NULL, FALSE, TRUE, FALSE),
+    ('paid', 'Paid vacation', 'unpaid', FALSE, TRUE, FALSE),
+    ('sick', 'Sick leave', NULL, TRUE, FALSE, TRUE),
+    ('parental', 'Parental leave', NULL, FALSE, TRUE, TRUE),
+    ('comp', 'Comp time', 'paid', FALSE, TRUE, FALSE);
+
+ALTER TABLE tbl_vacations ADD COLUMN leave_type VARCHAR(32) NOT NULL DEFAULT 'paid' REFERENCES tbl_leave_types(code);
+ALTER TABLE tbl_vacations ADD COLUMN document VARCHAR(255) NOT NULL DEFAULT '';
+
+-- Rebuild tbl_ledger with a foreign key on leave_type.
+CREATE TABLE tbl_ledger_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INT NOT NULL,
+    leave_type VARCHAR(32) NOT NULL,
+    amount INT NOT NULL,
+    reason VARCHAR(32) NOT NULL,
+    vacation_id INT NULL,
+    reference VARCHAR(255) NOT NULL DEFAULT '',
+    ts TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (leave_type) REFERENCES tbl_leave_types(code)
+);
+
+INSERT INTO tbl_ledger_new (id, user_id, leave_type, amount, reason, vacation_id, reference, ts)
+    SELECT id, user_id, leave_type, amount, reason, vacation_id, reference, ts FROM tbl_ledger;
+
+DROP TABLE tbl_ledger;
+ALTER TABLE tbl_ledger_new RENAME TO tbl_ledger;
+
+CREATE INDEX idx_ledger_user ON tbl_ledger (user_id, id);
+CREATE INDEX idx_ledger_vacation ON tbl_ledger (vacation_id);
+
+-- Create balances table
+-- The ledger sums of every leave type but paid and unpaid, which tbl_users keeps.
+CREATE TABLE tbl_balances (
+    user_id INT NOT NULL,
+    leave_type VARCHAR(32) NOT NULL,
+    balance INT NOT NULL DEFAULT 0,
+    PRIMARY KEY (user_id, leave_type),
+    FOREIGN KEY (user_id) REFERENCES tbl_users(id) ON DELETE CASCADE,
+    FOREIGN KEY (leave_type) REFERENCES tbl_leave_types(code) ON DELETE CASCADE
+);
@@ -0,0 +1,17 @@
+-- Adds tenure-based accrual policies and the hire date tenure counts from.
+
+ALTER TABLE tbl_users ADD COLUMN hire_date DATE NULL;
+
+-- Create accrual policies table
+-- One row per tenure band: people with at least min_tenure_years of service earn
+-- annual_days of leave_type a year, credited monthly or every January 1.
+CREATE TABLE tbl_accrual_policies (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    leave_type VARCHAR(32) NOT NULL,
+    frequency VARCHAR(16) NOT NULL DEFAULT 'monthly',
+    min_tenure_years INT NOT NULL DEFAULT 0,
+    annual_days INT NOT NULL,
+    ts TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT uq_accrual_band UNIQUE (leave_type, min_tenure_years),
+    FOREIGN KEY (leave_type) REFERENCES tbl_leave_types(code) ON DELETE CASCADE
+);
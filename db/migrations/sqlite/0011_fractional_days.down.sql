@@ -0,0 +1,2 @@
+ALTER TABLE tbl_vacations DROP COLUMN portion;
+ALTER TABLE tbl_vacations DROP COLUMN hours;
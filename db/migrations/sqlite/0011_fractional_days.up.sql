@@ -0,0 +1,6 @@
+-- Records which half or how many hours a vacation takes. SQLite keeps 2.5 in
+-- an INT column as readily as in a DECIMAL one, so the day counts and balances
+-- hold fractions as they are.
+
+ALTER TABLE tbl_vacations ADD COLUMN portion VARCHAR(8) NOT NULL DEFAULT '';
+ALTER TABLE tbl_vacations ADD COLUMN hours DECIMAL(5,2) NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+ALTER TABLE tbl_users DROP COLUMN work_days;
+ALTER TABLE tbl_users DROP COLUMN fte;
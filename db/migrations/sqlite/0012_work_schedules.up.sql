@@ -0,0 +1,4 @@
+-- Adds each user's working days ('' = Monday to Friday) and FTE percentage.
+
+ALTER TABLE tbl_users ADD COLUMN work_days VARCHAR(32) NOT NULL DEFAULT '';
+ALTER TABLE tbl_users ADD COLUMN fte INT NOT NULL DEFAULT 100;
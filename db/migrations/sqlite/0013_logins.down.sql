@@ -0,0 +1,3 @@
+DROP TABLE tbl_api_tokens;
+DROP TABLE tbl_sessions;
+ALTER TABLE tbl_users DROP COLUMN password_hash;
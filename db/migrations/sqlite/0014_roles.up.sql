@@ -0,0 +1,3 @@
+-- Adds each user's role: employee, manager or hr_admin.
+
+ALTER TABLE tbl_users ADD COLUMN role VARCHAR(16) NOT NULL DEFAULT 'employee';
@@ -0,0 +1,21 @@
+-- Create audit log table
+-- One row per change made through the API or the web UI. actor_id has no foreign
+-- key so the history outlives deleted users; before_json and after_json are the
+-- entity's snapshots, NULL for creations and deletions respectively.
+CREATE TABLE tbl_audit_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    actor_id INT NULL,
+    actor_email VARCHAR(255) NOT NULL DEFAULT '',
+    action VARCHAR(32) NOT NULL,
+    entity VARCHAR(32) NOT NULL,
+    entity_id VARCHAR(64) NOT NULL DEFAULT '',
+    before_json TEXT NULL,
+    after_json TEXT NULL,
+    request_id VARCHAR(64) NOT NULL DEFAULT '',
+    ip VARCHAR(45) NOT NULL DEFAULT '',
+    ts TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_audit_entity ON tbl_audit_log (entity, entity_id);
+CREATE INDEX idx_audit_actor ON tbl_audit_log (actor_id);
+CREATE INDEX idx_audit_ts ON tbl_audit_log (ts);
@@ -0,0 +1,45 @@
+package storetest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/georgiwritescode/vacation-tool/types"
+)
+
+func testCanceledContext(t testing.TB, s Stores) {
+	user := newUser(t, s, 10, 0)
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	_, err := s.Users.FindById(canceled, user.ID)
+	checkErr(t, "FindById with a canceled context", err, context.Canceled)
+
+	_, _, err = s.Users.FindPage(canceled, types.UserFilter{Search: user.LastName, Page: types.Page{Limit: 10}})
+	checkErr(t, "FindPage with a canceled context", err, context.Canceled)
+
+	_, err = s.Vacations.CreateVacation(canceled, &types.Vacation{Label: unique("Canceled "), FromDate: "2030-11-04", ToDate: "2030-11-05", PersonId: user.ID, Timestamp: "2024-01-01 00:00:00", DaysUsed: 2})
+	checkErr(t, "CreateVacation with a canceled context", err, context.Canceled)
+
+	vacations, _, err := s.Vacations.FindPage(ctx, types.VacationFilter{PersonID: user.ID, Page: types.Page{Limit: 10}})
+	if err != nil {
+		t.Fatalf("FindPage: %v", err)
+	}
+	if len(vacations) != 0 {
+		t.Errorf("a canceled CreateVacation filed %v", vacationIDs(vacations))
+	}
+
+	vacation := newVacation(t, s, user.ID, "2030-11-11", "2030-11-12", 2, "")
+	err = s.Vacations.TransitionVacation(canceled, vacation.ID, types.StatusApproved)
+	checkErr(t, "TransitionVacation with a canceled context", err, context.Canceled)
+
+	got, err := s.Vacations.FindById(ctx, vacation.ID)
+	if err != nil {
+		t.Fatalf("FindById(%d): %v", vacation.ID, err)
+	}
+	if got.Status != types.StatusPending {
+		t.Errorf("a canceled approval left the request %s", got.Status)
+	}
+	checkBalances(t, s, user.ID, 10, 0)
+}
@@ -0,0 +1,182 @@
+// Package storetest is the conformance suite every storage backend passes: it
+// drives types.UserStore and types.VacationStore through the behaviour the
+// handlers rely on, from balance bookkeeping to list filters.
+//
+// The tests create their own users, with unique emails, and only look at what
+// they created, so they run against databases already holding data too.
+package storetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/georgiwritescode/vacation-tool/calendar"
+	"github.com/georgiwritescode/vacation-tool/types"
+)
+
+// Stores are the stores under test, sharing one database.
+type Stores struct {
+	Users     types.UserStore
+	Vacations types.VacationStore
+}
+
+// Test is one conformance test.
+type Test struct {
+	Name string
+	Run  func(testing.TB, Stores)
+}
+
+// Tests lists the conformance tests, for callers running each as a subtest.
+var Tests = []Test{
+	{"CreateUser", testCreateUser},
+	{"FindUserByEmail", testFindUserByEmail},
+	{"DuplicateEmail", testDuplicateEmail},
+	{"ListUsers", testListUsers},
+	{"FindReports", testFindReports},
+	{"UpdateUser", testUpdateUser},
+	{"DeleteUser", testDeleteUser},
+	{"CreateVacation", testCreateVacation},
+	{"PendingReservations", testPendingReservations},
+	{"ApproveAlongFallback", testApproveAlongFallback},
+	{"InsufficientBalance", testInsufficientBalance},
+	{"Overlap", testOverlap},
+	{"Transitions", testTransitions},
+	{"NoApprovalNeeded", testNoApprovalNeeded},
+	{"UpdateVacation", testUpdateVacation},
+	{"DeleteVacation", testDeleteVacation},
+	{"ListVacations", testListVacations},
+	{"VacationsBetween", testVacationsBetween},
+	{"CanceledContext", testCanceledContext},
+}
+
+// Run runs every conformance test against stores, in order, each as a subtest.
+func Run(t *testing.T, stores Stores) {
+	for _, test := range Tests {
+		t.Run(test.Name, func(t *testing.T) {
+			test.Run(t, stores)
+		})
+	}
+}
+
+// ctx is the context the tests make their store calls in.
+var ctx = context.Background()
+
+var sequence atomic.Int64
+
+// unique returns prefix followed by a suffix no other call returns, in this
+// process or an earlier run against the same database.
+func unique(prefix string) string {
+	return fmt.Sprintf("%s%x%x", prefix, time.Now().UnixNano(), sequence.Add(1))
+}
+
+// newUser creates a user holding paid days of paid leave and unpaid of unpaid
+// leave. Paid must not be zero, which grants the default entitlement.
+func newUser(t testing.TB, s Stores, paid, unpaid float64) *types.User {
+	t.Helper()
+	user := &types.User{
+		FirstName:    "Store",
+		LastName:     unique("Test"),
+		Age:          30,
+		Email:        unique("storetest") + "@example.com",
+		VacationDays: paid,
+		NonPaidLeave: unpaid,
+	}
+	return createUser(t, s, user)
+}
+
+func createUser(t testing.TB, s Stores, user *types.User) *types.User {
+	t.Helper()
+	id, err := s.Users.CreateUser(ctx, user)
+	if err != nil {
+		t.Fatalf("CreateUser(%s): %v", user.Email, err)
+	}
+	created, err := s.Users.FindById(ctx, id)
+	if err != nil {
+		t.Fatalf("FindById(%d) after CreateUser: %v", id, err)
+	}
+	return created
+}
+
+// newVacation files a request of days for the user, whole days from from to
+// to, drawing from leaveType (paid when empty).
+func newVacation(t testing.TB, s Stores, personID int, from, to string, days float64, leaveType string) *types.Vacation {
+	t.Helper()
+	vacation := &types.Vacation{
+		Label:     unique("Leave "),
+		FromDate:  from,
+		ToDate:    to,
+		PersonId:  personID,
+		Timestamp: "2024-01-01 00:00:00",
+		DaysUsed:  days,
+		LeaveType: leaveType,
+	}
+	id, err := s.Vacations.CreateVacation(ctx, vacation)
+	if err != nil {
+		t.Fatalf("CreateVacation(%s..%s, %g days): %v", from, to, days, err)
+	}
+	created, err := s.Vacations.FindById(ctx, id)
+	if err != nil {
+		t.Fatalf("FindById(%d) after CreateVacation: %v", id, err)
+	}
+	return created
+}
+
+// transition moves a vacation to status, failing the test on error.
+func transition(t testing.TB, s Stores, id int, status types.VacationStatus) {
+	t.Helper()
+	if err := s.Vacations.TransitionVacation(ctx, id, status); err != nil {
+		t.Fatalf("TransitionVacation(%d, %s): %v", id, status, err)
+	}
+}
+
+// checkBalances compares a user's paid and unpaid balances, both the fields
+// and the Balances map.
+func checkBalances(t testing.TB, s Stores, userID int, paid, unpaid float64) {
+	t.Helper()
+	user, err := s.Users.FindById(ctx, userID)
+	if err != nil {
+		t.Fatalf("FindById(%d): %v", userID, err)
+	}
+	if user.VacationDays != paid || user.NonPaidLeave != unpaid {
+		t.Errorf("user %d has %g paid and %g unpaid days, want %g and %g", userID, user.VacationDays, user.NonPaidLeave, paid, unpaid)
+	}
+	if user.Balances[types.LeavePaid] != paid || user.Balances[types.LeaveUnpaid] != unpaid {
+		t.Errorf("user %d balances are %v, want paid %g and unpaid %g", userID, user.Balances, paid, unpaid)
+	}
+}
+
+// checkErr fails unless err matches target with errors.Is.
+func checkErr(t testing.TB, what string, err, target error) {
+	t.Helper()
+	if !errors.Is(err, target) {
+		t.Errorf("%s: got error %v, want %v", what, err, target)
+	}
+}
+
+// sameDate compares dates ignoring how the backend formats them: MySQL hands
+// DATE columns back as RFC3339 timestamps.
+func sameDate(got, want string) bool {
+	a, errA := calendar.ParseDate(got)
+	b, errB := calendar.ParseDate(want)
+	return errA == nil && errB == nil && a.Equal(b)
+}
+
+func userIDs(users []*types.User) []int {
+	ids := make([]int, len(users))
+	for i, u := range users {
+		ids[i] = u.ID
+	}
+	return ids
+}
+
+func vacationIDs(vacations []*types.Vacation) []int {
+	ids := make([]int, len(vacations))
+	for i, v := range vacations {
+		ids[i] = v.ID
+	}
+	return ids
+}
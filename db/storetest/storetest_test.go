@@ -0,0 +1,32 @@
+package storetest_test
+
+import (
+	"testing"
+
+	"github.com/georgiwritescode/vacation-tool/db"
+	"github.com/georgiwritescode/vacation-tool/db/migrations"
+	"github.com/georgiwritescode/vacation-tool/db/storetest"
+	"github.com/georgiwritescode/vacation-tool/service/user"
+	"github.com/georgiwritescode/vacation-tool/service/vacation"
+)
+
+func TestMemoryStorage(t *testing.T) {
+	database, err := db.MemoryStorage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	if _, err := migrations.New(database).Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	stores := storetest.Stores{
+		Users:     user.NewStore(database),
+		Vacations: vacation.NewStore(database),
+	}
+	for _, test := range storetest.Tests {
+		t.Run(test.Name, func(t *testing.T) {
+			test.Run(t, stores)
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package storetest
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/georgiwritescode/vacation-tool/types"
+)
+
+func testCreateUser(t testing.TB, s Stores) {
+	want := &types.User{
+		FirstName:       "Ada",
+		LastName:        unique("Lovelace"),
+		Age:             36,
+		Email:           unique("ada") + "@example.com",
+		VacationDays:    12.5,
+		NonPaidLeave:    3,
+		HolidayCalendar: "DE-BY",
+		WorkDays:        "mon,tue,wed,thu",
+		FTE:             80,
+		HireDate:        "2020-03-15",
+		Role:            types.RoleManager,
+	}
+	got := createUser(t, s, want)
+
+	if got.ID == 0 || got.FirstName != want.FirstName || got.LastName != want.LastName || got.Age != want.Age || got.Email != want.Email {
+		t.Errorf("created user reads back as %+v, want %+v", got, want)
+	}
+	if got.HolidayCalendar != want.HolidayCalendar || got.WorkDays != want.WorkDays || got.FTE != want.FTE || got.Role != want.Role {
+		t.Errorf("created user's calendar, schedule and role read back as %q %q %d %q, want %q %q %d %q",
+			got.HolidayCalendar, got.WorkDays, got.FTE, got.Role, want.HolidayCalendar, want.WorkDays, want.FTE, want.Role)
+	}
+	if got.HireDate != want.HireDate {
+		t.Errorf("hire date reads back as %q, want %q", got.HireDate, want.HireDate)
+	}
+	if got.Timestamp == "" {
+		t.Errorf("created user has no timestamp")
+	}
+	if len(got.Vacations) != 0 {
+		t.Errorf("new user has %d vacations", len(got.Vacations))
+	}
+	checkBalances(t, s, got.ID, 12.5, 3)
+}
+
+func testFindUserByEmail(t testing.TB, s Stores) {
+	user := newUser(t, s, 10, 0)
+
+	found, err := s.Users.FindByEmail(ctx, user.Email)
+	if err != nil || found.ID != user.ID {
+		t.Errorf("FindByEmail(%s) = %v, %v, want user %d", user.Email, found, err, user.ID)
+	}
+
+	found, err = s.Users.FindByEmail(ctx, strings.ToUpper(user.Email))
+	if err != nil || found.ID != user.ID {
+		t.Errorf("emails are compared case-insensitively: FindByEmail(%s) = %v, %v, want user %d", strings.ToUpper(user.Email), found, err, user.ID)
+	}
+
+	_, err = s.Users.FindByEmail(ctx, unique("nobody")+"@example.com")
+	checkErr(t, "FindByEmail of an unknown email", err, types.ErrNotFound)
+
+	_, err = s.Users.FindById(ctx, -1)
+	checkErr(t, "FindById of an unknown id", err, types.ErrNotFound)
+}
+
+func testDuplicateEmail(t testing.TB, s Stores) {
+	user := newUser(t, s, 10, 0)
+
+	if _, err := s.Users.CreateUser(ctx, &types.User{FirstName: "Twin", LastName: "User", Age: 30, Email: user.Email, VacationDays: 1}); err == nil {
+		t.Errorf("CreateUser accepted the email %s twice", user.Email)
+	}
+}
+
+func testListUsers(t testing.TB, s Stores) {
+	tag := unique("List")
+	literal := createUser(t, s, &types.User{FirstName: "Wild_card", LastName: tag, Age: 30, Email: unique("wild") + "@example.com", VacationDays: 1})
+	other := createUser(t, s, &types.User{FirstName: "Wildxcard", LastName: tag, Age: 30, Email: unique("wild") + "@example.com", VacationDays: 1})
+	third := createUser(t, s, &types.User{FirstName: "Zed", LastName: tag, Age: 30, Email: unique("zed") + "@example.com", VacationDays: 1})
+
+	all, err := s.Users.FetchAllUsers(ctx)
+	if err != nil {
+		t.Fatalf("FetchAllUsers: %v", err)
+	}
+	for _, id := range []int{literal.ID, other.ID, third.ID} {
+		if !slices.Contains(userIDs(all), id) {
+			t.Errorf("FetchAllUsers misses user %d", id)
+		}
+	}
+
+	page := func(filter types.UserFilter) ([]int, int) {
+		t.Helper()
+		users, total, err := s.Users.FindPage(ctx, filter)
+		if err != nil {
+			t.Fatalf("FindPage(%+v): %v", filter, err)
+		}
+		return userIDs(users), total
+	}
+
+	got, total := page(types.UserFilter{Search: tag, Page: types.Page{Limit: 10, Sort: "first_name", Desc: true}})
+	if want := []int{third.ID, other.ID, literal.ID}; total != 3 || !slices.Equal(got, want) {
+		t.Errorf("searching the last name sorted by first name descending found %v of %d, want %v of 3", got, total, want)
+	}
+
+	got, total = page(types.UserFilter{Search: tag, Page: types.Page{Limit: 2, Offset: 2}})
+	if total != 3 || !slices.Equal(got, []int{third.ID}) {
+		t.Errorf("the second page of two found %v of %d, want [%d] of 3", got, total, third.ID)
+	}
+
+	got, _ = page(types.UserFilter{Search: "Wild_card " + tag, Page: types.Page{Limit: 10}})
+	if !slices.Equal(got, []int{literal.ID}) {
+		t.Errorf("searching %q found %v, want only [%d]: wildcards must match literally", "Wild_card "+tag, got, literal.ID)
+	}
+
+	got, _ = page(types.UserFilter{Search: other.Email, Page: types.Page{Limit: 10}})
+	if !slices.Equal(got, []int{other.ID}) {
+		t.Errorf("searching the email %s found %v, want [%d]", other.Email, got, other.ID)
+	}
+
+	got, total = page(types.UserFilter{Search: tag, UserIDs: []int{other.ID}, Page: types.Page{Limit: 10}})
+	if total != 1 || !slices.Equal(got, []int{other.ID}) {
+		t.Errorf("restricting to user %d found %v of %d", other.ID, got, total)
+	}
+
+	got, total = page(types.UserFilter{Search: tag, UserIDs: []int{}, Page: types.Page{Limit: 10}})
+	if total != 0 || len(got) != 0 {
+		t.Errorf("restricting to no users found %v of %d", got, total)
+	}
+}
+
+func testFindReports(t testing.TB, s Stores) {
+	manager := newUser(t, s, 10, 0)
+	first := createUser(t, s, &types.User{FirstName: "Report", LastName: unique("One"), Age: 30, Email: unique("report") + "@example.com", VacationDays: 1, ManagerID: manager.ID})
+	second := createUser(t, s, &types.User{FirstName: "Report", LastName: unique("Two"), Age: 30, Email: unique("report") + "@example.com", VacationDays: 1, ManagerID: manager.ID})
+	newUser(t, s, 10, 0)
+
+	if first.ManagerID != manager.ID {
+		t.Errorf("report's manager reads back as %d, want %d", first.ManagerID, manager.ID)
+	}
+
+	reports, err := s.Users.FindReports(ctx, manager.ID)
+	if err != nil {
+		t.Fatalf("FindReports(%d): %v", manager.ID, err)
+	}
+	got := userIDs(reports)
+	slices.Sort(got)
+	if want := []int{first.ID, second.ID}; !slices.Equal(got, want) {
+		t.Errorf("FindReports(%d) = %v, want %v", manager.ID, got, want)
+	}
+
+	reports, err = s.Users.FindReports(ctx, first.ID)
+	if err != nil || len(reports) != 0 {
+		t.Errorf("FindReports of a user managing nobody = %v, %v", userIDs(reports), err)
+	}
+}
+
+func testUpdateUser(t testing.TB, s Stores) {
+	manager := newUser(t, s, 10, 0)
+	user := newUser(t, s, 10, 2)
+
+	user.FirstName = "Renamed"
+	user.Email = unique("renamed") + "@example.com"
+	user.VacationDays = 15.5
+	user.NonPaidLeave = 1
+	user.ManagerID = manager.ID
+	user.HireDate = "2021-07-01"
+	user.FTE = 50
+	if err := s.Users.UpdateUser(ctx, user); err != nil {
+		t.Fatalf("UpdateUser: %v", err)
+	}
+
+	got, err := s.Users.FindById(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("FindById(%d): %v", user.ID, err)
+	}
+	if got.FirstName != "Renamed" || got.Email != user.Email || got.ManagerID != manager.ID || got.HireDate != "2021-07-01" || got.FTE != 50 {
+		t.Errorf("updated user reads back as %+v", got)
+	}
+	if got.Role != types.RoleEmployee {
+		t.Errorf("an update without a role changed it to %q", got.Role)
+	}
+	checkBalances(t, s, user.ID, 15.5, 1)
+
+	manager.ManagerID = user.ID
+	err = s.Users.UpdateUser(ctx, manager)
+	checkErr(t, "making a user's report their manager", err, types.ErrValidation)
+
+	err = s.Users.UpdateUser(ctx, &types.User{ID: -1, FirstName: "Nobody", LastName: "Here", Age: 30, Email: unique("nobody") + "@example.com"})
+	checkErr(t, "UpdateUser of an unknown id", err, types.ErrNotFound)
+}
+
+func testDeleteUser(t testing.TB, s Stores) {
+	user := newUser(t, s, 10, 0)
+	vacation := newVacation(t, s, user.ID, "2030-03-04", "2030-03-05", 2, "")
+	transition(t, s, vacation.ID, types.StatusApproved)
+
+	if err := s.Users.DeleteUser(ctx, user.ID); err != nil {
+		t.Fatalf("DeleteUser(%d): %v", user.ID, err)
+	}
+
+	_, err := s.Users.FindById(ctx, user.ID)
+	checkErr(t, "FindById of a deleted user", err, types.ErrNotFound)
+
+	_, err = s.Vacations.FindById(ctx, vacation.ID)
+	checkErr(t, "FindById of a deleted user's vacation", err, types.ErrNotFound)
+}
@@ -0,0 +1,317 @@
+package storetest
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/georgiwritescode/vacation-tool/types"
+)
+
+// sick is the leave type the initial migration seeds as unlimited and
+// approved without asking.
+const sick = "sick"
+
+func testCreateVacation(t testing.TB, s Stores) {
+	user := newUser(t, s, 10, 0)
+	got := newVacation(t, s, user.ID, "2030-08-05", "2030-08-07", 3, "")
+
+	if got.ID == 0 || got.PersonId != user.ID || got.DaysUsed != 3 || got.Label == "" {
+		t.Errorf("created vacation reads back as %+v", got)
+	}
+	if !sameDate(got.FromDate, "2030-08-05") || !sameDate(got.ToDate, "2030-08-07") {
+		t.Errorf("created vacation runs %s..%s, want 2030-08-05..2030-08-07", got.FromDate, got.ToDate)
+	}
+	if got.Status != types.StatusPending || got.LeaveType != types.LeavePaid {
+		t.Errorf("created vacation is %s %s leave, want pending paid leave", got.Status, got.LeaveType)
+	}
+	if got.Timestamp == "" {
+		t.Errorf("created vacation has no timestamp")
+	}
+	// Pending requests reserve their days without deducting them.
+	checkBalances(t, s, user.ID, 10, 0)
+
+	withVacations, err := s.Users.FindById(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("FindById(%d): %v", user.ID, err)
+	}
+	if !slices.Equal(vacationIDs(withVacations.Vacations), []int{got.ID}) {
+		t.Errorf("user %d lists vacations %v, want [%d]", user.ID, vacationIDs(withVacations.Vacations), got.ID)
+	}
+
+	_, err = s.Vacations.FindById(ctx, -1)
+	checkErr(t, "FindById of an unknown vacation", err, types.ErrNotFound)
+}
+
+func testPendingReservations(t testing.TB, s Stores) {
+	user := newUser(t, s, 5, 0)
+	newVacation(t, s, user.ID, "2030-09-02", "2030-09-05", 4, "")
+
+	_, err := s.Vacations.CreateVacation(ctx, &types.Vacation{Label: "Too much", FromDate: "2030-10-07", ToDate: "2030-10-08", PersonId: user.ID, Timestamp: "2024-01-01 00:00:00", DaysUsed: 2})
+	checkErr(t, "booking past the days a pending request reserves", err, types.ErrInsufficientBalance)
+
+	newVacation(t, s, user.ID, "2030-10-07", "2030-10-07", 1, "")
+	checkBalances(t, s, user.ID, 5, 0)
+}
+
+func testApproveAlongFallback(t testing.TB, s Stores) {
+	user := newUser(t, s, 5, 10)
+	vacation := newVacation(t, s, user.ID, "2030-08-05", "2030-08-14", 8, "")
+	transition(t, s, vacation.ID, types.StatusApproved)
+
+	// Paid leave falls back to unpaid leave once it runs out.
+	checkBalances(t, s, user.ID, 0, 7)
+
+	got, err := s.Vacations.FindById(ctx, vacation.ID)
+	if err != nil {
+		t.Fatalf("FindById(%d): %v", vacation.ID, err)
+	}
+	if got.Status != types.StatusApproved || got.PaidDays != 5 || got.NonPaidDays != 3 {
+		t.Errorf("approved vacation is %s with %g paid and %g unpaid days, want approved with 5 and 3", got.Status, got.PaidDays, got.NonPaidDays)
+	}
+}
+
+func testInsufficientBalance(t testing.TB, s Stores) {
+	user := newUser(t, s, 2, 0.5)
+
+	_, err := s.Vacations.CreateVacation(ctx, &types.Vacation{Label: "Too long", FromDate: "2030-08-05", ToDate: "2030-08-07", PersonId: user.ID, Timestamp: "2024-01-01 00:00:00", DaysUsed: 3})
+	checkErr(t, "booking more than the paid and unpaid balances", err, types.ErrInsufficientBalance)
+
+	_, err = s.Vacations.CreateVacation(ctx, &types.Vacation{Label: "Unknown", FromDate: "2030-08-05", ToDate: "2030-08-05", PersonId: user.ID, Timestamp: "2024-01-01 00:00:00", DaysUsed: 1, LeaveType: unique("none")})
+	checkErr(t, "booking an unknown leave type", err, types.ErrValidation)
+
+	vacation := newVacation(t, s, user.ID, "2030-08-05", "2030-08-07", 2.5, "")
+	transition(t, s, vacation.ID, types.StatusApproved)
+	checkBalances(t, s, user.ID, 0, 0)
+}
+
+func testOverlap(t testing.TB, s Stores) {
+	user := newUser(t, s, 20, 0)
+	first := newVacation(t, s, user.ID, "2030-08-05", "2030-08-09", 5, "")
+
+	_, err := s.Vacations.CreateVacation(ctx, &types.Vacation{Label: "Clash", FromDate: "2030-08-09", ToDate: "2030-08-12", PersonId: user.ID, Timestamp: "2024-01-01 00:00:00", DaysUsed: 2})
+	var overlap *types.OverlapError
+	if !errors.As(err, &overlap) || !slices.Equal(overlap.VacationIDs, []int{first.ID}) {
+		t.Errorf("overlapping request got error %v, want an OverlapError naming [%d]", err, first.ID)
+	}
+	checkErr(t, "overlapping request", err, types.ErrConflict)
+
+	// Another person may take the same days, and rejected requests free them.
+	other := newUser(t, s, 20, 0)
+	newVacation(t, s, other.ID, "2030-08-05", "2030-08-09", 5, "")
+	transition(t, s, first.ID, types.StatusRejected)
+	newVacation(t, s, user.ID, "2030-08-09", "2030-08-12", 2, "")
+
+	// Halves of a day only overlap themselves.
+	morning := &types.Vacation{Label: "Morning", FromDate: "2030-08-14", ToDate: "2030-08-14", PersonId: user.ID, Timestamp: "2024-01-01 00:00:00", DaysUsed: 0.5, Portion: types.PortionAM}
+	if _, err := s.Vacations.CreateVacation(ctx, morning); err != nil {
+		t.Fatalf("CreateVacation(morning): %v", err)
+	}
+	afternoon := *morning
+	afternoon.Label, afternoon.Portion = "Afternoon", types.PortionPM
+	if _, err := s.Vacations.CreateVacation(ctx, &afternoon); err != nil {
+		t.Errorf("afternoon after a morning off got error %v", err)
+	}
+	again := *morning
+	again.Label = "Morning again"
+	_, err = s.Vacations.CreateVacation(ctx, &again)
+	checkErr(t, "second morning off", err, types.ErrConflict)
+}
+
+func testTransitions(t testing.TB, s Stores) {
+	user := newUser(t, s, 10, 0)
+	vacation := newVacation(t, s, user.ID, "2030-08-05", "2030-08-07", 3, "")
+	transition(t, s, vacation.ID, types.StatusApproved)
+	checkBalances(t, s, user.ID, 7, 0)
+
+	err := s.Vacations.TransitionVacation(ctx, vacation.ID, types.StatusRejected)
+	checkErr(t, "rejecting an approved request", err, types.ErrInvalidTransition)
+	checkBalances(t, s, user.ID, 7, 0)
+
+	transition(t, s, vacation.ID, types.StatusCancelled)
+	checkBalances(t, s, user.ID, 10, 0)
+
+	got, err := s.Vacations.FindById(ctx, vacation.ID)
+	if err != nil {
+		t.Fatalf("FindById(%d): %v", vacation.ID, err)
+	}
+	if got.Status != types.StatusCancelled || got.PaidDays != 0 {
+		t.Errorf("cancelled vacation is %s holding %g paid days", got.Status, got.PaidDays)
+	}
+
+	err = s.Vacations.TransitionVacation(ctx, vacation.ID, types.StatusApproved)
+	checkErr(t, "approving a cancelled request", err, types.ErrInvalidTransition)
+
+	err = s.Vacations.TransitionVacation(ctx, -1, types.StatusApproved)
+	checkErr(t, "TransitionVacation of an unknown vacation", err, types.ErrNotFound)
+}
+
+func testNoApprovalNeeded(t testing.TB, s Stores) {
+	user := newUser(t, s, 10, 0)
+
+	got := newVacation(t, s, user.ID, "2030-08-05", "2030-08-16", 10, sick)
+	if got.Status != types.StatusApproved || got.LeaveType != sick {
+		t.Errorf("sick leave was filed as %s %s", got.Status, got.LeaveType)
+	}
+	checkBalances(t, s, user.ID, 10, 0)
+}
+
+func testUpdateVacation(t testing.TB, s Stores) {
+	user := newUser(t, s, 5, 10)
+	vacation := newVacation(t, s, user.ID, "2030-08-05", "2030-08-14", 8, "")
+	transition(t, s, vacation.ID, types.StatusApproved)
+	checkBalances(t, s, user.ID, 0, 7)
+
+	update := func(to string, days float64) *types.Vacation {
+		t.Helper()
+		vacation.ToDate, vacation.DaysUsed = to, days
+		if err := s.Vacations.UpdateVacation(ctx, vacation); err != nil {
+			t.Fatalf("UpdateVacation(%d) to %g days: %v", vacation.ID, days, err)
+		}
+		got, err := s.Vacations.FindById(ctx, vacation.ID)
+		if err != nil {
+			t.Fatalf("FindById(%d): %v", vacation.ID, err)
+		}
+		return got
+	}
+
+	// Shrinking refunds in the proportion the booking took the balances, 5:3,
+	// in whole days as the booking was whole days.
+	got := update("2030-08-08", 4)
+	checkBalances(t, s, user.ID, 2, 9)
+	if got.DaysUsed != 4 || !sameDate(got.ToDate, "2030-08-08") || got.PaidDays != 3 || got.NonPaidDays != 1 {
+		t.Errorf("shrunk vacation reads back as %g days to %s charging %g paid and %g unpaid, want 4 days to 2030-08-08 charging 3 and 1",
+			got.DaysUsed, got.ToDate, got.PaidDays, got.NonPaidDays)
+	}
+
+	// Growing charges the extra days along the fallback chain again.
+	got = update("2030-08-12", 6)
+	checkBalances(t, s, user.ID, 0, 9)
+	if got.PaidDays != 5 || got.NonPaidDays != 1 {
+		t.Errorf("grown vacation charges %g paid and %g unpaid days, want 5 and 1", got.PaidDays, got.NonPaidDays)
+	}
+
+	// Moving it to sick leave refunds it all and charges the new type.
+	vacation.LeaveType = sick
+	got = update("2030-08-12", 6)
+	checkBalances(t, s, user.ID, 5, 10)
+	if got.LeaveType != sick || got.PaidDays != 0 || got.NonPaidDays != 0 {
+		t.Errorf("vacation moved to sick leave is %s charging %g paid and %g unpaid days", got.LeaveType, got.PaidDays, got.NonPaidDays)
+	}
+
+	err := s.Vacations.UpdateVacation(ctx, &types.Vacation{ID: -1, Label: "Nothing", FromDate: "2030-08-05", ToDate: "2030-08-05", PersonId: user.ID, Timestamp: "2024-01-01 00:00:00", DaysUsed: 1})
+	checkErr(t, "UpdateVacation of an unknown vacation", err, types.ErrNotFound)
+}
+
+func testDeleteVacation(t testing.TB, s Stores) {
+	user := newUser(t, s, 5, 10)
+	approved := newVacation(t, s, user.ID, "2030-08-05", "2030-08-14", 8, "")
+	transition(t, s, approved.ID, types.StatusApproved)
+	pending := newVacation(t, s, user.ID, "2030-09-02", "2030-09-03", 2, "")
+
+	for _, id := range []int{approved.ID, pending.ID} {
+		if err := s.Vacations.DeleteVacation(ctx, id); err != nil {
+			t.Fatalf("DeleteVacation(%d): %v", id, err)
+		}
+		_, err := s.Vacations.FindById(ctx, id)
+		checkErr(t, "FindById of a deleted vacation", err, types.ErrNotFound)
+	}
+	checkBalances(t, s, user.ID, 5, 10)
+
+	err := s.Vacations.DeleteVacation(ctx, approved.ID)
+	checkErr(t, "deleting a vacation twice", err, types.ErrNotFound)
+}
+
+func testListVacations(t testing.TB, s Stores) {
+	user := newUser(t, s, 30, 0)
+	march := newVacation(t, s, user.ID, "2030-03-04", "2030-03-08", 5, "")
+	april := newVacation(t, s, user.ID, "2030-04-01", "2030-04-02", 2, "")
+	percent := &types.Vacation{Label: unique("100% off "), FromDate: "2030-05-06", ToDate: "2030-05-06", PersonId: user.ID, Timestamp: "2024-01-01 00:00:00", DaysUsed: 1}
+	may, err := s.Vacations.CreateVacation(ctx, percent)
+	if err != nil {
+		t.Fatalf("CreateVacation(%s): %v", percent.Label, err)
+	}
+	transition(t, s, april.ID, types.StatusApproved)
+
+	page := func(filter types.VacationFilter) ([]int, int) {
+		t.Helper()
+		if filter.Limit == 0 {
+			filter.Limit = 10
+		}
+		vacations, total, err := s.Vacations.FindPage(ctx, filter)
+		if err != nil {
+			t.Fatalf("FindPage(%+v): %v", filter, err)
+		}
+		return vacationIDs(vacations), total
+	}
+	check := func(what string, filter types.VacationFilter, want ...int) {
+		t.Helper()
+		if want == nil {
+			want = []int{}
+		}
+		got, total := page(filter)
+		if total != len(want) || !slices.Equal(got, want) {
+			t.Errorf("%s found %v of %d, want %v", what, got, total, want)
+		}
+	}
+
+	check("listing the person's requests", types.VacationFilter{PersonID: user.ID}, march.ID, april.ID, may)
+	check("sorting by start date descending", types.VacationFilter{PersonID: user.ID, Page: types.Page{Sort: "from_date", Desc: true}}, may, april.ID, march.ID)
+	check("filtering by status", types.VacationFilter{PersonID: user.ID, Status: types.StatusApproved}, april.ID)
+	check("filtering by dates", types.VacationFilter{PersonID: user.ID, FromDate: "2030-03-08", ToDate: "2030-04-01"}, march.ID, april.ID)
+	check("searching a label with a literal %", types.VacationFilter{PersonID: user.ID, Label: "100% off"}, may)
+	check("searching a label with a literal _", types.VacationFilter{PersonID: user.ID, Label: "100_ off"})
+	check("restricting to the person", types.VacationFilter{UserIDs: []int{user.ID}}, march.ID, april.ID, may)
+	check("restricting to no one", types.VacationFilter{PersonID: user.ID, UserIDs: []int{}})
+
+	got, total := page(types.VacationFilter{PersonID: user.ID, Page: types.Page{Limit: 2, Offset: 1}})
+	if total != 3 || !slices.Equal(got, []int{april.ID, may}) {
+		t.Errorf("a page of two from the second found %v of %d, want [%d %d] of 3", got, total, april.ID, may)
+	}
+}
+
+func testVacationsBetween(t testing.TB, s Stores) {
+	user := newUser(t, s, 30, 0)
+	approved := newVacation(t, s, user.ID, "2030-06-03", "2030-06-07", 5, "")
+	transition(t, s, approved.ID, types.StatusApproved)
+	pending := newVacation(t, s, user.ID, "2030-06-10", "2030-06-11", 2, "")
+
+	all, err := s.Vacations.FindAll(ctx)
+	if err != nil {
+		t.Fatalf("FindAll: %v", err)
+	}
+	if !slices.Contains(vacationIDs(all), approved.ID) || !slices.Contains(vacationIDs(all), pending.ID) {
+		t.Errorf("FindAll misses vacations %d or %d", approved.ID, pending.ID)
+	}
+
+	for _, tc := range []struct {
+		from, to string
+		want     bool
+	}{
+		{"2030-06-03", "2030-06-03", true},
+		{"2030-06-07", "2030-06-07", true},
+		{"2030-06-01", "2030-06-30", true},
+		{"2030-06-08", "2030-06-30", false},
+		{"2030-05-01", "2030-06-02", false},
+	} {
+		vacations, err := s.Vacations.GetVacationsBetween(ctx, tc.from, tc.to)
+		if err != nil {
+			t.Fatalf("GetVacationsBetween(%s, %s): %v", tc.from, tc.to, err)
+		}
+		got := vacationIDs(vacations)
+		if slices.Contains(got, approved.ID) != tc.want {
+			t.Errorf("GetVacationsBetween(%s, %s) lists approved vacation %d: %t, want %t", tc.from, tc.to, approved.ID, !tc.want, tc.want)
+		}
+		if slices.Contains(got, pending.ID) {
+			t.Errorf("GetVacationsBetween(%s, %s) lists pending vacation %d", tc.from, tc.to, pending.ID)
+		}
+	}
+
+	active, err := s.Vacations.GetActiveVacations(ctx, "2030-06-05")
+	if err != nil {
+		t.Fatalf("GetActiveVacations: %v", err)
+	}
+	if !slices.Contains(vacationIDs(active), approved.ID) {
+		t.Errorf("GetActiveVacations(2030-06-05) misses vacation %d", approved.ID)
+	}
+}
@@ -0,0 +1,102 @@
+package hierarchy
+
+import (
+	"context"
+	"errors"
+
+	"github.com/georgiwritescode/vacation-tool/types"
+)
+
+var ErrNoApprover = errors.New("no manager to approve the request")
+
+// ResolveApprover returns who should approve a leave request filed by personID.
+// That is their manager, unless the manager is on vacation on date, in which case
+// the request skips up the chain to the first manager who is in. If the whole
+// chain is away the direct manager is returned so the request is never orphaned.
+func ResolveApprover(ctx context.Context, users types.UserStore, vacations types.VacationStore, personID int, date string) (*types.User, error) {
+	requester, err := users.FindById(ctx, personID)
+	if err != nil {
+		return nil, err
+	}
+	if requester.ManagerID == 0 {
+		return nil, ErrNoApprover
+	}
+
+	active, err := vacations.GetActiveVacations(ctx, date)
+	if err != nil {
+		return nil, err
+	}
+	away := make(map[int]bool)
+	for _, v := range active {
+		away[v.PersonId] = true
+	}
+
+	var direct *types.User
+	seen := map[int]bool{personID: true}
+	for id := requester.ManagerID; id != 0 && !seen[id]; {
+		seen[id] = true
+
+		manager, err := users.FindById(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if direct == nil {
+			direct = manager
+		}
+		if !away[manager.ID] {
+			return manager, nil
+		}
+
+		id = manager.ManagerID
+	}
+
+	return direct, nil
+}
+
+// Manages reports whether managerID is somewhere above personID in the
+// reporting line, not necessarily their direct manager.
+func Manages(ctx context.Context, users types.UserStore, managerID, personID int) (bool, error) {
+	person, err := users.FindById(ctx, personID)
+	if err != nil {
+		return false, err
+	}
+
+	seen := map[int]bool{personID: true}
+	for id := person.ManagerID; id != 0 && !seen[id]; {
+		if id == managerID {
+			return true, nil
+		}
+		seen[id] = true
+
+		manager, err := users.FindById(ctx, id)
+		if err != nil {
+			return false, err
+		}
+		id = manager.ManagerID
+	}
+
+	return false, nil
+}
+
+// Subordinates returns the ids of everyone below managerID in the reporting
+// lines: their reports, their reports' reports and so on.
+func Subordinates(ctx context.Context, users types.UserStore, managerID int) ([]int, error) {
+	var ids []int
+	seen := map[int]bool{managerID: true}
+	for queue := []int{managerID}; len(queue) > 0; queue = queue[1:] {
+		reports, err := users.FindReports(ctx, queue[0])
+		if err != nil {
+			return nil, err
+		}
+
+		for _, report := range reports {
+			if !seen[report.ID] {
+				seen[report.ID] = true
+				ids = append(ids, report.ID)
+				queue = append(queue, report.ID)
+			}
+		}
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/georgiwritescode/vacation-tool/service/audit"
+	"github.com/georgiwritescode/vacation-tool/service/auth"
+	"github.com/georgiwritescode/vacation-tool/types"
+	"github.com/georgiwritescode/vacation-tool/utils"
+)
+
+// Auth authenticates every request except the public routes, given as
+// "METHOD /path". /api/v1 requests need an API token with a scope covering
+// the method in an "Authorization: Bearer" header; the web UI needs a session
+// cookie and sends anyone without one to the login page.
+func Auth(store types.AuthStore, users types.UserStore, public ...string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if slices.Contains(public, r.Method+" "+r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			api := strings.HasPrefix(r.URL.Path, "/api/")
+
+			var principal *auth.Principal
+			var err error
+			if api {
+				principal, err = tokenPrincipal(r, store, users)
+			} else {
+				principal, err = sessionPrincipal(r, store, users)
+			}
+
+			switch {
+			case errors.Is(err, types.ErrNotFound) && api:
+				w.Header().Set("WWW-Authenticate", `Bearer realm="vacation-tool"`)
+				utils.WriteError(w, http.StatusUnauthorized, errors.New("a valid API token is required"))
+			case errors.Is(err, types.ErrNotFound):
+				http.Redirect(w, r, "/login?next="+url.QueryEscape(r.URL.RequestURI()), http.StatusSeeOther)
+			case err != nil:
+				utils.WriteError(w, http.StatusInternalServerError, err)
+			case api && !principal.Token.Allows(requiredScope(r)):
+				utils.WriteError(w, http.StatusForbidden, errors.New("the API token lacks the "+requiredScope(r)+" scope"))
+			default:
+				// The user is also the actor of the changes the audit log records
+				ctx := audit.WithActor(r.Context(), principal.User.ID, principal.User.Email)
+				next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(ctx, principal)))
+			}
+		})
+	}
+}
+
+// tokenPrincipal authenticates an API request by its bearer token.
+func tokenPrincipal(r *http.Request, store types.AuthStore, users types.UserStore) (*auth.Principal, error) {
+	secret, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || secret == "" {
+		return nil, types.ErrNotFound
+	}
+
+	token, err := store.FindToken(r.Context(), auth.HashSecret(secret))
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := users.FindById(r.Context(), token.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return &auth.Principal{User: user, Token: token}, nil
+}
+
+// sessionPrincipal authenticates a web request by its session cookie.
+func sessionPrincipal(r *http.Request, store types.AuthStore, users types.UserStore) (*auth.Principal, error) {
+	cookie, err := r.Cookie(auth.SessionCookie)
+	if err != nil {
+		return nil, types.ErrNotFound
+	}
+
+	userID, err := store.FindSession(r.Context(), auth.HashSecret(cookie.Value))
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := users.FindById(r.Context(), userID)
+	if err != nil {
+		return nil, err
+	}
+	return &auth.Principal{User: user}, nil
+}
+
+// requiredScope is the token scope a request's method needs.
+func requiredScope(r *http.Request) string {
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		return types.ScopeRead
+	}
+	return types.ScopeWrite
+}
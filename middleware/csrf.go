@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/georgiwritescode/vacation-tool/service/auth"
+	"github.com/georgiwritescode/vacation-tool/utils"
+)
+
+// CSRF rejects state-changing web requests that don't carry their session's
+// CSRF token, in the form or the X-CSRF-Token header. /api/v1 requests are
+// exempt: they authenticate with a bearer token, which browsers never send on
+// their own. So are requests without a session, such as logging in.
+func CSRF(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		want := auth.CSRFToken(r)
+		if strings.HasPrefix(r.URL.Path, "/api/") || want == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		got := r.Header.Get(auth.CSRFHeader)
+		if got == "" {
+			got = r.PostFormValue(auth.CSRFField)
+		}
+		if subtle.ConstantTimeCompare([]byte(got), []byte(want)) != 1 {
+			utils.WriteError(w, http.StatusForbidden, errors.New("the form is missing its CSRF token or is from another site; reload the page and try again"))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -4,6 +4,8 @@ import (
 	"log"
 	"net/http"
 	"time"
+
+	"github.com/georgiwritescode/vacation-tool/service/audit"
 )
 
 type wrappedWriter struct {
@@ -26,6 +28,6 @@ func Loogging(h http.Handler) http.Handler {
 		}
 
 		h.ServeHTTP(wrapped, r)
-		log.Println(wrapped.statusCode, r.Method, r.URL.Path, time.Since(start))
+		log.Println(wrapped.statusCode, r.Method, r.URL.Path, time.Since(start), audit.RequestID(r))
 	})
 }
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"regexp"
+
+	"github.com/georgiwritescode/vacation-tool/service/audit"
+)
+
+// validRequestID limits the request ids taken from clients to something safe
+// to log and store.
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// RequestID gives every request an id, the client's X-Request-ID if it sent a
+// sane one, echoes it in the response and attaches it with the client's IP
+// to the context for the audit log.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(audit.RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			b := make([]byte, 8)
+			rand.Read(b)
+			id = hex.EncodeToString(b)
+		}
+
+		w.Header().Set(audit.RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(audit.WithRequest(r.Context(), id, clientIP(r))))
+	})
+}
+
+// clientIP is the address the request came from, without its port.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,143 @@
+package oidc_test
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/georgiwritescode/vacation-tool/oidc"
+	"github.com/georgiwritescode/vacation-tool/oidc/oidctest"
+)
+
+const redirectURL = "http://vt.test/login/sso/callback"
+
+func newIssuer(t *testing.T) *oidctest.Issuer {
+	t.Helper()
+	iss, err := oidctest.NewIssuer("vt", "secret")
+	if err != nil {
+		t.Fatalf("NewIssuer: %v", err)
+	}
+	t.Cleanup(iss.Close)
+	return iss
+}
+
+// authorize logs in at the issuer and returns the code it redirects back with.
+func authorize(t *testing.T, client *oidc.Client, state, nonce, verifier string) string {
+	t.Helper()
+	target, err := client.AuthCodeURL(state, nonce, verifier)
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+
+	noRedirect := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := noRedirect.Get(target)
+	if err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("authorize: status %d", resp.StatusCode)
+	}
+
+	back, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatalf("authorize redirect: %v", err)
+	}
+	if !strings.HasPrefix(back.String(), redirectURL) || back.Query().Get("state") != state {
+		t.Fatalf("authorize redirected to %s", back)
+	}
+	return back.Query().Get("code")
+}
+
+func TestExchange(t *testing.T) {
+	iss := newIssuer(t)
+	iss.SetClaims(map[string]any{"email": "ada@example.com", "email_verified": true, "groups": []string{"hr", "staff"}})
+	client := oidc.New(iss.Config(redirectURL), nil)
+
+	code := authorize(t, client, "state", "nonce", "verifier")
+	claims, err := client.Exchange(code, "verifier", "nonce")
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if claims.Email != "ada@example.com" || claims.EmailVerified == nil || !*claims.EmailVerified {
+		t.Errorf("claims = %+v", claims)
+	}
+	if groups := claims.Strings("groups"); len(groups) != 2 || groups[0] != "hr" {
+		t.Errorf("groups = %v", groups)
+	}
+
+	if _, err := client.Exchange(code, "verifier", "nonce"); err == nil {
+		t.Error("a used code was exchanged again")
+	}
+}
+
+func TestExchangeWrongVerifier(t *testing.T) {
+	iss := newIssuer(t)
+	iss.SetClaims(map[string]any{"email": "ada@example.com"})
+	client := oidc.New(iss.Config(redirectURL), nil)
+
+	code := authorize(t, client, "state", "nonce", "verifier")
+	if _, err := client.Exchange(code, "another verifier", "nonce"); err == nil {
+		t.Error("Exchange accepted a code with the wrong PKCE verifier")
+	}
+}
+
+func TestExchangeWrongNonce(t *testing.T) {
+	iss := newIssuer(t)
+	iss.SetClaims(map[string]any{"email": "ada@example.com"})
+	client := oidc.New(iss.Config(redirectURL), nil)
+
+	code := authorize(t, client, "state", "nonce", "verifier")
+	if _, err := client.Exchange(code, "verifier", "replayed"); !errors.Is(err, oidc.ErrInvalidToken) {
+		t.Errorf("Exchange with the wrong nonce = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestVerifyUnknownKeyRefetchesOnce(t *testing.T) {
+	iss := newIssuer(t)
+
+	var jwksFetches atomic.Int32
+	transport := roundTripper(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Path == "/jwks" {
+			jwksFetches.Add(1)
+		}
+		return http.DefaultTransport.RoundTrip(req)
+	})
+	client := oidc.New(iss.Config(redirectURL), &http.Client{Transport: transport})
+
+	for _, kid := range []string{"forged-1", "forged-2", "forged-3"} {
+		if _, err := client.Verify(unsignedToken(kid), ""); !errors.Is(err, oidc.ErrInvalidToken) {
+			t.Errorf("Verify with key %q = %v, want ErrInvalidToken", kid, err)
+		}
+	}
+	if n := jwksFetches.Load(); n != 1 {
+		t.Errorf("fetched the JWKS %d times, want 1", n)
+	}
+
+	// The real key was cached by the one fetch.
+	token, err := iss.SignToken(map[string]any{"iss": iss.URL, "aud": "vt", "exp": 1 << 40, "nonce": "n"})
+	if err != nil {
+		t.Fatalf("SignToken: %v", err)
+	}
+	if _, err := client.Verify(token, "n"); err != nil {
+		t.Errorf("Verify: %v", err)
+	}
+	if n := jwksFetches.Load(); n != 1 {
+		t.Errorf("fetched the JWKS %d times, want 1", n)
+	}
+}
+
+type roundTripper func(*http.Request) (*http.Response, error)
+
+func (f roundTripper) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+// unsignedToken is an RS256 token header naming kid, with an empty payload
+// and a garbage signature.
+func unsignedToken(kid string) string {
+	segment := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+	return segment(`{"alg":"RS256","kid":"`+kid+`"}`) + "." + segment(`{}`) + "." + segment("signature")
+}
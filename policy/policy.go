@@ -0,0 +1,135 @@
+// Package policy decides what the authenticated user may do. Employees see
+// and edit their own requests, managers also see their reports and decide
+// their requests, and HR admins may do everything but decide their own
+// requests. Every check returns nil, an error wrapping types.ErrForbidden, or
+// the store error that kept it from deciding, which callers must not report
+// as forbidden.
+package policy
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/georgiwritescode/vacation-tool/hierarchy"
+	"github.com/georgiwritescode/vacation-tool/types"
+)
+
+type Policy struct {
+	users types.UserStore
+}
+
+func New(users types.UserStore) *Policy {
+	return &Policy{users: users}
+}
+
+func forbidden(format string, args ...any) error {
+	return fmt.Errorf("%w: "+format, append([]any{types.ErrForbidden}, args...)...)
+}
+
+// IsAdmin reports whether actor is an HR admin.
+func IsAdmin(actor *types.User) bool {
+	return actor != nil && actor.Role == types.RoleHRAdmin
+}
+
+// VisibleUsers returns the ids of the users whose profiles and requests actor
+// may see, for restricting lists. nil means everyone.
+func (p *Policy) VisibleUsers(ctx context.Context, actor *types.User) ([]int, error) {
+	if actor == nil {
+		return []int{}, nil
+	}
+	if IsAdmin(actor) {
+		return nil, nil
+	}
+	if actor.Role != types.RoleManager {
+		return []int{actor.ID}, nil
+	}
+
+	reports, err := hierarchy.Subordinates(ctx, p.users, actor.ID)
+	if err != nil {
+		return nil, err
+	}
+	return append([]int{actor.ID}, reports...), nil
+}
+
+// ViewUser allows seeing a user's profile and requests: your own, your
+// reports' as a manager, or anyone's as an HR admin.
+func (p *Policy) ViewUser(ctx context.Context, actor *types.User, userID int) error {
+	switch {
+	case actor == nil:
+		return forbidden("not logged in")
+	case IsAdmin(actor), actor.ID == userID:
+		return nil
+	case actor.Role == types.RoleManager:
+		manages, err := hierarchy.Manages(ctx, p.users, actor.ID, userID)
+		if err != nil {
+			return fmt.Errorf("checking whether %d reports to %d: %w", userID, actor.ID, err)
+		}
+		if manages {
+			return nil
+		}
+	}
+	return forbidden("user %d is not you or one of your reports", userID)
+}
+
+// ManageUsers allows creating, editing and deleting users, adjusting
+// balances and changing settings. Only HR admins may.
+func (p *Policy) ManageUsers(actor *types.User) error {
+	if !IsAdmin(actor) {
+		return forbidden("only HR admins manage users, balances and settings")
+	}
+	return nil
+}
+
+// FileVacation allows requesting leave for personID: for yourself, or for
+// anyone as an HR admin.
+func (p *Policy) FileVacation(actor *types.User, personID int) error {
+	if actor == nil || (actor.ID != personID && !IsAdmin(actor)) {
+		return forbidden("you can only request leave for yourself")
+	}
+	return nil
+}
+
+// EditVacation allows changing or deleting a request: your own, or anyone's
+// as an HR admin.
+func (p *Policy) EditVacation(actor *types.User, vacation *types.Vacation) error {
+	return p.FileVacation(actor, vacation.PersonId)
+}
+
+// DecideVacation allows approving or rejecting a request: a report's as a
+// manager, or anyone else's as an HR admin. Nobody decides their own.
+func (p *Policy) DecideVacation(ctx context.Context, actor *types.User, vacation *types.Vacation) error {
+	switch {
+	case actor == nil:
+		return forbidden("not logged in")
+	case actor.ID == vacation.PersonId:
+		return forbidden("you cannot decide your own request")
+	case IsAdmin(actor):
+		return nil
+	case actor.Role == types.RoleManager:
+		manages, err := hierarchy.Manages(ctx, p.users, actor.ID, vacation.PersonId)
+		if err != nil {
+			return fmt.Errorf("checking whether %d reports to %d: %w", vacation.PersonId, actor.ID, err)
+		}
+		if manages {
+			return nil
+		}
+	}
+	return forbidden("only the requester's managers or HR admins decide a request")
+}
+
+// CancelVacation allows cancelling a request: your own, or one you may decide.
+func (p *Policy) CancelVacation(ctx context.Context, actor *types.User, vacation *types.Vacation) error {
+	if actor != nil && actor.ID == vacation.PersonId {
+		return nil
+	}
+	return p.DecideVacation(ctx, actor, vacation)
+}
+
+// Transition checks moving a request to status: cancelling with
+// CancelVacation, approving and rejecting with DecideVacation.
+func (p *Policy) Transition(ctx context.Context, actor *types.User, vacation *types.Vacation, status types.VacationStatus) error {
+	if status == types.StatusCancelled {
+		return p.CancelVacation(ctx, actor, vacation)
+	}
+	return p.DecideVacation(ctx, actor, vacation)
+}
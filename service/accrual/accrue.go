@@ -0,0 +1,64 @@
+package accrual
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/georgiwritescode/vacation-tool/types"
+)
+
+// Reference tags the ledger entries of one leave year's accruals, so reruns
+// can tell what has been credited already.
+func Reference(year int) string {
+	return fmt.Sprintf("accrual %d", year)
+}
+
+// Due works out how many days someone hired on hire has earned in asOf's year
+// up to and including asOf, given the tenure bands of one leave type. People
+// who join during the year earn from their joining month on.
+func Due(bands []*types.AccrualPolicy, hire, asOf time.Time) float64 {
+	if hire.After(asOf) {
+		return 0
+	}
+
+	band := bandFor(bands, TenureYears(hire, asOf))
+	if band == nil {
+		return 0
+	}
+
+	firstMonth := time.January
+	if hire.Year() == asOf.Year() {
+		firstMonth = hire.Month()
+	}
+
+	var months int
+	switch band.Frequency {
+	case types.AccrueAnnual:
+		// The whole year is granted up front, prorated for joiners
+		months = int(time.December-firstMonth) + 1
+	default:
+		months = int(asOf.Month()-firstMonth) + 1
+	}
+
+	return types.RoundDays(band.AnnualDays * float64(months) / 12)
+}
+
+// TenureYears counts the full years of service between hire and asOf.
+func TenureYears(hire, asOf time.Time) int {
+	years := asOf.Year() - hire.Year()
+	if asOf.Month() < hire.Month() || (asOf.Month() == hire.Month() && asOf.Day() < hire.Day()) {
+		years--
+	}
+	return max(years, 0)
+}
+
+// bandFor picks the band with the highest minimum tenure that tenure reaches.
+func bandFor(bands []*types.AccrualPolicy, tenure int) *types.AccrualPolicy {
+	var best *types.AccrualPolicy
+	for _, b := range bands {
+		if b.MinTenureYears <= tenure && (best == nil || b.MinTenureYears > best.MinTenureYears) {
+			best = b
+		}
+	}
+	return best
+}
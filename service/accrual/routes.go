@@ -0,0 +1,134 @@
+package accrual
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/georgiwritescode/vacation-tool/calendar"
+	"github.com/georgiwritescode/vacation-tool/service/auth"
+	"github.com/georgiwritescode/vacation-tool/types"
+	"github.com/georgiwritescode/vacation-tool/utils"
+)
+
+type Handler struct {
+	store types.AccrualStore
+}
+
+func NewHandler(store types.AccrualStore) *Handler {
+	return &Handler{store: store}
+}
+
+func (h *Handler) RegisterRoutes(router *http.ServeMux) {
+	router.HandleFunc("GET /api/v1/accruals/policies", h.HandleListPolicies)
+	router.HandleFunc("POST /api/v1/accruals/policies/create", auth.AdminOnly(h.HandleCreatePolicy))
+	router.HandleFunc("PUT /api/v1/accruals/policies/update", auth.AdminOnly(h.HandleUpdatePolicy))
+	router.HandleFunc("DELETE /api/v1/accruals/policies/delete/{id}", auth.AdminOnly(h.HandleDeletePolicy))
+	router.HandleFunc("POST /api/v1/accruals/run", auth.AdminOnly(h.HandleRun))
+}
+
+func (h *Handler) HandleListPolicies(w http.ResponseWriter, r *http.Request) {
+	policies, err := h.store.FindAll(r.Context())
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, policies)
+}
+
+func (h *Handler) HandleCreatePolicy(w http.ResponseWriter, r *http.Request) {
+	var policy types.AccrualPolicy
+	if err := utils.ParseJSON(r, &policy); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := validate(&policy); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	id, err := h.store.CreatePolicy(r.Context(), &policy)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, map[string]int{"id": id})
+}
+
+func (h *Handler) HandleUpdatePolicy(w http.ResponseWriter, r *http.Request) {
+	var policy types.AccrualPolicy
+	if err := utils.ParseJSON(r, &policy); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := validate(&policy); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.store.UpdatePolicy(r.Context(), &policy); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"status": "updated"})
+}
+
+func (h *Handler) HandleDeletePolicy(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid policy id: %v", err))
+		return
+	}
+
+	if err := h.store.DeletePolicy(r.Context(), id); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// HandleRun accrues up to ?as_of=YYYY-MM-DD, today by default, and lists what was credited.
+func (h *Handler) HandleRun(w http.ResponseWriter, r *http.Request) {
+	asOf := r.URL.Query().Get("as_of")
+	if asOf == "" {
+		asOf = time.Now().Format(calendar.DateLayout)
+	}
+	if _, err := calendar.ParseInputDate(asOf); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	credits, err := h.store.Accrue(r.Context(), asOf)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, credits)
+}
+
+func validate(policy *types.AccrualPolicy) error {
+	if policy.LeaveType == "" {
+		return types.Invalid("leave_type", "leave_type is required")
+	}
+	if policy.Frequency == "" {
+		policy.Frequency = types.AccrueMonthly
+	}
+	if policy.Frequency != types.AccrueMonthly && policy.Frequency != types.AccrueAnnual {
+		return types.Invalid("frequency", "frequency must be %q or %q", types.AccrueMonthly, types.AccrueAnnual)
+	}
+	if policy.MinTenureYears < 0 {
+		return types.Invalid("min_tenure_years", "min_tenure_years must not be negative")
+	}
+	if policy.AnnualDays < 0 {
+		return types.Invalid("annual_days", "annual_days must not be negative")
+	}
+	return nil
+}
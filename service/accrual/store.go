@@ -0,0 +1,314 @@
+package accrual
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/georgiwritescode/vacation-tool/calendar"
+	"github.com/georgiwritescode/vacation-tool/db"
+	"github.com/georgiwritescode/vacation-tool/service/audit"
+	"github.com/georgiwritescode/vacation-tool/service/ledger"
+	"github.com/georgiwritescode/vacation-tool/types"
+)
+
+type Store struct {
+	db *db.DB
+}
+
+func NewStore(db *db.DB) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) FindAll(ctx context.Context) ([]*types.AccrualPolicy, error) {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT id, leave_type, frequency, min_tenure_years, annual_days, ts FROM tbl_accrual_policies ORDER BY leave_type, min_tenure_years")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	policies := make([]*types.AccrualPolicy, 0)
+	for rows.Next() {
+		p, err := scanRowsIntoPolicy(rows)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, p)
+	}
+
+	return policies, nil
+}
+
+func (s *Store) CreatePolicy(ctx context.Context, policy *types.AccrualPolicy) (int, error) {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		return -1, err
+	}
+
+	id, err := tx.Insert("INSERT INTO tbl_accrual_policies (leave_type, frequency, min_tenure_years, annual_days) VALUES (?, ?, ?, ?)",
+		policy.LeaveType, policy.Frequency, policy.MinTenureYears, policy.AnnualDays)
+	if err != nil {
+		tx.Rollback()
+		return -1, err
+	}
+
+	after, err := findPolicy(tx, int(id))
+	if err == nil {
+		err = audit.Record(tx, audit.ActionCreate, audit.EntityAccrualPolicy, id, nil, after)
+	}
+	if err != nil {
+		tx.Rollback()
+		return -1, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return -1, err
+	}
+
+	return int(id), nil
+}
+
+func (s *Store) UpdatePolicy(ctx context.Context, policy *types.AccrualPolicy) error {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+
+	before, err := findPolicy(tx, policy.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.Exec("UPDATE tbl_accrual_policies SET leave_type=?, frequency=?, min_tenure_years=?, annual_days=? WHERE id=?",
+		policy.LeaveType, policy.Frequency, policy.MinTenureYears, policy.AnnualDays, policy.ID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	after, err := findPolicy(tx, policy.ID)
+	if err == nil {
+		err = audit.Record(tx, audit.ActionUpdate, audit.EntityAccrualPolicy, policy.ID, before, after)
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *Store) DeletePolicy(ctx context.Context, id int) error {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+
+	before, err := findPolicy(tx, id)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM tbl_accrual_policies WHERE id=?", id); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := audit.Record(tx, audit.ActionDelete, audit.EntityAccrualPolicy, id, before, nil); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Accrue credits every user the difference between what they have earned so
+// far in asOf's year, prorated to their FTE, and what earlier runs credited for
+// that year. Each user is credited in their own transaction, holding their row lock, so concurrent or
+// repeated runs never credit the same days twice.
+func (s *Store) Accrue(ctx context.Context, asOf string) ([]*types.AccrualCredit, error) {
+	date, err := calendar.ParseInputDate(asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	policies, err := s.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	bands := make(map[string][]*types.AccrualPolicy)
+	for _, p := range policies {
+		bands[p.LeaveType] = append(bands[p.LeaveType], p)
+	}
+	leaveTypes := make([]string, 0, len(bands))
+	for leaveType := range bands {
+		leaveTypes = append(leaveTypes, leaveType)
+	}
+	sort.Strings(leaveTypes)
+
+	hires, err := s.hireDates(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	credits := make([]*types.AccrualCredit, 0)
+	for _, userID := range sortedIDs(hires) {
+		hire, err := calendar.ParseDate(hires[userID])
+		if err != nil {
+			return nil, err
+		}
+
+		credited, err := s.accrueUser(ctx, userID, hire, date, bands, leaveTypes)
+		if err != nil {
+			return nil, err
+		}
+		credits = append(credits, credited...)
+	}
+
+	return credits, nil
+}
+
+// accrueUser credits one user what Accrue owes them, in a transaction of its
+// own that the query timeout bounds.
+func (s *Store) accrueUser(ctx context.Context, userID int, hire, date time.Time, bands map[string][]*types.AccrualPolicy, leaveTypes []string) ([]*types.AccrualCredit, error) {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var fte int
+	if err := tx.QueryRow("SELECT fte FROM tbl_users WHERE id = ? FOR UPDATE", userID).Scan(&fte); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	var credits []*types.AccrualCredit
+	for _, leaveType := range leaveTypes {
+		credit := &types.AccrualCredit{UserID: userID, LeaveType: leaveType, Year: date.Year(), Due: types.ProrateFTE(Due(bands[leaveType], hire, date), fte)}
+
+		err := tx.QueryRow("SELECT COALESCE(SUM(amount), 0) FROM tbl_ledger WHERE user_id = ? AND leave_type = ? AND reason = ? AND reference = ?",
+			userID, leaveType, types.ReasonAccrual, Reference(credit.Year)).Scan(&credit.Credited)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+
+		// Never claw back: a run for an earlier date than the last one credits nothing
+		credit.Amount = types.RoundDays(max(credit.Due-credit.Credited, 0))
+		if credit.Amount == 0 {
+			continue
+		}
+
+		err = ledger.Apply(tx, &types.LedgerEntry{UserID: userID, LeaveType: leaveType, Amount: credit.Amount, Reason: types.ReasonAccrual, Reference: Reference(credit.Year)})
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		credits = append(credits, credit)
+	}
+
+	if len(credits) > 0 {
+		if err := audit.Record(tx, audit.ActionRun, audit.EntityAccrual, date.Format(calendar.DateLayout), nil, credits); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return credits, nil
+}
+
+// hireDates maps every user to their hire date, falling back to the day they were created.
+func (s *Store) hireDates(ctx context.Context) (map[int]string, error) {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT id, COALESCE(hire_date, DATE(ts)) FROM tbl_users")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hires := make(map[int]string)
+	for rows.Next() {
+		var id int
+		var hire string
+		if err := rows.Scan(&id, &hire); err != nil {
+			return nil, err
+		}
+		hires[id] = hire
+	}
+
+	return hires, rows.Err()
+}
+
+func sortedIDs(m map[int]string) []int {
+	ids := make([]int, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// findPolicy reads a policy's row within tx, locking it until tx ends.
+func findPolicy(tx *db.Tx, id int) (*types.AccrualPolicy, error) {
+	rows, err := tx.Query("SELECT id, leave_type, frequency, min_tenure_years, annual_days, ts FROM tbl_accrual_policies WHERE id = ? FOR UPDATE", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	policy := new(types.AccrualPolicy)
+	for rows.Next() {
+		policy, err = scanRowsIntoPolicy(rows)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if policy.ID == 0 {
+		return nil, fmt.Errorf("accrual policy %w", types.ErrNotFound)
+	}
+
+	return policy, nil
+}
+
+func scanRowsIntoPolicy(rows *sql.Rows) (*types.AccrualPolicy, error) {
+	policy := new(types.AccrualPolicy)
+
+	err := rows.Scan(
+		&policy.ID,
+		&policy.LeaveType,
+		&policy.Frequency,
+		&policy.MinTenureYears,
+		&policy.AnnualDays,
+		&policy.Timestamp,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return policy, nil
+}
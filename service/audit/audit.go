@@ -0,0 +1,130 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/georgiwritescode/vacation-tool/db"
+	"github.com/georgiwritescode/vacation-tool/types"
+)
+
+// Actions recorded besides the vacation transitions, which are recorded by
+// the status they move to.
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+	ActionAdjust = "adjust"
+	ActionRun    = "run"
+	ActionImport = "import"
+	ActionRevoke = "revoke"
+)
+
+// Entities recorded, named after their tables.
+const (
+	EntityUser           = "user"
+	EntityVacation       = "vacation"
+	EntityLedger         = "ledger"
+	EntityLeaveType      = "leave_type"
+	EntityAccrualPolicy  = "accrual_policy"
+	EntityAccrual        = "accrual"
+	EntityRolloverPolicy = "rollover_policy"
+	EntityRollover       = "rollover"
+	EntityExpiry         = "expiry"
+	EntityHoliday        = "holiday"
+	EntityTeam           = "team"
+	EntityDepartment     = "department"
+	EntityRule           = "rule"
+	EntityAPIToken       = "api_token"
+	EntityPassword       = "password"
+)
+
+// RequestIDHeader carries a request's id, taken from the client or generated.
+const RequestIDHeader = "X-Request-ID"
+
+// origin is where a change comes from: the request and the user making it.
+type origin struct {
+	requestID  string
+	ip         string
+	actorID    int
+	actorEmail string
+}
+
+type originKey struct{}
+
+func originOf(ctx context.Context) origin {
+	o, _ := ctx.Value(originKey{}).(origin)
+	return o
+}
+
+// WithRequest attaches a request's id and client IP to its context.
+func WithRequest(ctx context.Context, id, ip string) context.Context {
+	o := originOf(ctx)
+	o.requestID, o.ip = id, ip
+	return context.WithValue(ctx, originKey{}, o)
+}
+
+// WithActor attaches the user making the changes to a context.
+func WithActor(ctx context.Context, id int, email string) context.Context {
+	o := originOf(ctx)
+	o.actorID, o.actorEmail = id, email
+	return context.WithValue(ctx, originKey{}, o)
+}
+
+// RequestID returns the id of the request, or "".
+func RequestID(r *http.Request) string {
+	return originOf(r.Context()).requestID
+}
+
+// Record logs action on an entity within the transaction making the change,
+// so the change and its entry are committed or rolled back together. Pass
+// nil for a missing snapshot. Snapshots are of the rows as stored, read in
+// the transaction, so they show what the server derived. Who made the change
+// and from where come from the transaction's context; changes made outside
+// a request, like the accrue command's, have no actor.
+func Record(tx *db.Tx, action, entity string, entityID any, before, after any) error {
+	o := originOf(tx.Context())
+	entry := &types.AuditEntry{
+		ActorID:    o.actorID,
+		ActorEmail: o.actorEmail,
+		Action:     action,
+		Entity:     entity,
+		EntityID:   fmt.Sprint(entityID),
+		RequestID:  o.requestID,
+		IP:         o.ip,
+	}
+
+	var err error
+	if entry.Before, err = snapshot(before); err == nil {
+		entry.After, err = snapshot(after)
+	}
+	if err == nil {
+		_, err = tx.Exec("INSERT INTO tbl_audit_log (actor_id, actor_email, action, entity, entity_id, before_json, after_json, request_id, ip) VALUES (NULLIF(?, 0), ?, ?, ?, ?, ?, ?, ?, ?)",
+			entry.ActorID, entry.ActorEmail, entry.Action, entry.Entity, entry.EntityID, nullJSON(entry.Before), nullJSON(entry.After), entry.RequestID, entry.IP)
+	}
+	if err != nil {
+		return fmt.Errorf("audit: recording %s %s %v: %w", action, entity, entityID, err)
+	}
+	return nil
+}
+
+// snapshot marshals an entity for the log. Users are stored without their
+// password and request history, which has entries of its own.
+func snapshot(v any) (json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+	if u, ok := v.(*types.User); ok && u != nil {
+		stripped := *u
+		stripped.Password, stripped.Vacations = "", nil
+		v = &stripped
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil || string(b) == "null" {
+		return nil, err
+	}
+	return b, nil
+}
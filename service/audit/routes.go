@@ -0,0 +1,82 @@
+package audit
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/georgiwritescode/vacation-tool/calendar"
+	"github.com/georgiwritescode/vacation-tool/service/auth"
+	"github.com/georgiwritescode/vacation-tool/types"
+	"github.com/georgiwritescode/vacation-tool/utils"
+)
+
+type Handler struct {
+	store types.AuditStore
+}
+
+func NewHandler(store types.AuditStore) *Handler {
+	return &Handler{store: store}
+}
+
+func (h *Handler) RegisterRoutes(router *http.ServeMux) {
+	router.HandleFunc("GET /api/v1/audit", auth.AdminOnly(h.HandleListEntries))
+}
+
+// HandleListEntries lists one page of the audit log, newest first unless
+// sorted otherwise. The total count and the links to the neighbouring pages
+// are sent as headers.
+func (h *Handler) HandleListEntries(w http.ResponseWriter, r *http.Request) {
+	filter, err := ParseFilter(r.URL.Query())
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	entries, total, err := h.store.FindPage(r.Context(), filter)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WritePageHeaders(w, r, filter.Page, total)
+	utils.WriteJSON(w, http.StatusOK, entries)
+}
+
+// ParseFilter reads an audit log listing's filters from a query string:
+// actor_id, action, entity, entity_id, from and to (days, inclusive), and the
+// paging parameters limit, offset, sort and order.
+func ParseFilter(q url.Values) (types.AuditFilter, error) {
+	page, err := utils.ParsePage(q, sortColumns)
+	if err != nil {
+		return types.AuditFilter{}, err
+	}
+	if page.Sort == "" {
+		page.Sort, page.Desc = "id", q.Get("order") != "asc"
+	}
+
+	filter := types.AuditFilter{
+		Action:   q.Get("action"),
+		Entity:   q.Get("entity"),
+		EntityID: q.Get("entity_id"),
+		FromDate: q.Get("from"),
+		ToDate:   q.Get("to"),
+		Page:     page,
+	}
+
+	if v := q.Get("actor_id"); v != "" {
+		if filter.ActorID, err = strconv.Atoi(v); err != nil {
+			return types.AuditFilter{}, types.Invalid("actor_id", "invalid actor_id %q", v)
+		}
+	}
+	for param, date := range map[string]string{"from": filter.FromDate, "to": filter.ToDate} {
+		if date == "" {
+			continue
+		}
+		if _, err := calendar.ParseInputDate(date); err != nil {
+			return types.AuditFilter{}, types.Invalid(param, "%v", err)
+		}
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,124 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/georgiwritescode/vacation-tool/calendar"
+	"github.com/georgiwritescode/vacation-tool/db"
+	"github.com/georgiwritescode/vacation-tool/types"
+	"github.com/georgiwritescode/vacation-tool/utils"
+)
+
+const entryColumns = "id, COALESCE(actor_id, 0), actor_email, action, entity, entity_id, before_json, after_json, request_id, ip, ts"
+
+// sortColumns maps the fields audit log lists may be sorted by to their columns.
+var sortColumns = map[string]string{
+	"id":       "id",
+	"actor_id": "actor_id",
+	"action":   "action",
+	"entity":   "entity",
+	"ts":       "ts",
+}
+
+type Store struct {
+	db *db.DB
+}
+
+func NewStore(db *db.DB) *Store {
+	return &Store{db: db}
+}
+
+// FindPage returns one page of the entries matching the filter and how many
+// match in all.
+func (s *Store) FindPage(ctx context.Context, filter types.AuditFilter) ([]*types.AuditEntry, int, error) {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	where, args := []string{"1 = 1"}, []any{}
+	if filter.ActorID != 0 {
+		where, args = append(where, "actor_id = ?"), append(args, filter.ActorID)
+	}
+	if filter.Action != "" {
+		where, args = append(where, "action = ?"), append(args, filter.Action)
+	}
+	if filter.Entity != "" {
+		where, args = append(where, "entity = ?"), append(args, filter.Entity)
+	}
+	if filter.EntityID != "" {
+		where, args = append(where, "entity_id = ?"), append(args, filter.EntityID)
+	}
+	if filter.FromDate != "" {
+		where, args = append(where, "ts >= ?"), append(args, filter.FromDate)
+	}
+	if filter.ToDate != "" {
+		next, err := calendar.NextDay(filter.ToDate)
+		if err != nil {
+			return nil, 0, err
+		}
+		where, args = append(where, "ts < ?"), append(args, next)
+	}
+	cond := " WHERE " + strings.Join(where, " AND ")
+
+	var total int
+	if err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM tbl_audit_log"+cond, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	order, pageArgs := utils.OrderBy(sortColumns, filter.Page)
+	rows, err := s.db.QueryContext(ctx, "SELECT "+entryColumns+" FROM tbl_audit_log"+cond+order, append(args, pageArgs...)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	entries := make([]*types.AuditEntry, 0)
+	for rows.Next() {
+		entry, err := scanRowsIntoEntry(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, total, rows.Err()
+}
+
+func scanRowsIntoEntry(rows *sql.Rows) (*types.AuditEntry, error) {
+	entry := new(types.AuditEntry)
+	var before, after sql.NullString
+
+	err := rows.Scan(
+		&entry.ID,
+		&entry.ActorID,
+		&entry.ActorEmail,
+		&entry.Action,
+		&entry.Entity,
+		&entry.EntityID,
+		&before,
+		&after,
+		&entry.RequestID,
+		&entry.IP,
+		&entry.Timestamp,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if before.Valid {
+		entry.Before = []byte(before.String)
+	}
+	if after.Valid {
+		entry.After = []byte(after.String)
+	}
+	return entry, nil
+}
+
+// nullJSON stores a missing snapshot as NULL.
+func nullJSON(snapshot []byte) any {
+	if len(snapshot) == 0 {
+		return nil
+	}
+	return string(snapshot)
+}
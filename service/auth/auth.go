@@ -0,0 +1,158 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/georgiwritescode/vacation-tool/types"
+	"github.com/georgiwritescode/vacation-tool/utils"
+	"github.com/georgiwritescode/vacation-tool/validation"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// SessionCookie names the cookie holding a web session's secret.
+const SessionCookie = "vt_session"
+
+// SessionTTL is how long a web session lasts after logging in.
+const SessionTTL = 12 * time.Hour
+
+// CSRFField names the form field carrying the CSRF token; scripts may send it
+// in the CSRFHeader header instead.
+const (
+	CSRFField  = "csrf_token"
+	CSRFHeader = "X-CSRF-Token"
+)
+
+// ErrBadCredentials means the email or password did not match. It does not
+// say which, so accounts cannot be probed.
+var ErrBadCredentials = errors.New("invalid email or password")
+
+// HashPassword hashes a password with bcrypt.
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	return string(hash), err
+}
+
+// CheckPassword reports whether password matches a bcrypt hash. An empty
+// hash, a user who never set a password, matches nothing.
+func CheckPassword(hash, password string) bool {
+	return hash != "" && bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// NewSecret returns a random secret for a session or API token and the hash
+// it is stored under.
+func NewSecret() (secret, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	secret = base64.RawURLEncoding.EncodeToString(b)
+	return secret, HashSecret(secret), nil
+}
+
+// HashSecret is the SHA-256 hash a secret is stored and looked up by. The
+// secrets are random, so a fast hash is enough.
+func HashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// CSRFToken returns the CSRF token of the request's web session, or "" without
+// one. It is derived from the session secret, so it lasts as long as the
+// session and a cross-site page, which cannot read the cookie, cannot know it.
+func CSRFToken(r *http.Request) string {
+	cookie, err := r.Cookie(SessionCookie)
+	if err != nil || cookie.Value == "" {
+		return ""
+	}
+	return HashSecret("csrf:" + cookie.Value)
+}
+
+// Login checks an email and password and returns the user's id.
+func Login(ctx context.Context, store types.AuthStore, email, password string) (int, error) {
+	userID, hash, err := store.FindPasswordHash(ctx, email)
+	if errors.Is(err, types.ErrNotFound) {
+		return 0, ErrBadCredentials
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if !CheckPassword(hash, password) {
+		return 0, ErrBadCredentials
+	}
+	return userID, nil
+}
+
+// Bootstrap makes sure the HR admin configured at startup can log in: the
+// user is created if missing, or given the password and the HR admin role if
+// they have no password yet. A password set since is left alone.
+func Bootstrap(ctx context.Context, store types.AuthStore, users types.UserStore, email, password string) error {
+	if err := validation.Password("ADMIN_PASSWORD", password); err != nil {
+		return err
+	}
+
+	userID, hash, err := store.FindPasswordHash(ctx, email)
+	if errors.Is(err, types.ErrNotFound) {
+		_, err = users.CreateUser(ctx, &types.User{FirstName: "Admin", LastName: "Admin", Email: email, Age: validation.MinAge, Role: types.RoleHRAdmin, Password: password})
+		return err
+	}
+	if err != nil || hash != "" {
+		return err
+	}
+
+	if hash, err = HashPassword(password); err != nil {
+		return err
+	}
+	if err := store.SetPasswordHash(ctx, userID, hash); err != nil {
+		return err
+	}
+	return store.SetRole(ctx, userID, types.RoleHRAdmin)
+}
+
+type contextKey struct{}
+
+// Principal is who a request was authenticated as. Token is set for API
+// requests and limits them to its scopes.
+type Principal struct {
+	User  *types.User
+	Token *types.APIToken
+}
+
+// WithPrincipal attaches the authenticated principal to a request context.
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, p)
+}
+
+// FromRequest returns the principal the request was authenticated as, or nil.
+func FromRequest(r *http.Request) *Principal {
+	p, _ := r.Context().Value(contextKey{}).(*Principal)
+	return p
+}
+
+// Actor returns the user the request was authenticated as, or nil.
+func Actor(r *http.Request) *types.User {
+	if p := FromRequest(r); p != nil {
+		return p.User
+	}
+	return nil
+}
+
+// AdminOnly lets only HR admins through to next, for routes changing
+// settings that have no finer-grained policy.
+func AdminOnly(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if actor := Actor(r); actor == nil || actor.Role != types.RoleHRAdmin {
+			utils.WriteError(w, http.StatusForbidden, fmt.Errorf("%w: only HR admins change settings", types.ErrForbidden))
+			return
+		}
+		next(w, r)
+	}
+}
@@ -0,0 +1,164 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/georgiwritescode/vacation-tool/calendar"
+	"github.com/georgiwritescode/vacation-tool/types"
+	"github.com/georgiwritescode/vacation-tool/utils"
+	"github.com/georgiwritescode/vacation-tool/validation"
+)
+
+type Handler struct {
+	store types.AuthStore
+}
+
+func NewHandler(store types.AuthStore) *Handler {
+	return &Handler{store: store}
+}
+
+// PublicRoutes are the routes of this handler that are reached without
+// being authenticated: creating a token is authenticated by the password.
+var PublicRoutes = []string{"POST /api/v1/auth/tokens"}
+
+func (h *Handler) RegisterRoutes(router *http.ServeMux) {
+	router.HandleFunc("POST /api/v1/auth/tokens", h.HandleCreateToken)
+	router.HandleFunc("GET /api/v1/auth/tokens", h.HandleListTokens)
+	router.HandleFunc("DELETE /api/v1/auth/tokens/{id}", h.HandleRevokeToken)
+	router.HandleFunc("PUT /api/v1/auth/password", h.HandleChangePassword)
+}
+
+// HandleCreateToken issues a personal API token, e.g.
+// {"email": "...", "password": "...", "name": "ci", "scopes": ["read"]}.
+// The token is only ever shown in this response.
+func (h *Handler) HandleCreateToken(w http.ResponseWriter, r *http.Request) {
+	var req types.TokenRequest
+	if err := utils.ParseJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	userID, err := Login(r.Context(), h.store, req.Email, req.Password)
+	if errors.Is(err, ErrBadCredentials) {
+		utils.WriteError(w, http.StatusUnauthorized, err)
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := checkTokenRequest(&req); err != nil {
+		utils.WriteError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	secret, hash, err := NewSecret()
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	token := &types.APIToken{UserID: userID, Name: req.Name, Scopes: req.Scopes, ExpiresAt: req.ExpiresAt}
+	if token.ID, err = h.store.CreateToken(r.Context(), token, hash); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	token.Token = secret
+
+	utils.WriteJSON(w, http.StatusCreated, token)
+}
+
+// HandleListTokens lists the authenticated user's tokens, revoked ones included.
+func (h *Handler) HandleListTokens(w http.ResponseWriter, r *http.Request) {
+	tokens, err := h.store.FindTokensByUser(r.Context(), FromRequest(r).User.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, tokens)
+}
+
+// HandleRevokeToken revokes one of the authenticated user's tokens.
+func (h *Handler) HandleRevokeToken(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid token id: %v", err))
+		return
+	}
+
+	if err := h.store.RevokeToken(r.Context(), FromRequest(r).User.ID, id); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"status": "revoked"})
+}
+
+// HandleChangePassword sets the authenticated user's password, e.g.
+// {"current_password": "...", "new_password": "..."}.
+func (h *Handler) HandleChangePassword(w http.ResponseWriter, r *http.Request) {
+	var req types.PasswordChange
+	if err := utils.ParseJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	user := FromRequest(r).User
+	if _, err := Login(r.Context(), h.store, user.Email, req.CurrentPassword); err != nil {
+		utils.WriteError(w, http.StatusUnprocessableEntity, types.Invalid("current_password", "current password is wrong"))
+		return
+	}
+	if err := validation.Password("new_password", req.NewPassword); err != nil {
+		utils.WriteError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	hash, err := HashPassword(req.NewPassword)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := h.store.SetPasswordHash(r.Context(), user.ID, hash); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"status": "updated"})
+}
+
+// checkTokenRequest validates a token's name, scopes and optional expiry date.
+func checkTokenRequest(req *types.TokenRequest) error {
+	var errs validation.Errors
+
+	if strings.TrimSpace(req.Name) == "" {
+		errs.Add("name", "name is required")
+	}
+
+	if len(req.Scopes) == 0 {
+		errs.Add("scopes", "at least one scope is required")
+	}
+	for _, scope := range req.Scopes {
+		if scope != types.ScopeRead && scope != types.ScopeWrite {
+			errs.Add("scopes", "unknown scope %q, use %q or %q", scope, types.ScopeRead, types.ScopeWrite)
+		}
+	}
+
+	if req.ExpiresAt != "" {
+		expires, err := calendar.ParseInputDate(req.ExpiresAt)
+		if err != nil {
+			errs.Add("expires_at", "%v", err)
+		} else if !expires.After(time.Now()) {
+			errs.Add("expires_at", "expiry date must be in the future")
+		}
+	}
+
+	return errs.Err()
+}
@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/georgiwritescode/vacation-tool/oidc"
+	"github.com/georgiwritescode/vacation-tool/types"
+	"github.com/georgiwritescode/vacation-tool/validation"
+)
+
+// ErrNoAccount means a single sign-on login matched no user and provisioning
+// is off, or the provider did not vouch for the email.
+var ErrNoAccount = errors.New("no account for this login")
+
+// roleRank orders the roles by privilege, for users in several mapped groups.
+var roleRank = map[string]int{types.RoleEmployee: 1, types.RoleManager: 2, types.RoleHRAdmin: 3}
+
+// SSO logs users in through an OpenID Connect provider. Its users are matched
+// to tbl_users by the email claim.
+type SSO struct {
+	Client *oidc.Client
+	// Provision creates users logging in for the first time.
+	Provision bool
+	// GroupsClaim names the claim listing the user's groups.
+	GroupsClaim string
+	// GroupRoles maps groups to roles. When set, the provider decides roles:
+	// users get the highest role of their groups, or employee without one.
+	GroupRoles map[string]string
+}
+
+// ParseGroupRoles reads a group to role mapping written as
+// "group=role,group=role".
+func ParseGroupRoles(s string) (map[string]string, error) {
+	roles := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		group, role, ok := strings.Cut(pair, "=")
+		group, role = strings.TrimSpace(group), strings.TrimSpace(role)
+		if !ok || group == "" {
+			return nil, fmt.Errorf("group role %q is not group=role", pair)
+		}
+		if _, known := roleRank[role]; !known {
+			return nil, fmt.Errorf("group %q maps to unknown role %q", group, role)
+		}
+		roles[group] = role
+	}
+	return roles, nil
+}
+
+// Login returns the id of the user the verified claims belong to, creating
+// them if provisioning is on and updating their role from their groups.
+func (s *SSO) Login(ctx context.Context, store types.AuthStore, users types.UserStore, claims *oidc.Claims) (int, error) {
+	if claims.Email == "" || (claims.EmailVerified != nil && !*claims.EmailVerified) {
+		return 0, fmt.Errorf("%w: the provider sent no verified email", ErrNoAccount)
+	}
+
+	role, mapped := s.role(claims)
+
+	user, err := users.FindByEmail(ctx, claims.Email)
+	if errors.Is(err, types.ErrNotFound) {
+		if !s.Provision {
+			return 0, fmt.Errorf("%w: %s", ErrNoAccount, claims.Email)
+		}
+		return users.CreateUser(ctx, provisionedUser(claims, role))
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if mapped && user.Role != role {
+		if err := store.SetRole(ctx, user.ID, role); err != nil {
+			return 0, err
+		}
+	}
+	return user.ID, nil
+}
+
+// role is the highest role the user's groups map to. ok is false when no
+// mapping is configured, leaving roles to HR admins.
+func (s *SSO) role(claims *oidc.Claims) (role string, ok bool) {
+	if len(s.GroupRoles) == 0 {
+		return types.RoleEmployee, false
+	}
+
+	role = types.RoleEmployee
+	for _, group := range claims.Strings(s.GroupsClaim) {
+		if r, found := s.GroupRoles[group]; found && roleRank[r] > roleRank[role] {
+			role = r
+		}
+	}
+	return role, true
+}
+
+// provisionedUser is a new user made from the claims. Details the provider
+// doesn't know, like the age, get placeholders for HR to fill in.
+func provisionedUser(claims *oidc.Claims, role string) *types.User {
+	first, last := claims.GivenName, claims.FamilyName
+	if first == "" && last == "" {
+		first, last, _ = strings.Cut(claims.Name, " ")
+	}
+	if first == "" {
+		first, _, _ = strings.Cut(claims.Email, "@")
+	}
+
+	return &types.User{FirstName: first, LastName: last, Email: claims.Email, Age: validation.MinAge, Role: role}
+}
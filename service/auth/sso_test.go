@@ -0,0 +1,114 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/georgiwritescode/vacation-tool/db"
+	"github.com/georgiwritescode/vacation-tool/db/migrations"
+	"github.com/georgiwritescode/vacation-tool/oidc"
+	"github.com/georgiwritescode/vacation-tool/service/audit"
+	"github.com/georgiwritescode/vacation-tool/service/auth"
+	"github.com/georgiwritescode/vacation-tool/service/user"
+	"github.com/georgiwritescode/vacation-tool/types"
+)
+
+func TestParseGroupRoles(t *testing.T) {
+	roles, err := auth.ParseGroupRoles(" hr = hr_admin, leads=manager,,")
+	if err != nil {
+		t.Fatalf("ParseGroupRoles: %v", err)
+	}
+	if len(roles) != 2 || roles["hr"] != types.RoleHRAdmin || roles["leads"] != types.RoleManager {
+		t.Errorf("roles = %v", roles)
+	}
+
+	for _, bad := range []string{"hr", "=manager", "hr=owner"} {
+		if _, err := auth.ParseGroupRoles(bad); err == nil {
+			t.Errorf("ParseGroupRoles(%q) accepted a bad mapping", bad)
+		}
+	}
+}
+
+func TestLogin(t *testing.T) {
+	database, err := db.MemoryStorage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	if _, err := migrations.New(database).Up(); err != nil {
+		t.Fatal(err)
+	}
+	store, users := auth.NewStore(database, audit.Record), user.NewStore(database)
+	ctx := context.Background()
+
+	verified := true
+	claims := func(email string, groups ...any) *oidc.Claims {
+		return &oidc.Claims{Email: email, EmailVerified: &verified, GivenName: "Ada", FamilyName: "Lovelace", Raw: map[string]any{"groups": groups}}
+	}
+	mapped := &auth.SSO{Provision: true, GroupsClaim: "groups", GroupRoles: map[string]string{"leads": types.RoleManager, "hr": types.RoleHRAdmin}}
+
+	t.Run("provisions with the highest group role", func(t *testing.T) {
+		id, err := mapped.Login(ctx, store, users, claims("ada@example.com", "staff", "hr", "leads"))
+		if err != nil {
+			t.Fatalf("Login: %v", err)
+		}
+		u, err := users.FindById(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if u.Email != "ada@example.com" || u.FirstName != "Ada" || u.LastName != "Lovelace" || u.Role != types.RoleHRAdmin {
+			t.Errorf("provisioned %+v", u)
+		}
+	})
+
+	t.Run("updates the role of an existing user", func(t *testing.T) {
+		id, err := mapped.Login(ctx, store, users, claims("ada@example.com", "leads"))
+		if err != nil {
+			t.Fatalf("Login: %v", err)
+		}
+		if u, _ := users.FindById(ctx, id); u.Role != types.RoleManager {
+			t.Errorf("role = %q, want %q", u.Role, types.RoleManager)
+		}
+	})
+
+	t.Run("leaves roles alone without a mapping", func(t *testing.T) {
+		unmapped := &auth.SSO{GroupsClaim: "groups"}
+		id, err := unmapped.Login(ctx, store, users, claims("ada@example.com", "hr"))
+		if err != nil {
+			t.Fatalf("Login: %v", err)
+		}
+		if u, _ := users.FindById(ctx, id); u.Role != types.RoleManager {
+			t.Errorf("role = %q, want %q", u.Role, types.RoleManager)
+		}
+	})
+
+	t.Run("refuses unknown users without provisioning", func(t *testing.T) {
+		unmapped := &auth.SSO{GroupsClaim: "groups"}
+		if _, err := unmapped.Login(ctx, store, users, claims("grace@example.com")); !errors.Is(err, auth.ErrNoAccount) {
+			t.Errorf("Login = %v, want ErrNoAccount", err)
+		}
+	})
+
+	t.Run("refuses unverified emails", func(t *testing.T) {
+		unverified := claims("ada@example.com")
+		unverified.EmailVerified = new(bool)
+		if _, err := mapped.Login(ctx, store, users, unverified); !errors.Is(err, auth.ErrNoAccount) {
+			t.Errorf("Login = %v, want ErrNoAccount", err)
+		}
+	})
+
+	t.Run("records provisioning and role changes", func(t *testing.T) {
+		entries, _, err := audit.NewStore(database).FindPage(ctx, types.AuditFilter{Entity: audit.EntityUser})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var actions []string
+		for _, e := range entries {
+			actions = append(actions, e.Action)
+		}
+		if len(actions) != 2 || actions[0] != audit.ActionCreate || actions[1] != audit.ActionUpdate {
+			t.Errorf("audited %v, want [create update]", actions)
+		}
+	})
+}
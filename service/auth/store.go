@@ -0,0 +1,307 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/georgiwritescode/vacation-tool/db"
+	"github.com/georgiwritescode/vacation-tool/types"
+)
+
+const tokenColumns = "id, user_id, name, scopes, expires_at, last_used_at, revoked_at, ts"
+
+// Recorder writes an audit log entry within the transaction making the
+// change. It is implemented by the audit package, which depends on this one.
+type Recorder func(tx *db.Tx, action, entity string, entityID any, before, after any) error
+
+type Store struct {
+	db     *db.DB
+	record Recorder
+}
+
+func NewStore(db *db.DB, record Recorder) *Store {
+	return &Store{db: db, record: record}
+}
+
+func (s *Store) FindPasswordHash(ctx context.Context, email string) (int, string, error) {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	var userID int
+	var hash string
+	err := s.db.QueryRowContext(ctx, "SELECT id, password_hash FROM tbl_users WHERE email = ?", email).Scan(&userID, &hash)
+	if err == sql.ErrNoRows {
+		return 0, "", fmt.Errorf("user %w", types.ErrNotFound)
+	}
+	return userID, hash, err
+}
+
+// SetPasswordHash replaces the user's password. The audit log records that it
+// changed, never the hash.
+func (s *Store) SetPasswordHash(ctx context.Context, userID int, hash string) error {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+
+	res, err := tx.Exec("UPDATE tbl_users SET password_hash = ? WHERE id = ?", hash, userID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		tx.Rollback()
+		return fmt.Errorf("user %w", types.ErrNotFound)
+	}
+
+	if err := s.record(tx, "update", "password", userID, nil, nil); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *Store) SetRole(ctx context.Context, userID int, role string) error {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+
+	var current string
+	err = tx.QueryRow("SELECT role FROM tbl_users WHERE id = ? FOR UPDATE", userID).Scan(&current)
+	if err == sql.ErrNoRows {
+		tx.Rollback()
+		return fmt.Errorf("user %w", types.ErrNotFound)
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.Exec("UPDATE tbl_users SET role = ? WHERE id = ?", role, userID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := s.record(tx, "update", "user", userID, map[string]string{"role": current}, map[string]string{"role": role}); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CreateSession starts a session lasting ttl.
+func (s *Store) CreateSession(ctx context.Context, userID int, tokenHash string, ttl time.Duration) error {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, "INSERT INTO tbl_sessions (token_hash, user_id, expires_at) VALUES (?, ?, ?)", tokenHash, userID, timestamp(time.Now().Add(ttl)))
+	return err
+}
+
+// FindSession returns the user of an unexpired session.
+func (s *Store) FindSession(ctx context.Context, tokenHash string) (int, error) {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	var userID int
+	err := s.db.QueryRowContext(ctx, "SELECT user_id FROM tbl_sessions WHERE token_hash = ? AND expires_at > ?", tokenHash, timestamp(time.Now())).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("session %w", types.ErrNotFound)
+	}
+	return userID, err
+}
+
+func (s *Store) DeleteSession(ctx context.Context, tokenHash string) error {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, "DELETE FROM tbl_sessions WHERE token_hash = ? OR expires_at <= ?", tokenHash, timestamp(time.Now()))
+	return err
+}
+
+func (s *Store) CreateToken(ctx context.Context, token *types.APIToken, tokenHash string) (int, error) {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		return -1, err
+	}
+
+	id, err := tx.Insert("INSERT INTO tbl_api_tokens (user_id, name, token_hash, scopes, expires_at) VALUES (?, ?, ?, ?, ?)",
+		token.UserID, token.Name, tokenHash, strings.Join(token.Scopes, ","), db.NullIfEmpty(token.ExpiresAt))
+	if err != nil {
+		tx.Rollback()
+		return -1, err
+	}
+
+	after, err := findToken(tx, token.UserID, int(id))
+	if err == nil {
+		err = s.record(tx, "create", "api_token", id, nil, after)
+	}
+	if err != nil {
+		tx.Rollback()
+		return -1, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return -1, err
+	}
+
+	return int(id), nil
+}
+
+// FindToken returns an unrevoked, unexpired token and records that it was used.
+func (s *Store) FindToken(ctx context.Context, tokenHash string) (*types.APIToken, error) {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT "+tokenColumns+" FROM tbl_api_tokens WHERE token_hash = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", tokenHash, timestamp(time.Now()))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	token := new(types.APIToken)
+	for rows.Next() {
+		token, err = scanRowsIntoToken(rows)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if token.ID == 0 {
+		return nil, fmt.Errorf("api token %w", types.ErrNotFound)
+	}
+
+	if _, err := s.db.ExecContext(ctx, "UPDATE tbl_api_tokens SET last_used_at = ? WHERE id = ?", timestamp(time.Now()), token.ID); err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+func (s *Store) FindTokensByUser(ctx context.Context, userID int) ([]*types.APIToken, error) {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT "+tokenColumns+" FROM tbl_api_tokens WHERE user_id = ? ORDER BY id", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := make([]*types.APIToken, 0)
+	for rows.Next() {
+		token, err := scanRowsIntoToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+
+	return tokens, nil
+}
+
+// RevokeToken revokes one of the user's tokens. Revoked tokens are kept so
+// their use stays traceable.
+func (s *Store) RevokeToken(ctx context.Context, userID, id int) error {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+
+	before, err := findToken(tx, userID, id)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.Exec("UPDATE tbl_api_tokens SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL", timestamp(time.Now()), id); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	after, err := findToken(tx, userID, id)
+	if err == nil {
+		err = s.record(tx, "revoke", "api_token", id, before, after)
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// findToken reads one of the user's tokens within tx, locking it until tx ends.
+func findToken(tx *db.Tx, userID, id int) (*types.APIToken, error) {
+	rows, err := tx.Query("SELECT "+tokenColumns+" FROM tbl_api_tokens WHERE id = ? AND user_id = ? FOR UPDATE", id, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	token := new(types.APIToken)
+	for rows.Next() {
+		token, err = scanRowsIntoToken(rows)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if token.ID == 0 {
+		return nil, fmt.Errorf("api token %w", types.ErrNotFound)
+	}
+
+	return token, nil
+}
+
+// timestamp formats t as the databases write CURRENT_TIMESTAMP, in UTC.
+// Times are computed here rather than with NOW(), which not every database
+// has.
+func timestamp(t time.Time) string {
+	return t.UTC().Format(time.DateTime)
+}
+
+func scanRowsIntoToken(rows *sql.Rows) (*types.APIToken, error) {
+	token := new(types.APIToken)
+	var scopes string
+	var expiresAt, lastUsedAt, revokedAt sql.NullString
+
+	err := rows.Scan(
+		&token.ID,
+		&token.UserID,
+		&token.Name,
+		&scopes,
+		&expiresAt,
+		&lastUsedAt,
+		&revokedAt,
+		&token.Timestamp,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	token.ExpiresAt, token.LastUsedAt, token.RevokedAt = expiresAt.String, lastUsedAt.String, revokedAt.String
+	if scopes != "" {
+		token.Scopes = strings.Split(scopes, ",")
+	}
+	return token, nil
+}
@@ -0,0 +1,99 @@
+package department
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/georgiwritescode/vacation-tool/service/auth"
+	"github.com/georgiwritescode/vacation-tool/types"
+	"github.com/georgiwritescode/vacation-tool/utils"
+)
+
+type Handler struct {
+	store types.DepartmentStore
+}
+
+func NewHandler(store types.DepartmentStore) *Handler {
+	return &Handler{store: store}
+}
+
+func (h *Handler) RegisterRoutes(router *http.ServeMux) {
+	router.HandleFunc("GET /api/v1/departments/{id}", h.HandleGetByID)
+	router.HandleFunc("GET /api/v1/departments/list", h.HandleListDepartments)
+	router.HandleFunc("POST /api/v1/departments/create", auth.AdminOnly(h.HandleCreateDepartment))
+	router.HandleFunc("PUT /api/v1/departments/update", auth.AdminOnly(h.HandleUpdateDepartment))
+	router.HandleFunc("DELETE /api/v1/departments/delete/{id}", auth.AdminOnly(h.HandleDeleteDepartment))
+}
+
+func (h *Handler) HandleGetByID(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid department id: %v", err))
+		return
+	}
+
+	department, err := h.store.FindById(r.Context(), id)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, department)
+}
+
+func (h *Handler) HandleListDepartments(w http.ResponseWriter, r *http.Request) {
+	departments, err := h.store.FindAll(r.Context())
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, departments)
+}
+
+func (h *Handler) HandleCreateDepartment(w http.ResponseWriter, r *http.Request) {
+	var department types.Department
+	if err := utils.ParseJSON(r, &department); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	id, err := h.store.CreateDepartment(r.Context(), &department)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, map[string]int{"id": id})
+}
+
+func (h *Handler) HandleUpdateDepartment(w http.ResponseWriter, r *http.Request) {
+	var department types.Department
+	if err := utils.ParseJSON(r, &department); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.store.UpdateDepartment(r.Context(), &department); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"status": "updated"})
+}
+
+func (h *Handler) HandleDeleteDepartment(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid department id: %v", err))
+		return
+	}
+
+	if err := h.store.DeleteDepartment(r.Context(), id); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
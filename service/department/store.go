@@ -0,0 +1,220 @@
+package department
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/georgiwritescode/vacation-tool/db"
+	"github.com/georgiwritescode/vacation-tool/service/audit"
+	"github.com/georgiwritescode/vacation-tool/types"
+)
+
+type Store struct {
+	db *db.DB
+}
+
+func NewStore(db *db.DB) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) FindById(ctx context.Context, id int) (*types.Department, error) {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT id, name, ts FROM tbl_departments WHERE id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	department := new(types.Department)
+	for rows.Next() {
+		department, err = scanRowsIntoDepartment(rows)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if department.ID == 0 {
+		return nil, fmt.Errorf("department %w", types.ErrNotFound)
+	}
+
+	teams, err := s.getTeamsByDepartmentId(ctx, department.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching teams: %v", err)
+	}
+	department.Teams = teams
+
+	return department, nil
+}
+
+func (s *Store) FindAll(ctx context.Context) ([]*types.Department, error) {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT id, name, ts FROM tbl_departments ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	departments := make([]*types.Department, 0)
+	for rows.Next() {
+		d, err := scanRowsIntoDepartment(rows)
+		if err != nil {
+			return nil, err
+		}
+		departments = append(departments, d)
+	}
+
+	return departments, nil
+}
+
+func (s *Store) CreateDepartment(ctx context.Context, department *types.Department) (int, error) {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		return -1, err
+	}
+
+	id, err := tx.Insert("INSERT INTO tbl_departments (name) VALUES (?)", department.Name)
+	if err != nil {
+		tx.Rollback()
+		return -1, err
+	}
+
+	after, err := findDepartment(tx, int(id))
+	if err == nil {
+		err = audit.Record(tx, audit.ActionCreate, audit.EntityDepartment, id, nil, after)
+	}
+	if err != nil {
+		tx.Rollback()
+		return -1, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return -1, err
+	}
+
+	return int(id), nil
+}
+
+func (s *Store) UpdateDepartment(ctx context.Context, department *types.Department) error {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+
+	before, err := findDepartment(tx, department.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.Exec("UPDATE tbl_departments SET name=? WHERE id=?", department.Name, department.ID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	after, err := findDepartment(tx, department.ID)
+	if err == nil {
+		err = audit.Record(tx, audit.ActionUpdate, audit.EntityDepartment, department.ID, before, after)
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *Store) DeleteDepartment(ctx context.Context, id int) error {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+
+	before, err := findDepartment(tx, id)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM tbl_departments WHERE id=?", id); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := audit.Record(tx, audit.ActionDelete, audit.EntityDepartment, id, before, nil); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// findDepartment reads a department's row within tx, locking it until tx ends.
+func findDepartment(tx *db.Tx, id int) (*types.Department, error) {
+	rows, err := tx.Query("SELECT id, name, ts FROM tbl_departments WHERE id = ? FOR UPDATE", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	department := new(types.Department)
+	for rows.Next() {
+		department, err = scanRowsIntoDepartment(rows)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if department.ID == 0 {
+		return nil, fmt.Errorf("department %w", types.ErrNotFound)
+	}
+
+	return department, nil
+}
+
+func scanRowsIntoDepartment(rows *sql.Rows) (*types.Department, error) {
+	department := new(types.Department)
+
+	err := rows.Scan(
+		&department.ID,
+		&department.Name,
+		&department.Timestamp,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return department, nil
+}
+
+func (s *Store) getTeamsByDepartmentId(ctx context.Context, departmentId int) ([]*types.Team, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT id, name, ts FROM tbl_teams WHERE department_id = ? ORDER BY name", departmentId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	teams := make([]*types.Team, 0)
+	for rows.Next() {
+		t := &types.Team{DepartmentID: departmentId}
+		if err := rows.Scan(&t.ID, &t.Name, &t.Timestamp); err != nil {
+			return nil, err
+		}
+		teams = append(teams, t)
+	}
+
+	return teams, nil
+}
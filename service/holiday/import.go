@@ -0,0 +1,144 @@
+package holiday
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/georgiwritescode/vacation-tool/calendar"
+	"github.com/georgiwritescode/vacation-tool/types"
+)
+
+// ParseICS reads the all-day VEVENTs of an iCalendar file into holidays of the given calendar.
+// Events spanning several days (DTEND is exclusive) produce one holiday per day.
+func ParseICS(r io.Reader, calendarCode string) ([]*types.Holiday, error) {
+	lines, err := unfoldICS(r)
+	if err != nil {
+		return nil, err
+	}
+
+	holidays := make([]*types.Holiday, 0)
+	var inEvent bool
+	var summary, start, end string
+
+	for _, line := range lines {
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		// Drop parameters such as DTSTART;VALUE=DATE
+		name, _, _ = strings.Cut(strings.ToUpper(name), ";")
+
+		switch {
+		case name == "BEGIN" && value == "VEVENT":
+			inEvent = true
+			summary, start, end = "", "", ""
+		case name == "END" && value == "VEVENT":
+			inEvent = false
+			days, err := expandICSEvent(start, end)
+			if err != nil {
+				return nil, fmt.Errorf("event %q: %v", summary, err)
+			}
+			for _, day := range days {
+				holidays = append(holidays, &types.Holiday{Calendar: calendarCode, Date: day, Name: summary, Observed: true})
+			}
+		case !inEvent:
+		case name == "SUMMARY":
+			summary = unescapeICS(value)
+		case name == "DTSTART":
+			start = value
+		case name == "DTEND":
+			end = value
+		}
+	}
+
+	return holidays, nil
+}
+
+// ParseCSV reads "date,name" records into holidays of the given calendar. A header row is skipped.
+func ParseCSV(r io.Reader, calendarCode string) ([]*types.Holiday, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	holidays := make([]*types.Holiday, 0)
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) < 2 {
+			return nil, fmt.Errorf("line %d: expected date,name", line)
+		}
+		if line == 1 && strings.EqualFold(record[0], "date") {
+			continue
+		}
+
+		date, err := calendar.ParseInputDate(record[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+
+		holidays = append(holidays, &types.Holiday{
+			Calendar: calendarCode,
+			Date:     date.Format(calendar.DateLayout),
+			Name:     strings.TrimSpace(record[1]),
+			Observed: true,
+		})
+	}
+
+	return holidays, nil
+}
+
+func unfoldICS(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+func expandICSEvent(start, end string) ([]string, error) {
+	from, err := parseICSDate(start)
+	if err != nil {
+		return nil, err
+	}
+	if end == "" {
+		return []string{from.Format(calendar.DateLayout)}, nil
+	}
+
+	to, err := parseICSDate(end)
+	if err != nil {
+		return nil, err
+	}
+
+	days := []string{from.Format(calendar.DateLayout)}
+	for day := from.AddDate(0, 0, 1); day.Before(to); day = day.AddDate(0, 0, 1) {
+		days = append(days, day.Format(calendar.DateLayout))
+	}
+	return days, nil
+}
+
+func parseICSDate(value string) (time.Time, error) {
+	if len(value) < len("20060102") {
+		return time.Time{}, fmt.Errorf("invalid date %q", value)
+	}
+	return time.Parse("20060102", value[:len("20060102")])
+}
+
+func unescapeICS(value string) string {
+	return strings.NewReplacer(`\,`, ",", `\;`, ";", `\n`, " ", `\N`, " ", `\\`, `\`).Replace(value)
+}
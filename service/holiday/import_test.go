@@ -0,0 +1,90 @@
+package holiday
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseICS(t *testing.T) {
+	ics := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"BEGIN:VEVENT",
+		"SUMMARY:Spring\\, Days",
+		"DTSTART;VALUE=DATE:20320302",
+		"DTEND;VALUE=DATE:20320304",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"SUMMARY:Long",
+		"  Name",
+		"DTSTART;VALUE=DATE:20320310",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+
+	holidays, err := ParseICS(strings.NewReader(ics), "XH")
+	if err != nil {
+		t.Fatalf("ParseICS: %v", err)
+	}
+
+	want := []struct{ date, name string }{
+		{"2032-03-02", "Spring, Days"},
+		{"2032-03-03", "Spring, Days"},
+		{"2032-03-10", "Long Name"},
+	}
+	if len(holidays) != len(want) {
+		t.Fatalf("got %d holidays, want %d", len(holidays), len(want))
+	}
+	for i, w := range want {
+		h := holidays[i]
+		if h.Date != w.date || h.Name != w.name || h.Calendar != "XH" || !h.Observed {
+			t.Errorf("holiday %d = %+v, want %s %q", i, h, w.date, w.name)
+		}
+	}
+}
+
+func TestParseICSMalformed(t *testing.T) {
+	tests := map[string]string{
+		"missing DTSTART": "BEGIN:VEVENT\nSUMMARY:No start\nEND:VEVENT\n",
+		"bad DTSTART":     "BEGIN:VEVENT\nSUMMARY:Bad\nDTSTART:2032-03-02\nEND:VEVENT\n",
+		"bad DTEND":       "BEGIN:VEVENT\nSUMMARY:Bad\nDTSTART:20320302\nDTEND:tomorrow\nEND:VEVENT\n",
+	}
+	for name, ics := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParseICS(strings.NewReader(ics), "XH"); err == nil {
+				t.Error("ParseICS accepted a malformed event")
+			}
+		})
+	}
+}
+
+func TestParseCSV(t *testing.T) {
+	csv := "date,name\n2032-03-05, Region Day\n2032-05-01,Labour Day\n"
+
+	holidays, err := ParseCSV(strings.NewReader(csv), "XH-R")
+	if err != nil {
+		t.Fatalf("ParseCSV: %v", err)
+	}
+	if len(holidays) != 2 {
+		t.Fatalf("got %d holidays, want 2", len(holidays))
+	}
+	if h := holidays[0]; h.Date != "2032-03-05" || h.Name != "Region Day" || h.Calendar != "XH-R" || !h.Observed {
+		t.Errorf("first holiday = %+v", h)
+	}
+}
+
+func TestParseCSVMalformed(t *testing.T) {
+	tests := map[string]string{
+		"missing name":     "2032-03-05\n",
+		"invalid date":     "2032-13-01,Thirteenth\n",
+		"trailing junk":    "2032-03-05junk,Region Day\n",
+		"unquoted quote":   "2032-03-05,Bad \"name\n",
+		"bad row after ok": "2032-03-05,Fine\nnot-a-date,Broken\n",
+	}
+	for name, csv := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParseCSV(strings.NewReader(csv), "XH"); err == nil {
+				t.Error("ParseCSV accepted a malformed row")
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package holiday
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/georgiwritescode/vacation-tool/calendar"
+	"github.com/georgiwritescode/vacation-tool/service/auth"
+	"github.com/georgiwritescode/vacation-tool/types"
+	"github.com/georgiwritescode/vacation-tool/utils"
+)
+
+type Handler struct {
+	store types.HolidayStore
+}
+
+func NewHandler(store types.HolidayStore) *Handler {
+	return &Handler{store: store}
+}
+
+func (h *Handler) RegisterRoutes(router *http.ServeMux) {
+	router.HandleFunc("GET /api/v1/holidays/{id}", h.HandleGetByID)
+	router.HandleFunc("GET /api/v1/holidays/list", h.HandleListHolidays)
+	router.HandleFunc("POST /api/v1/holidays/create", auth.AdminOnly(h.HandleCreateHoliday))
+	router.HandleFunc("PUT /api/v1/holidays/update", auth.AdminOnly(h.HandleUpdateHoliday))
+	router.HandleFunc("DELETE /api/v1/holidays/delete/{id}", auth.AdminOnly(h.HandleDeleteHoliday))
+	router.HandleFunc("POST /api/v1/holidays/import", auth.AdminOnly(h.HandleImportHolidays))
+}
+
+func (h *Handler) HandleGetByID(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid holiday id: %v", err))
+		return
+	}
+
+	holiday, err := h.store.FindById(r.Context(), id)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, holiday)
+}
+
+func (h *Handler) HandleListHolidays(w http.ResponseWriter, r *http.Request) {
+	holidays, err := h.store.FindAll(r.Context(), r.URL.Query().Get("calendar"))
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, holidays)
+}
+
+func (h *Handler) HandleCreateHoliday(w http.ResponseWriter, r *http.Request) {
+	holiday := types.Holiday{Observed: true}
+	if err := utils.ParseJSON(r, &holiday); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := checkHoliday(&holiday); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	id, err := h.store.CreateHoliday(r.Context(), &holiday)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, map[string]int{"id": id})
+}
+
+func (h *Handler) HandleUpdateHoliday(w http.ResponseWriter, r *http.Request) {
+	holiday := types.Holiday{Observed: true}
+	if err := utils.ParseJSON(r, &holiday); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := checkHoliday(&holiday); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.store.UpdateHoliday(r.Context(), &holiday); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"status": "updated"})
+}
+
+func (h *Handler) HandleDeleteHoliday(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid holiday id: %v", err))
+		return
+	}
+
+	if err := h.store.DeleteHoliday(r.Context(), id); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// HandleImportHolidays bulk-loads a calendar from an .ics or .csv request body.
+// The format comes from ?format= or, failing that, the Content-Type header.
+func (h *Handler) HandleImportHolidays(w http.ResponseWriter, r *http.Request) {
+	calendarCode := r.URL.Query().Get("calendar")
+	if calendarCode == "" {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("missing calendar query parameter"))
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		switch {
+		case strings.HasPrefix(r.Header.Get("Content-Type"), "text/calendar"):
+			format = "ics"
+		case strings.HasPrefix(r.Header.Get("Content-Type"), "text/csv"):
+			format = "csv"
+		}
+	}
+
+	var holidays []*types.Holiday
+	var err error
+	switch format {
+	case "ics":
+		holidays, err = ParseICS(r.Body, calendarCode)
+	case "csv":
+		holidays, err = ParseCSV(r.Body, calendarCode)
+	default:
+		err = fmt.Errorf("unsupported import format %q, expected ics or csv", format)
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	imported, err := h.store.ImportHolidays(r.Context(), holidays)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]int{"parsed": len(holidays), "imported": imported})
+}
+
+func checkHoliday(holiday *types.Holiday) error {
+	if holiday.Calendar == "" {
+		return types.Invalid("calendar", "calendar is required")
+	}
+	if _, err := calendar.ParseInputDate(holiday.Date); err != nil {
+		return types.Invalid("date", "%v", err)
+	}
+	return nil
+}
@@ -0,0 +1,329 @@
+package holiday
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/georgiwritescode/vacation-tool/calendar"
+	"github.com/georgiwritescode/vacation-tool/db"
+	"github.com/georgiwritescode/vacation-tool/service/audit"
+	"github.com/georgiwritescode/vacation-tool/types"
+)
+
+const (
+	SourceManual = "manual"
+	SourceImport = "import"
+)
+
+type Store struct {
+	db *db.DB
+}
+
+func NewStore(db *db.DB) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) FindById(ctx context.Context, id int) (*types.Holiday, error) {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT id, calendar, holiday_date, name, observed, source, ts FROM tbl_holidays WHERE id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	holiday := new(types.Holiday)
+	for rows.Next() {
+		holiday, err = scanRowsIntoHoliday(rows)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if holiday.ID == 0 {
+		return nil, fmt.Errorf("holiday %w", types.ErrNotFound)
+	}
+
+	return holiday, nil
+}
+
+// FindAll lists the holidays of one calendar, or of every calendar when calendar is empty.
+func (s *Store) FindAll(ctx context.Context, calendar string) ([]*types.Holiday, error) {
+	query := "SELECT id, calendar, holiday_date, name, observed, source, ts FROM tbl_holidays"
+	args := []any{}
+	if calendar != "" {
+		query += " WHERE calendar = ?"
+		args = append(args, calendar)
+	}
+	query += " ORDER BY holiday_date, calendar"
+
+	return s.query(ctx, query, args...)
+}
+
+// FindBetween lists the holidays of the given calendars falling between from and to, both inclusive.
+func (s *Store) FindBetween(ctx context.Context, calendars []string, from, to string) ([]*types.Holiday, error) {
+	if len(calendars) == 0 {
+		return []*types.Holiday{}, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(calendars)), ", ")
+	args := make([]any, 0, len(calendars)+2)
+	for _, c := range calendars {
+		args = append(args, c)
+	}
+	args = append(args, from, to)
+
+	return s.query(ctx, "SELECT id, calendar, holiday_date, name, observed, source, ts FROM tbl_holidays WHERE calendar IN ("+placeholders+") AND holiday_date BETWEEN ? AND ? ORDER BY holiday_date, calendar", args...)
+}
+
+func (s *Store) CreateHoliday(ctx context.Context, holiday *types.Holiday) (int, error) {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		return -1, err
+	}
+
+	id, err := tx.Insert("INSERT INTO tbl_holidays (calendar, holiday_date, name, observed, source) VALUES (?, ?, ?, ?, ?)",
+		holiday.Calendar, holiday.Date, holiday.Name, holiday.Observed, SourceManual)
+	if err != nil {
+		tx.Rollback()
+		return -1, err
+	}
+
+	after, err := findHoliday(tx, int(id))
+	if err == nil {
+		err = audit.Record(tx, audit.ActionCreate, audit.EntityHoliday, id, nil, after)
+	}
+	if err != nil {
+		tx.Rollback()
+		return -1, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return -1, err
+	}
+
+	return int(id), nil
+}
+
+// UpdateHoliday edits a holiday. Edited rows become manual overrides so a later import keeps them.
+func (s *Store) UpdateHoliday(ctx context.Context, holiday *types.Holiday) error {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+
+	before, err := findHoliday(tx, holiday.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.Exec("UPDATE tbl_holidays SET calendar=?, holiday_date=?, name=?, observed=?, source=? WHERE id=?",
+		holiday.Calendar, holiday.Date, holiday.Name, holiday.Observed, SourceManual, holiday.ID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	after, err := findHoliday(tx, holiday.ID)
+	if err == nil {
+		err = audit.Record(tx, audit.ActionUpdate, audit.EntityHoliday, holiday.ID, before, after)
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *Store) DeleteHoliday(ctx context.Context, id int) error {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+
+	before, err := findHoliday(tx, id)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM tbl_holidays WHERE id=?", id); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := audit.Record(tx, audit.ActionDelete, audit.EntityHoliday, id, before, nil); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ImportHolidays upserts holidays by calendar and date in one transaction.
+// Manual overrides already present for a date are left untouched.
+func (s *Store) ImportHolidays(ctx context.Context, holidays []*types.Holiday) (int, error) {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	imported := 0
+	for _, h := range holidays {
+		changed, err := importHoliday(tx, h)
+		if err != nil {
+			tx.Rollback()
+			return 0, fmt.Errorf("importing %s %s: %v", h.Calendar, h.Date, err)
+		}
+
+		if changed {
+			imported++
+		}
+	}
+
+	for _, calendar := range calendarsOf(holidays) {
+		after, err := findHolidays(tx, "SELECT id, calendar, holiday_date, name, observed, source, ts FROM tbl_holidays WHERE calendar = ? ORDER BY holiday_date", calendar)
+		if err == nil {
+			err = audit.Record(tx, audit.ActionImport, audit.EntityHoliday, calendar, nil, after)
+		}
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return imported, nil
+}
+
+// importHoliday inserts h, or renames the imported holiday already on its
+// date, and reports whether anything changed.
+func importHoliday(tx *db.Tx, h *types.Holiday) (bool, error) {
+	res, err := tx.Exec("UPDATE tbl_holidays SET name = ? WHERE calendar = ? AND holiday_date = ? AND source <> ? AND name <> ?",
+		h.Name, h.Calendar, h.Date, SourceManual, h.Name)
+	if err != nil {
+		return false, err
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		return true, nil
+	}
+
+	var exists bool
+	err = tx.QueryRow("SELECT EXISTS (SELECT 1 FROM tbl_holidays WHERE calendar = ? AND holiday_date = ?)", h.Calendar, h.Date).Scan(&exists)
+	if err != nil || exists {
+		return false, err
+	}
+
+	_, err = tx.Exec("INSERT INTO tbl_holidays (calendar, holiday_date, name, observed, source) VALUES (?, ?, ?, TRUE, ?)",
+		h.Calendar, h.Date, h.Name, SourceImport)
+	return err == nil, err
+}
+
+// calendarsOf lists the calendars holidays belong to, in the order they first appear.
+func calendarsOf(holidays []*types.Holiday) []string {
+	var calendars []string
+	seen := make(map[string]bool)
+	for _, h := range holidays {
+		if !seen[h.Calendar] {
+			seen[h.Calendar] = true
+			calendars = append(calendars, h.Calendar)
+		}
+	}
+	return calendars
+}
+
+// findHoliday reads a holiday's row within tx, locking it until tx ends.
+func findHoliday(tx *db.Tx, id int) (*types.Holiday, error) {
+	holidays, err := findHolidays(tx, "SELECT id, calendar, holiday_date, name, observed, source, ts FROM tbl_holidays WHERE id = ? FOR UPDATE", id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(holidays) == 0 {
+		return nil, fmt.Errorf("holiday %w", types.ErrNotFound)
+	}
+
+	return holidays[0], nil
+}
+
+// findHolidays runs query within tx.
+func findHolidays(tx *db.Tx, query string, args ...any) ([]*types.Holiday, error) {
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	holidays := make([]*types.Holiday, 0)
+	for rows.Next() {
+		h, err := scanRowsIntoHoliday(rows)
+		if err != nil {
+			return nil, err
+		}
+		holidays = append(holidays, h)
+	}
+
+	return holidays, rows.Err()
+}
+
+func (s *Store) query(ctx context.Context, query string, args ...any) ([]*types.Holiday, error) {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	holidays := make([]*types.Holiday, 0)
+	for rows.Next() {
+		h, err := scanRowsIntoHoliday(rows)
+		if err != nil {
+			return nil, err
+		}
+		holidays = append(holidays, h)
+	}
+
+	return holidays, nil
+}
+
+func scanRowsIntoHoliday(rows *sql.Rows) (*types.Holiday, error) {
+	holiday := new(types.Holiday)
+
+	err := rows.Scan(
+		&holiday.ID,
+		&holiday.Calendar,
+		&holiday.Date,
+		&holiday.Name,
+		&holiday.Observed,
+		&holiday.Source,
+		&holiday.Timestamp,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	holiday.Date = calendar.DateOnly(holiday.Date)
+
+	return holiday, nil
+}
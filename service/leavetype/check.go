@@ -0,0 +1,29 @@
+package leavetype
+
+import (
+	"context"
+	"errors"
+
+	"github.com/georgiwritescode/vacation-tool/types"
+)
+
+// CheckVacation defaults the vacation to paid leave and makes sure its leave
+// type exists and, where the type asks for one, that a document is attached.
+func CheckVacation(ctx context.Context, store types.LeaveTypeStore, vacation *types.Vacation) error {
+	if vacation.LeaveType == "" {
+		vacation.LeaveType = types.LeavePaid
+	}
+
+	leaveType, err := store.FindByCode(ctx, vacation.LeaveType)
+	if errors.Is(err, types.ErrNotFound) {
+		return types.Invalid("leaveType", "unknown leave type %q", vacation.LeaveType)
+	}
+	if err != nil {
+		return err
+	}
+
+	if leaveType.RequiresDocument && vacation.Document == "" {
+		return types.Invalid("document", "%s requires a supporting document", leaveType.Name)
+	}
+	return nil
+}
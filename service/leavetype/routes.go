@@ -0,0 +1,125 @@
+package leavetype
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/georgiwritescode/vacation-tool/service/auth"
+	"github.com/georgiwritescode/vacation-tool/types"
+	"github.com/georgiwritescode/vacation-tool/utils"
+)
+
+type Handler struct {
+	store types.LeaveTypeStore
+}
+
+func NewHandler(store types.LeaveTypeStore) *Handler {
+	return &Handler{store: store}
+}
+
+func (h *Handler) RegisterRoutes(router *http.ServeMux) {
+	router.HandleFunc("GET /api/v1/leave-types/{code}", h.HandleGetByCode)
+	router.HandleFunc("GET /api/v1/leave-types/list", h.HandleListLeaveTypes)
+	router.HandleFunc("POST /api/v1/leave-types/create", auth.AdminOnly(h.HandleCreateLeaveType))
+	router.HandleFunc("PUT /api/v1/leave-types/update", auth.AdminOnly(h.HandleUpdateLeaveType))
+	router.HandleFunc("DELETE /api/v1/leave-types/delete/{code}", auth.AdminOnly(h.HandleDeleteLeaveType))
+}
+
+func (h *Handler) HandleGetByCode(w http.ResponseWriter, r *http.Request) {
+	leaveType, err := h.store.FindByCode(r.Context(), r.PathValue("code"))
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, leaveType)
+}
+
+func (h *Handler) HandleListLeaveTypes(w http.ResponseWriter, r *http.Request) {
+	leaveTypes, err := h.store.FindAll(r.Context())
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, leaveTypes)
+}
+
+func (h *Handler) HandleCreateLeaveType(w http.ResponseWriter, r *http.Request) {
+	var leaveType types.LeaveType
+	if err := utils.ParseJSON(r, &leaveType); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.validate(r.Context(), &leaveType); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.store.CreateLeaveType(r.Context(), &leaveType); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, map[string]string{"code": leaveType.Code})
+}
+
+func (h *Handler) HandleUpdateLeaveType(w http.ResponseWriter, r *http.Request) {
+	var leaveType types.LeaveType
+	if err := utils.ParseJSON(r, &leaveType); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.validate(r.Context(), &leaveType); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.store.UpdateLeaveType(r.Context(), &leaveType); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"status": "updated"})
+}
+
+func (h *Handler) HandleDeleteLeaveType(w http.ResponseWriter, r *http.Request) {
+	code := r.PathValue("code")
+	if code == types.LeavePaid || code == types.LeaveUnpaid {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("leave type %q is built in", code))
+		return
+	}
+
+	if err := h.store.DeleteLeaveType(r.Context(), code); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// validate checks the code and name and that following the fallback chain
+// never leads back to the leave type itself.
+func (h *Handler) validate(ctx context.Context, leaveType *types.LeaveType) error {
+	if leaveType.Code == "" || leaveType.Name == "" {
+		return types.Invalid("code", "code and name are required")
+	}
+
+	seen := map[string]bool{leaveType.Code: true}
+	for next := leaveType.Fallback; next != ""; {
+		if seen[next] {
+			return types.Invalid("fallback", "fallback chain of %q loops back on itself", leaveType.Code)
+		}
+		seen[next] = true
+
+		fallback, err := h.store.FindByCode(ctx, next)
+		if err != nil {
+			return types.Invalid("fallback", "unknown fallback leave type %q", next)
+		}
+		next = fallback.Fallback
+	}
+	return nil
+}
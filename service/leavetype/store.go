@@ -0,0 +1,198 @@
+package leavetype
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/georgiwritescode/vacation-tool/db"
+	"github.com/georgiwritescode/vacation-tool/service/audit"
+	"github.com/georgiwritescode/vacation-tool/types"
+)
+
+const leaveTypeColumns = "code, name, COALESCE(fallback, ''), unlimited, requires_approval, requires_document, ts"
+
+type Store struct {
+	db *db.DB
+}
+
+func NewStore(db *db.DB) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) FindByCode(ctx context.Context, code string) (*types.LeaveType, error) {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT "+leaveTypeColumns+" FROM tbl_leave_types WHERE code = ?", code)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	leaveType := new(types.LeaveType)
+	for rows.Next() {
+		leaveType, err = scanRowsIntoLeaveType(rows)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if leaveType.Code == "" {
+		return nil, fmt.Errorf("leave type %w", types.ErrNotFound)
+	}
+
+	return leaveType, nil
+}
+
+func (s *Store) FindAll(ctx context.Context) ([]*types.LeaveType, error) {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT "+leaveTypeColumns+" FROM tbl_leave_types ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	leaveTypes := make([]*types.LeaveType, 0)
+	for rows.Next() {
+		t, err := scanRowsIntoLeaveType(rows)
+		if err != nil {
+			return nil, err
+		}
+		leaveTypes = append(leaveTypes, t)
+	}
+
+	return leaveTypes, nil
+}
+
+func (s *Store) CreateLeaveType(ctx context.Context, leaveType *types.LeaveType) error {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("INSERT INTO tbl_leave_types (code, name, fallback, unlimited, requires_approval, requires_document) VALUES (?, ?, NULLIF(?, ''), ?, ?, ?)",
+		leaveType.Code, leaveType.Name, leaveType.Fallback, leaveType.Unlimited, leaveType.RequiresApproval, leaveType.RequiresDocument); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	after, err := findLeaveType(tx, leaveType.Code)
+	if err == nil {
+		err = audit.Record(tx, audit.ActionCreate, audit.EntityLeaveType, leaveType.Code, nil, after)
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *Store) UpdateLeaveType(ctx context.Context, leaveType *types.LeaveType) error {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+
+	before, err := findLeaveType(tx, leaveType.Code)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.Exec("UPDATE tbl_leave_types SET name=?, fallback=NULLIF(?, ''), unlimited=?, requires_approval=?, requires_document=? WHERE code=?",
+		leaveType.Name, leaveType.Fallback, leaveType.Unlimited, leaveType.RequiresApproval, leaveType.RequiresDocument, leaveType.Code); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	after, err := findLeaveType(tx, leaveType.Code)
+	if err == nil {
+		err = audit.Record(tx, audit.ActionUpdate, audit.EntityLeaveType, leaveType.Code, before, after)
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *Store) DeleteLeaveType(ctx context.Context, code string) error {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+
+	before, err := findLeaveType(tx, code)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM tbl_leave_types WHERE code=?", code); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := audit.Record(tx, audit.ActionDelete, audit.EntityLeaveType, code, before, nil); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// findLeaveType reads a leave type's row within tx, locking it until tx ends.
+func findLeaveType(tx *db.Tx, code string) (*types.LeaveType, error) {
+	rows, err := tx.Query("SELECT "+leaveTypeColumns+" FROM tbl_leave_types WHERE code = ? FOR UPDATE", code)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	leaveType := new(types.LeaveType)
+	for rows.Next() {
+		leaveType, err = scanRowsIntoLeaveType(rows)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if leaveType.Code == "" {
+		return nil, fmt.Errorf("leave type %w", types.ErrNotFound)
+	}
+
+	return leaveType, nil
+}
+
+func scanRowsIntoLeaveType(rows *sql.Rows) (*types.LeaveType, error) {
+	leaveType := new(types.LeaveType)
+
+	err := rows.Scan(
+		&leaveType.Code,
+		&leaveType.Name,
+		&leaveType.Fallback,
+		&leaveType.Unlimited,
+		&leaveType.RequiresApproval,
+		&leaveType.RequiresDocument,
+		&leaveType.Timestamp,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return leaveType, nil
+}
@@ -0,0 +1,86 @@
+package ledger
+
+import (
+	"database/sql"
+
+	"github.com/georgiwritescode/vacation-tool/db"
+	"github.com/georgiwritescode/vacation-tool/types"
+)
+
+// balanceColumns maps the built-in leave types to the tbl_users column caching
+// their balance. Every other leave type is cached in tbl_balances.
+var balanceColumns = map[string]string{
+	types.LeavePaid:   "vacation_days",
+	types.LeaveUnpaid: "non_paid_leave",
+}
+
+// Apply records entries in the ledger and moves the cached balances on tbl_users
+// by the same amounts. Every balance change goes through here, inside the
+// caller's transaction, so the ledger and the cached balances move together.
+// Zero-amount entries are skipped.
+func Apply(tx *db.Tx, entries ...*types.LedgerEntry) error {
+	for _, e := range entries {
+		if e.Amount == 0 {
+			continue
+		}
+
+		if column, ok := balanceColumns[e.LeaveType]; ok {
+			if _, err := tx.Exec("UPDATE tbl_users SET "+column+" = "+column+" + ? WHERE id = ?", e.Amount, e.UserID); err != nil {
+				return err
+			}
+		} else {
+			_, err := tx.Exec("INSERT INTO tbl_balances (user_id, leave_type, balance) VALUES (?, ?, ?)"+
+				tx.Dialect.OnConflict("user_id", "leave_type")+"balance = tbl_balances.balance + "+tx.Dialect.Excluded("balance"),
+				e.UserID, e.LeaveType, e.Amount)
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err := tx.Exec("INSERT INTO tbl_ledger (user_id, leave_type, amount, reason, vacation_id, reference) VALUES (?, ?, ?, ?, NULLIF(?, 0), ?)",
+			e.UserID, e.LeaveType, e.Amount, e.Reason, e.VacationID, e.Reference)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Balance reads the cached balance of one leave type and locks it for the rest
+// of the transaction.
+func Balance(tx *db.Tx, userID int, leaveType string) (float64, error) {
+	var balance float64
+	var err error
+	if column, ok := balanceColumns[leaveType]; ok {
+		err = tx.QueryRow("SELECT "+column+" FROM tbl_users WHERE id = ? FOR UPDATE", userID).Scan(&balance)
+	} else {
+		err = tx.QueryRow("SELECT balance FROM tbl_balances WHERE user_id = ? AND leave_type = ? FOR UPDATE", userID, leaveType).Scan(&balance)
+		if err == sql.ErrNoRows {
+			return 0, nil
+		}
+	}
+	return balance, err
+}
+
+// Charged sums what vacation id currently holds of the user's balances, per
+// leave type: its bookings minus whatever has been refunded since.
+func Charged(tx *db.Tx, vacationID, userID int) (map[string]float64, error) {
+	rows, err := tx.Query("SELECT leave_type, -SUM(amount) FROM tbl_ledger WHERE vacation_id = ? AND user_id = ? GROUP BY leave_type", vacationID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	charged := make(map[string]float64)
+	for rows.Next() {
+		var leaveType string
+		var days float64
+		if err := rows.Scan(&leaveType, &days); err != nil {
+			return nil, err
+		}
+		if days != 0 {
+			charged[leaveType] = days
+		}
+	}
+	return charged, rows.Err()
+}
@@ -0,0 +1,82 @@
+package ledger
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/georgiwritescode/vacation-tool/policy"
+	"github.com/georgiwritescode/vacation-tool/service/auth"
+	"github.com/georgiwritescode/vacation-tool/types"
+	"github.com/georgiwritescode/vacation-tool/utils"
+)
+
+type Handler struct {
+	store      types.LedgerStore
+	leaveTypes types.LeaveTypeStore
+	policy     *policy.Policy
+}
+
+func NewHandler(store types.LedgerStore, leaveTypes types.LeaveTypeStore, policy *policy.Policy) *Handler {
+	return &Handler{store: store, leaveTypes: leaveTypes, policy: policy}
+}
+
+func (h *Handler) RegisterRoutes(router *http.ServeMux) {
+	router.HandleFunc("GET /api/v1/users/{id}/ledger", h.HandleGetLedger)
+	router.HandleFunc("POST /api/v1/users/{id}/ledger", auth.AdminOnly(h.HandleAdjust))
+}
+
+func (h *Handler) HandleGetLedger(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid user id: %v", err))
+		return
+	}
+
+	if err := h.policy.ViewUser(r.Context(), auth.Actor(r), id); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	summary, err := h.store.Summarize(r.Context(), id)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, summary)
+}
+
+// HandleAdjust records a manual adjustment, e.g. {"leave_type": "paid", "amount": 2, "reference": "overtime March"}.
+func (h *Handler) HandleAdjust(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid user id: %v", err))
+		return
+	}
+
+	var entry types.LedgerEntry
+	if err := utils.ParseJSON(r, &entry); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	if _, err := h.leaveTypes.FindByCode(r.Context(), entry.LeaveType); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, types.Invalid("leave_type", "unknown leave type %q", entry.LeaveType))
+		return
+	}
+	if entry.Amount == 0 {
+		utils.WriteError(w, http.StatusBadRequest, types.Invalid("amount", "amount must not be zero"))
+		return
+	}
+
+	entry.UserID = id
+	entry.Reason = types.ReasonAdjustment
+	entry.VacationID = 0
+
+	if err := h.store.Adjust(r.Context(), &entry); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, map[string]string{"status": "adjusted"})
+}
@@ -0,0 +1,143 @@
+package ledger
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/georgiwritescode/vacation-tool/db"
+	"github.com/georgiwritescode/vacation-tool/service/audit"
+	"github.com/georgiwritescode/vacation-tool/types"
+)
+
+type Store struct {
+	db *db.DB
+}
+
+func NewStore(db *db.DB) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) FindByUser(ctx context.Context, userID int) ([]*types.LedgerEntry, error) {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT id, user_id, leave_type, amount, reason, COALESCE(vacation_id, 0), reference, ts FROM tbl_ledger WHERE user_id = ? ORDER BY id", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]*types.LedgerEntry, 0)
+	for rows.Next() {
+		e, err := scanRowsIntoEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, nil
+}
+
+// Summarize derives the user's balances from the ledger and reconciles them
+// against the balances cached on tbl_users and tbl_balances.
+func (s *Store) Summarize(ctx context.Context, userID int) (*types.LedgerSummary, error) {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	summary := &types.LedgerSummary{
+		UserID:   userID,
+		Ledger:   map[string]float64{types.LeavePaid: 0, types.LeaveUnpaid: 0},
+		Balances: map[string]float64{},
+	}
+
+	var paid, unpaid float64
+	err := s.db.QueryRowContext(ctx, "SELECT vacation_days, non_paid_leave FROM tbl_users WHERE id = ?", userID).Scan(&paid, &unpaid)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("user %w", types.ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	summary.Balances[types.LeavePaid] = paid
+	summary.Balances[types.LeaveUnpaid] = unpaid
+
+	rows, err := s.db.QueryContext(ctx, "SELECT leave_type, balance FROM tbl_balances WHERE user_id = ?", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var leaveType string
+		var balance float64
+		if err := rows.Scan(&leaveType, &balance); err != nil {
+			return nil, err
+		}
+		summary.Balances[leaveType] = balance
+	}
+
+	summary.Entries, err = s.FindByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range summary.Entries {
+		summary.Ledger[e.LeaveType] = types.RoundDays(summary.Ledger[e.LeaveType] + e.Amount)
+	}
+
+	summary.InSync = true
+	for leaveType, balance := range summary.Balances {
+		if summary.Ledger[leaveType] != balance {
+			summary.InSync = false
+		}
+	}
+	for leaveType, amount := range summary.Ledger {
+		if summary.Balances[leaveType] != amount {
+			summary.InSync = false
+		}
+	}
+
+	return summary, nil
+}
+
+// Adjust applies a manual balance adjustment.
+func (s *Store) Adjust(ctx context.Context, entry *types.LedgerEntry) error {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = Apply(tx, entry)
+	if err == nil {
+		err = audit.Record(tx, audit.ActionAdjust, audit.EntityLedger, entry.UserID, nil, entry)
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func scanRowsIntoEntry(rows *sql.Rows) (*types.LedgerEntry, error) {
+	entry := new(types.LedgerEntry)
+
+	err := rows.Scan(
+		&entry.ID,
+		&entry.UserID,
+		&entry.LeaveType,
+		&entry.Amount,
+		&entry.Reason,
+		&entry.VacationID,
+		&entry.Reference,
+		&entry.Timestamp,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
@@ -0,0 +1,158 @@
+package rollover
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/georgiwritescode/vacation-tool/calendar"
+	"github.com/georgiwritescode/vacation-tool/service/auth"
+	"github.com/georgiwritescode/vacation-tool/types"
+	"github.com/georgiwritescode/vacation-tool/utils"
+)
+
+type Handler struct {
+	store types.RolloverStore
+}
+
+func NewHandler(store types.RolloverStore) *Handler {
+	return &Handler{store: store}
+}
+
+func (h *Handler) RegisterRoutes(router *http.ServeMux) {
+	router.HandleFunc("GET /api/v1/rollover/policies", h.HandleListPolicies)
+	router.HandleFunc("POST /api/v1/rollover/policies/create", auth.AdminOnly(h.HandleCreatePolicy))
+	router.HandleFunc("PUT /api/v1/rollover/policies/update", auth.AdminOnly(h.HandleUpdatePolicy))
+	router.HandleFunc("DELETE /api/v1/rollover/policies/delete/{id}", auth.AdminOnly(h.HandleDeletePolicy))
+	router.HandleFunc("GET /api/v1/rollover/{year}/preview", auth.AdminOnly(h.handleRollover(false)))
+	router.HandleFunc("POST /api/v1/rollover/{year}/commit", auth.AdminOnly(h.handleRollover(true)))
+	router.HandleFunc("GET /api/v1/rollover/expire/preview", auth.AdminOnly(h.handleExpire(false)))
+	router.HandleFunc("POST /api/v1/rollover/expire/commit", auth.AdminOnly(h.handleExpire(true)))
+}
+
+func (h *Handler) HandleListPolicies(w http.ResponseWriter, r *http.Request) {
+	policies, err := h.store.FindPolicies(r.Context())
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, policies)
+}
+
+func (h *Handler) HandleCreatePolicy(w http.ResponseWriter, r *http.Request) {
+	var policy types.CarryoverPolicy
+	if err := utils.ParseJSON(r, &policy); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := validate(&policy); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	id, err := h.store.CreatePolicy(r.Context(), &policy)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, map[string]int{"id": id})
+}
+
+func (h *Handler) HandleUpdatePolicy(w http.ResponseWriter, r *http.Request) {
+	var policy types.CarryoverPolicy
+	if err := utils.ParseJSON(r, &policy); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := validate(&policy); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.store.UpdatePolicy(r.Context(), &policy); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"status": "updated"})
+}
+
+func (h *Handler) HandleDeletePolicy(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid policy id: %v", err))
+		return
+	}
+
+	if err := h.store.DeletePolicy(r.Context(), id); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// handleRollover returns a handler closing the leave year in the path; the
+// preview variant reports the carry-overs and forfeits without booking them.
+func (h *Handler) handleRollover(commit bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		year, err := strconv.Atoi(r.PathValue("year"))
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid year: %v", err))
+			return
+		}
+
+		carryovers, err := h.store.Rollover(r.Context(), year, commit)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		utils.WriteJSON(w, http.StatusOK, carryovers)
+	}
+}
+
+// handleExpire returns a handler expiring unused carried days up to ?as_of=,
+// today by default; the preview variant only reports them.
+func (h *Handler) handleExpire(commit bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		asOf := r.URL.Query().Get("as_of")
+		if asOf == "" {
+			asOf = time.Now().Format(calendar.DateLayout)
+		}
+		if _, err := calendar.ParseInputDate(asOf); err != nil {
+			utils.WriteError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		carryovers, err := h.store.Expire(r.Context(), asOf, commit)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		utils.WriteJSON(w, http.StatusOK, carryovers)
+	}
+}
+
+func validate(policy *types.CarryoverPolicy) error {
+	if policy.LeaveType == "" {
+		return types.Invalid("leave_type", "leave_type is required")
+	}
+	if policy.MaxDays < 0 {
+		return types.Invalid("max_days", "max_days must not be negative")
+	}
+	if policy.ExpiresOn != "" {
+		if _, err := time.Parse("01-02", policy.ExpiresOn); err != nil {
+			return types.Invalid("expires_on", "invalid expires_on %q, expected MM-DD", policy.ExpiresOn)
+		}
+	}
+	policy.Country = strings.ToUpper(policy.Country)
+	return nil
+}
@@ -0,0 +1,455 @@
+package rollover
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+
+	"github.com/georgiwritescode/vacation-tool/calendar"
+	"github.com/georgiwritescode/vacation-tool/db"
+	"github.com/georgiwritescode/vacation-tool/service/audit"
+	"github.com/georgiwritescode/vacation-tool/service/ledger"
+	"github.com/georgiwritescode/vacation-tool/types"
+)
+
+const carryoverColumns = "id, user_id, leave_type, year, country, balance, carried, forfeited, expires_on, used, expired, ts"
+
+type Store struct {
+	db *db.DB
+}
+
+func NewStore(db *db.DB) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) FindPolicies(ctx context.Context) ([]*types.CarryoverPolicy, error) {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT id, leave_type, country, max_days, expires_on, ts FROM tbl_carryover_policies ORDER BY leave_type, country")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	policies := make([]*types.CarryoverPolicy, 0)
+	for rows.Next() {
+		p := new(types.CarryoverPolicy)
+		if err := rows.Scan(&p.ID, &p.LeaveType, &p.Country, &p.MaxDays, &p.ExpiresOn, &p.Timestamp); err != nil {
+			return nil, err
+		}
+		policies = append(policies, p)
+	}
+
+	return policies, nil
+}
+
+func (s *Store) CreatePolicy(ctx context.Context, policy *types.CarryoverPolicy) (int, error) {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		return -1, err
+	}
+
+	id, err := tx.Insert("INSERT INTO tbl_carryover_policies (leave_type, country, max_days, expires_on) VALUES (?, ?, ?, ?)",
+		policy.LeaveType, policy.Country, policy.MaxDays, policy.ExpiresOn)
+	if err != nil {
+		tx.Rollback()
+		return -1, err
+	}
+
+	after, err := findPolicy(tx, int(id))
+	if err == nil {
+		err = audit.Record(tx, audit.ActionCreate, audit.EntityRolloverPolicy, id, nil, after)
+	}
+	if err != nil {
+		tx.Rollback()
+		return -1, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return -1, err
+	}
+
+	return int(id), nil
+}
+
+func (s *Store) UpdatePolicy(ctx context.Context, policy *types.CarryoverPolicy) error {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+
+	before, err := findPolicy(tx, policy.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.Exec("UPDATE tbl_carryover_policies SET leave_type=?, country=?, max_days=?, expires_on=? WHERE id=?",
+		policy.LeaveType, policy.Country, policy.MaxDays, policy.ExpiresOn, policy.ID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	after, err := findPolicy(tx, policy.ID)
+	if err == nil {
+		err = audit.Record(tx, audit.ActionUpdate, audit.EntityRolloverPolicy, policy.ID, before, after)
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *Store) DeletePolicy(ctx context.Context, id int) error {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+
+	before, err := findPolicy(tx, id)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM tbl_carryover_policies WHERE id=?", id); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := audit.Record(tx, audit.ActionDelete, audit.EntityRolloverPolicy, id, before, nil); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// findPolicy reads a policy's row within tx, locking it until tx ends.
+func findPolicy(tx *db.Tx, id int) (*types.CarryoverPolicy, error) {
+	p := new(types.CarryoverPolicy)
+	err := tx.QueryRow("SELECT id, leave_type, country, max_days, expires_on, ts FROM tbl_carryover_policies WHERE id = ? FOR UPDATE", id).
+		Scan(&p.ID, &p.LeaveType, &p.Country, &p.MaxDays, &p.ExpiresOn, &p.Timestamp)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("carry-over policy %w", types.ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// Rollover closes leave year `year` for every user and leave type with a
+// carry-over policy. Each balance as of December 31, per the ledger, is capped
+// at the policy's maximum and the excess forfeited. Users already rolled over
+// for the year are skipped, so the action can be repeated safely. Without
+// commit the work is rolled back and only the report is returned.
+func (s *Store) Rollover(ctx context.Context, year int, commit bool) ([]*types.Carryover, error) {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	carryovers, err := rollover(tx, year, commit)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if !commit {
+		return carryovers, tx.Rollback()
+	}
+	if err := audit.Record(tx, audit.ActionRun, audit.EntityRollover, year, nil, carryovers); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	return carryovers, tx.Commit()
+}
+
+func rollover(tx *db.Tx, year int, commit bool) ([]*types.Carryover, error) {
+	policies, err := policiesByLeaveType(tx)
+	if err != nil {
+		return nil, err
+	}
+	leaveTypes := make([]string, 0, len(policies))
+	for leaveType := range policies {
+		leaveTypes = append(leaveTypes, leaveType)
+	}
+	sort.Strings(leaveTypes)
+
+	countries, err := userCountries(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	done, err := rolledOver(tx, year)
+	if err != nil {
+		return nil, err
+	}
+
+	yearEnd := fmt.Sprintf("%d-01-01", year+1)
+	carryovers := make([]*types.Carryover, 0)
+	for _, userID := range sortedIDs(countries) {
+		country := countries[userID]
+		for _, leaveType := range leaveTypes {
+			policy := policyFor(policies[leaveType], country)
+			if policy == nil || done[rolloverKey(userID, leaveType)] {
+				continue
+			}
+
+			c := &types.Carryover{UserID: userID, LeaveType: leaveType, Year: year, Country: country}
+			err := tx.QueryRow("SELECT COALESCE(SUM(amount), 0) FROM tbl_ledger WHERE user_id = ? AND leave_type = ? AND ts < ?", userID, leaveType, yearEnd).Scan(&c.Balance)
+			if err != nil {
+				return nil, err
+			}
+
+			if c.Balance > 0 {
+				c.Carried = min(c.Balance, policy.MaxDays)
+				c.Forfeited = types.RoundDays(c.Balance - c.Carried)
+			}
+			if c.Carried > 0 && policy.ExpiresOn != "" {
+				c.ExpiresOn = fmt.Sprintf("%d-%s", year+1, policy.ExpiresOn)
+			}
+			carryovers = append(carryovers, c)
+
+			if !commit {
+				continue
+			}
+
+			err = ledger.Apply(tx, &types.LedgerEntry{UserID: userID, LeaveType: leaveType, Amount: -c.Forfeited, Reason: types.ReasonForfeit, Reference: fmt.Sprintf("rollover %d", year)})
+			if err != nil {
+				return nil, err
+			}
+
+			id, err := tx.Insert("INSERT INTO tbl_carryovers (user_id, leave_type, year, country, balance, carried, forfeited, expires_on) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+				c.UserID, c.LeaveType, c.Year, c.Country, c.Balance, c.Carried, c.Forfeited, db.NullIfEmpty(c.ExpiresOn))
+			if err != nil {
+				return nil, err
+			}
+			c.ID = int(id)
+		}
+	}
+
+	return carryovers, nil
+}
+
+// Expire forfeits the carried days still unused on their expiry date, for
+// every rollover whose expiry date is on or before asOf. Days booked in the
+// new year count against the carried days first. Without commit the work is
+// rolled back and only the report is returned.
+func (s *Store) Expire(ctx context.Context, asOf string, commit bool) ([]*types.Carryover, error) {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	if _, err := calendar.ParseInputDate(asOf); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	carryovers, err := expire(tx, asOf, commit)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if !commit {
+		return carryovers, tx.Rollback()
+	}
+	if err := audit.Record(tx, audit.ActionRun, audit.EntityExpiry, asOf, nil, carryovers); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	return carryovers, tx.Commit()
+}
+
+func expire(tx *db.Tx, asOf string, commit bool) ([]*types.Carryover, error) {
+	rows, err := tx.Query("SELECT "+carryoverColumns+" FROM tbl_carryovers WHERE expiry_done = FALSE AND expires_on IS NOT NULL AND expires_on <= ? ORDER BY id FOR UPDATE", asOf)
+	if err != nil {
+		return nil, err
+	}
+	carryovers := make([]*types.Carryover, 0)
+	for rows.Next() {
+		c, err := scanRowsIntoCarryover(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		carryovers = append(carryovers, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, c := range carryovers {
+		until, err := calendar.NextDay(c.ExpiresOn)
+		if err != nil {
+			return nil, err
+		}
+		err = tx.QueryRow("SELECT COALESCE(-SUM(amount), 0) FROM tbl_ledger WHERE user_id = ? AND leave_type = ? AND reason IN (?, ?) AND ts >= ? AND ts < ?",
+			c.UserID, c.LeaveType, types.ReasonBooking, types.ReasonRefund, fmt.Sprintf("%d-01-01", c.Year+1), until).Scan(&c.Used)
+		if err != nil {
+			return nil, err
+		}
+		c.Used = max(c.Used, 0)
+
+		balance, err := ledger.Balance(tx, c.UserID, c.LeaveType)
+		if err != nil {
+			return nil, err
+		}
+		c.Expired = types.RoundDays(max(min(c.Carried-c.Used, balance), 0))
+
+		if !commit {
+			continue
+		}
+
+		err = ledger.Apply(tx, &types.LedgerEntry{UserID: c.UserID, LeaveType: c.LeaveType, Amount: -c.Expired, Reason: types.ReasonExpiry, Reference: fmt.Sprintf("rollover %d", c.Year)})
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := tx.Exec("UPDATE tbl_carryovers SET expiry_done = TRUE, used = ?, expired = ? WHERE id = ?", c.Used, c.Expired, c.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return carryovers, nil
+}
+
+func policiesByLeaveType(tx *db.Tx) (map[string][]*types.CarryoverPolicy, error) {
+	rows, err := tx.Query("SELECT id, leave_type, country, max_days, expires_on FROM tbl_carryover_policies")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	policies := make(map[string][]*types.CarryoverPolicy)
+	for rows.Next() {
+		p := new(types.CarryoverPolicy)
+		if err := rows.Scan(&p.ID, &p.LeaveType, &p.Country, &p.MaxDays, &p.ExpiresOn); err != nil {
+			return nil, err
+		}
+		policies[p.LeaveType] = append(policies[p.LeaveType], p)
+	}
+	return policies, rows.Err()
+}
+
+// policyFor picks the policy for country, falling back to the default one.
+func policyFor(policies []*types.CarryoverPolicy, country string) *types.CarryoverPolicy {
+	var fallback *types.CarryoverPolicy
+	for _, p := range policies {
+		if p.Country == country {
+			return p
+		}
+		if p.Country == "" {
+			fallback = p
+		}
+	}
+	return fallback
+}
+
+// userCountries maps every user to the country of their holiday calendar and
+// locks their rows for the rollover.
+func userCountries(tx *db.Tx) (map[int]string, error) {
+	rows, err := tx.Query("SELECT id, holiday_calendar FROM tbl_users FOR UPDATE")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	countries := make(map[int]string)
+	for rows.Next() {
+		var id int
+		var code string
+		if err := rows.Scan(&id, &code); err != nil {
+			return nil, err
+		}
+		if scopes := calendar.Scopes(code); len(scopes) > 0 {
+			countries[id] = scopes[0]
+		} else {
+			countries[id] = ""
+		}
+	}
+	return countries, rows.Err()
+}
+
+// rolledOver lists the user and leave type pairs already rolled over for year.
+func rolledOver(tx *db.Tx, year int) (map[string]bool, error) {
+	rows, err := tx.Query("SELECT user_id, leave_type FROM tbl_carryovers WHERE year = ?", year)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	done := make(map[string]bool)
+	for rows.Next() {
+		var userID int
+		var leaveType string
+		if err := rows.Scan(&userID, &leaveType); err != nil {
+			return nil, err
+		}
+		done[rolloverKey(userID, leaveType)] = true
+	}
+	return done, rows.Err()
+}
+
+func rolloverKey(userID int, leaveType string) string {
+	return fmt.Sprintf("%d/%s", userID, leaveType)
+}
+
+func sortedIDs(m map[int]string) []int {
+	ids := make([]int, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+func scanRowsIntoCarryover(rows *sql.Rows) (*types.Carryover, error) {
+	c := new(types.Carryover)
+	var expiresOn sql.NullString
+
+	err := rows.Scan(
+		&c.ID,
+		&c.UserID,
+		&c.LeaveType,
+		&c.Year,
+		&c.Country,
+		&c.Balance,
+		&c.Carried,
+		&c.Forfeited,
+		&expiresOn,
+		&c.Used,
+		&c.Expired,
+		&c.Timestamp,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	c.ExpiresOn = calendar.DateOnly(expiresOn.String)
+
+	return c, nil
+}
@@ -0,0 +1,213 @@
+package rule
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/georgiwritescode/vacation-tool/calendar"
+	"github.com/georgiwritescode/vacation-tool/types"
+)
+
+// Evaluator checks vacations against the capacity and blackout rules of the requester's team.
+type Evaluator struct {
+	rules     types.RuleStore
+	users     types.UserStore
+	vacations types.VacationStore
+	holidays  types.HolidayStore
+}
+
+func NewEvaluator(rules types.RuleStore, users types.UserStore, vacations types.VacationStore, holidays types.HolidayStore) *Evaluator {
+	return &Evaluator{rules: rules, users: users, vacations: vacations, holidays: holidays}
+}
+
+// member is what capacity rules need to know about someone: their team and
+// the days they would be at work.
+type member struct {
+	team     int
+	schedule calendar.Schedule
+	holidays calendar.Holidays
+}
+
+// works reports whether the member is due at work on day.
+func (m *member) works(day time.Time) bool {
+	return m.schedule.Works(day) && !m.holidays.Contains(day)
+}
+
+// Evaluate lists every rule the vacation would break. Capacity is counted
+// against the other people's approved vacations in the same date range, on
+// the days both the requester and the absentee would otherwise work.
+func (e *Evaluator) Evaluate(ctx context.Context, vacation *types.Vacation) ([]types.RuleViolation, error) {
+	from, err := calendar.ParseDate(vacation.FromDate)
+	if err != nil {
+		return nil, err
+	}
+	to, err := calendar.ParseDate(vacation.ToDate)
+	if err != nil {
+		return nil, err
+	}
+	fromDate, toDate := from.Format(calendar.DateLayout), to.Format(calendar.DateLayout)
+
+	requester, err := e.users.FindById(ctx, vacation.PersonId)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := e.rules.FindApplicable(ctx, requester.TeamID)
+	if err != nil {
+		return nil, err
+	}
+
+	violations := make([]types.RuleViolation, 0)
+	var absences []*types.Vacation
+	var members map[int]*member
+
+	for _, rule := range rules {
+		switch rule.Kind {
+		case types.RuleBlackout:
+			if rule.FromDate <= toDate && rule.ToDate >= fromDate {
+				violations = append(violations, violation(rule, fmt.Sprintf("%s: no leave between %s and %s", rule.Label, rule.FromDate, rule.ToDate)))
+			}
+
+		case types.RuleMaxConcurrent:
+			if absences == nil {
+				if absences, err = e.vacations.GetVacationsBetween(ctx, fromDate, toDate); err != nil {
+					return nil, err
+				}
+				if members, err = e.members(ctx, fromDate, toDate); err != nil {
+					return nil, err
+				}
+			}
+
+			if day, out := busiestDay(rule, vacation, absences, members, from, to); out+1 > rule.MaxAbsent {
+				violations = append(violations, violation(rule, fmt.Sprintf("%s: %d of at most %d already out on %s", rule.Label, out, rule.MaxAbsent, day)))
+			}
+		}
+	}
+
+	return violations, nil
+}
+
+// CheckCreate rejects a new vacation breaking a block rule. Broken flag rules
+// only mark the vacation as flagged for the approving manager.
+func (e *Evaluator) CheckCreate(ctx context.Context, vacation *types.Vacation) error {
+	violations, err := e.Evaluate(ctx, vacation)
+	if err != nil {
+		return err
+	}
+
+	if blocking := filter(violations, types.ActionBlock); len(blocking) > 0 {
+		return &types.RuleViolationError{Violations: blocking}
+	}
+
+	vacation.Flagged = len(violations) > 0
+	vacation.FlagReason = summarize(violations)
+	return nil
+}
+
+// CheckApproval re-evaluates the rules at approval time. Block rules always
+// stop the approval; flag rules stop it unless the manager overrides them.
+func (e *Evaluator) CheckApproval(ctx context.Context, vacation *types.Vacation, override bool) error {
+	violations, err := e.Evaluate(ctx, vacation)
+	if err != nil {
+		return err
+	}
+
+	if blocking := filter(violations, types.ActionBlock); len(blocking) > 0 {
+		return &types.RuleViolationError{Violations: blocking}
+	}
+	if len(violations) > 0 && !override {
+		return &types.RuleViolationError{Violations: violations, NeedsOverride: true}
+	}
+	return nil
+}
+
+// members loads everyone's team, work schedule and holidays between fromDate
+// and toDate, keyed by user id.
+func (e *Evaluator) members(ctx context.Context, fromDate, toDate string) (map[int]*member, error) {
+	users, err := e.users.FetchAllUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byCalendar := make(map[string]calendar.Holidays)
+	members := make(map[int]*member, len(users))
+	for _, u := range users {
+		schedule, err := calendar.ParseSchedule(u.WorkDays)
+		if err != nil {
+			return nil, err
+		}
+
+		holidays, ok := byCalendar[u.HolidayCalendar]
+		if !ok {
+			rows, err := e.holidays.FindBetween(ctx, calendar.Scopes(u.HolidayCalendar), fromDate, toDate)
+			if err != nil {
+				return nil, err
+			}
+			holidays = calendar.NewHolidays(rows)
+			byCalendar[u.HolidayCalendar] = holidays
+		}
+
+		members[u.ID] = &member{team: u.TeamID, schedule: schedule, holidays: holidays}
+	}
+	return members, nil
+}
+
+// busiestDay finds the working day of the vacation with the most other people
+// in the rule's scope already out, and how many that is. People only count as
+// out on the days they would otherwise work.
+func busiestDay(rule *types.Rule, vacation *types.Vacation, absences []*types.Vacation, members map[int]*member, from, to time.Time) (string, int) {
+	busiest, most := from.Format(calendar.DateLayout), 0
+	requester := members[vacation.PersonId]
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		if requester == nil || !requester.works(day) {
+			continue
+		}
+
+		date := day.Format(calendar.DateLayout)
+		out := make(map[int]bool)
+		for _, a := range absences {
+			if a.PersonId == vacation.PersonId || a.ID == vacation.ID {
+				continue
+			}
+			absentee := members[a.PersonId]
+			if absentee == nil || rule.TeamID != 0 && absentee.team != rule.TeamID {
+				continue
+			}
+			if !absentee.works(day) {
+				continue
+			}
+			if calendar.DateOnly(a.FromDate) <= date && calendar.DateOnly(a.ToDate) >= date {
+				out[a.PersonId] = true
+			}
+		}
+
+		if len(out) > most {
+			busiest, most = date, len(out)
+		}
+	}
+	return busiest, most
+}
+
+func violation(rule *types.Rule, message string) types.RuleViolation {
+	return types.RuleViolation{RuleID: rule.ID, Label: rule.Label, Action: rule.Action, Message: message}
+}
+
+func filter(violations []types.RuleViolation, action string) []types.RuleViolation {
+	matching := make([]types.RuleViolation, 0)
+	for _, v := range violations {
+		if v.Action == action {
+			matching = append(matching, v)
+		}
+	}
+	return matching
+}
+
+func summarize(violations []types.RuleViolation) string {
+	messages := make([]string, len(violations))
+	for i, v := range violations {
+		messages[i] = v.Message
+	}
+	return strings.Join(messages, "; ")
+}
@@ -0,0 +1,41 @@
+package rule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/georgiwritescode/vacation-tool/calendar"
+	"github.com/georgiwritescode/vacation-tool/types"
+)
+
+func TestBusiestDay(t *testing.T) {
+	weekend := calendar.Schedule{time.Saturday: true, time.Sunday: true}
+	monday := calendar.NewHolidays([]*types.Holiday{{Calendar: "XH", Date: "2032-03-01", Observed: true}})
+	members := map[int]*member{
+		1: {team: 7, schedule: calendar.FullWeek},
+		2: {team: 7, schedule: weekend},
+		3: {team: 7, schedule: weekend},
+		4: {team: 7, schedule: calendar.FullWeek, holidays: monday},
+		5: {team: 8, schedule: weekend},
+	}
+	rule := &types.Rule{TeamID: 7}
+	absences := []*types.Vacation{
+		{ID: 10, PersonId: 2, FromDate: "2032-02-28", ToDate: "2032-03-01"},
+		{ID: 11, PersonId: 4, FromDate: "2032-03-01", ToDate: "2032-03-01"},
+		{ID: 12, PersonId: 5, FromDate: "2032-02-28", ToDate: "2032-03-01"},
+	}
+	day := func(date string) time.Time {
+		d, _ := calendar.ParseDate(date)
+		return d
+	}
+
+	// The weekday worker's Monday: person 2 is off by schedule, person 4 by holiday.
+	if busiest, out := busiestDay(rule, &types.Vacation{PersonId: 1}, absences, members, day("2032-03-01"), day("2032-03-01")); out != 0 {
+		t.Errorf("Monday: %d out on %s, want 0", out, busiest)
+	}
+
+	// The weekend worker's weekend: person 2 is out, person 5 is on another team.
+	if busiest, out := busiestDay(rule, &types.Vacation{PersonId: 3}, absences, members, day("2032-02-28"), day("2032-03-01")); busiest != "2032-02-28" || out != 1 {
+		t.Errorf("weekend: %d out on %s, want 1 on 2032-02-28", out, busiest)
+	}
+}
@@ -0,0 +1,146 @@
+package rule
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/georgiwritescode/vacation-tool/calendar"
+	"github.com/georgiwritescode/vacation-tool/service/auth"
+	"github.com/georgiwritescode/vacation-tool/types"
+	"github.com/georgiwritescode/vacation-tool/utils"
+)
+
+type Handler struct {
+	store types.RuleStore
+}
+
+func NewHandler(store types.RuleStore) *Handler {
+	return &Handler{store: store}
+}
+
+func (h *Handler) RegisterRoutes(router *http.ServeMux) {
+	router.HandleFunc("GET /api/v1/rules/{id}", h.HandleGetByID)
+	router.HandleFunc("GET /api/v1/rules/list", h.HandleListRules)
+	router.HandleFunc("POST /api/v1/rules/create", auth.AdminOnly(h.HandleCreateRule))
+	router.HandleFunc("PUT /api/v1/rules/update", auth.AdminOnly(h.HandleUpdateRule))
+	router.HandleFunc("DELETE /api/v1/rules/delete/{id}", auth.AdminOnly(h.HandleDeleteRule))
+}
+
+func (h *Handler) HandleGetByID(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid rule id: %v", err))
+		return
+	}
+
+	rule, err := h.store.FindById(r.Context(), id)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, rule)
+}
+
+func (h *Handler) HandleListRules(w http.ResponseWriter, r *http.Request) {
+	rules, err := h.store.FindAll(r.Context())
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, rules)
+}
+
+func (h *Handler) HandleCreateRule(w http.ResponseWriter, r *http.Request) {
+	var rule types.Rule
+	if err := utils.ParseJSON(r, &rule); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := validate(&rule); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	id, err := h.store.CreateRule(r.Context(), &rule)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, map[string]int{"id": id})
+}
+
+func (h *Handler) HandleUpdateRule(w http.ResponseWriter, r *http.Request) {
+	var rule types.Rule
+	if err := utils.ParseJSON(r, &rule); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := validate(&rule); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.store.UpdateRule(r.Context(), &rule); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"status": "updated"})
+}
+
+func (h *Handler) HandleDeleteRule(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid rule id: %v", err))
+		return
+	}
+
+	if err := h.store.DeleteRule(r.Context(), id); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// validate checks that the rule carries the fields its kind needs.
+func validate(rule *types.Rule) error {
+	if rule.Action != types.ActionBlock && rule.Action != types.ActionFlag {
+		return types.Invalid("action", "action must be %q or %q", types.ActionBlock, types.ActionFlag)
+	}
+
+	switch rule.Kind {
+	case types.RuleMaxConcurrent:
+		if rule.MaxAbsent < 1 {
+			return types.Invalid("max_absent", "max_absent must be at least 1")
+		}
+		rule.FromDate, rule.ToDate = "", ""
+	case types.RuleBlackout:
+		from, err := calendar.ParseInputDate(rule.FromDate)
+		if err != nil {
+			return types.Invalid("fromDate", "%v", err)
+		}
+		to, err := calendar.ParseInputDate(rule.ToDate)
+		if err != nil {
+			return types.Invalid("toDate", "%v", err)
+		}
+		if to.Before(from) {
+			return types.Invalid("toDate", "toDate must not be before fromDate")
+		}
+		rule.FromDate, rule.ToDate = from.Format(calendar.DateLayout), to.Format(calendar.DateLayout)
+		rule.MaxAbsent = 0
+	default:
+		return types.Invalid("kind", "kind must be %q or %q", types.RuleMaxConcurrent, types.RuleBlackout)
+	}
+
+	if rule.Label == "" {
+		rule.Label = rule.Kind
+	}
+	return nil
+}
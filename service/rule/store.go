@@ -0,0 +1,218 @@
+package rule
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/georgiwritescode/vacation-tool/calendar"
+	"github.com/georgiwritescode/vacation-tool/db"
+	"github.com/georgiwritescode/vacation-tool/service/audit"
+	"github.com/georgiwritescode/vacation-tool/types"
+)
+
+const ruleColumns = "id, kind, label, COALESCE(team_id, 0), max_absent, from_date, to_date, action, ts"
+
+type Store struct {
+	db *db.DB
+}
+
+func NewStore(db *db.DB) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) FindById(ctx context.Context, id int) (*types.Rule, error) {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT "+ruleColumns+" FROM tbl_rules WHERE id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rule := new(types.Rule)
+	for rows.Next() {
+		rule, err = scanRowsIntoRule(rows)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if rule.ID == 0 {
+		return nil, fmt.Errorf("rule %w", types.ErrNotFound)
+	}
+
+	return rule, nil
+}
+
+func (s *Store) FindAll(ctx context.Context) ([]*types.Rule, error) {
+	return s.query(ctx, "SELECT "+ruleColumns+" FROM tbl_rules ORDER BY id")
+}
+
+// FindApplicable lists the company-wide rules plus the rules of the given team.
+func (s *Store) FindApplicable(ctx context.Context, teamID int) ([]*types.Rule, error) {
+	return s.query(ctx, "SELECT "+ruleColumns+" FROM tbl_rules WHERE team_id IS NULL OR team_id = ? ORDER BY id", teamID)
+}
+
+func (s *Store) CreateRule(ctx context.Context, rule *types.Rule) (int, error) {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		return -1, err
+	}
+
+	id, err := tx.Insert("INSERT INTO tbl_rules (kind, label, team_id, max_absent, from_date, to_date, action) VALUES (?, ?, NULLIF(?, 0), ?, ?, ?, ?)",
+		rule.Kind, rule.Label, rule.TeamID, rule.MaxAbsent, db.NullIfEmpty(rule.FromDate), db.NullIfEmpty(rule.ToDate), rule.Action)
+	if err != nil {
+		tx.Rollback()
+		return -1, err
+	}
+
+	after, err := findRule(tx, int(id))
+	if err == nil {
+		err = audit.Record(tx, audit.ActionCreate, audit.EntityRule, id, nil, after)
+	}
+	if err != nil {
+		tx.Rollback()
+		return -1, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return -1, err
+	}
+
+	return int(id), nil
+}
+
+func (s *Store) UpdateRule(ctx context.Context, rule *types.Rule) error {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+
+	before, err := findRule(tx, rule.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.Exec("UPDATE tbl_rules SET kind=?, label=?, team_id=NULLIF(?, 0), max_absent=?, from_date=?, to_date=?, action=? WHERE id=?",
+		rule.Kind, rule.Label, rule.TeamID, rule.MaxAbsent, db.NullIfEmpty(rule.FromDate), db.NullIfEmpty(rule.ToDate), rule.Action, rule.ID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	after, err := findRule(tx, rule.ID)
+	if err == nil {
+		err = audit.Record(tx, audit.ActionUpdate, audit.EntityRule, rule.ID, before, after)
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *Store) DeleteRule(ctx context.Context, id int) error {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+
+	before, err := findRule(tx, id)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM tbl_rules WHERE id=?", id); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := audit.Record(tx, audit.ActionDelete, audit.EntityRule, id, before, nil); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *Store) query(ctx context.Context, query string, args ...any) ([]*types.Rule, error) {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := make([]*types.Rule, 0)
+	for rows.Next() {
+		r, err := scanRowsIntoRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, r)
+	}
+
+	return rules, nil
+}
+
+// findRule reads a rule's row within tx, locking it until tx ends.
+func findRule(tx *db.Tx, id int) (*types.Rule, error) {
+	rows, err := tx.Query("SELECT "+ruleColumns+" FROM tbl_rules WHERE id = ? FOR UPDATE", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rule := new(types.Rule)
+	for rows.Next() {
+		rule, err = scanRowsIntoRule(rows)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if rule.ID == 0 {
+		return nil, fmt.Errorf("rule %w", types.ErrNotFound)
+	}
+
+	return rule, nil
+}
+
+func scanRowsIntoRule(rows *sql.Rows) (*types.Rule, error) {
+	rule := new(types.Rule)
+	var fromDate, toDate sql.NullString
+
+	err := rows.Scan(
+		&rule.ID,
+		&rule.Kind,
+		&rule.Label,
+		&rule.TeamID,
+		&rule.MaxAbsent,
+		&fromDate,
+		&toDate,
+		&rule.Action,
+		&rule.Timestamp,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	rule.FromDate, rule.ToDate = calendar.DateOnly(fromDate.String), calendar.DateOnly(toDate.String)
+
+	return rule, nil
+}
@@ -0,0 +1,99 @@
+package team
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/georgiwritescode/vacation-tool/service/auth"
+	"github.com/georgiwritescode/vacation-tool/types"
+	"github.com/georgiwritescode/vacation-tool/utils"
+)
+
+type Handler struct {
+	store types.TeamStore
+}
+
+func NewHandler(store types.TeamStore) *Handler {
+	return &Handler{store: store}
+}
+
+func (h *Handler) RegisterRoutes(router *http.ServeMux) {
+	router.HandleFunc("GET /api/v1/teams/{id}", h.HandleGetByID)
+	router.HandleFunc("GET /api/v1/teams/list", h.HandleListTeams)
+	router.HandleFunc("POST /api/v1/teams/create", auth.AdminOnly(h.HandleCreateTeam))
+	router.HandleFunc("PUT /api/v1/teams/update", auth.AdminOnly(h.HandleUpdateTeam))
+	router.HandleFunc("DELETE /api/v1/teams/delete/{id}", auth.AdminOnly(h.HandleDeleteTeam))
+}
+
+func (h *Handler) HandleGetByID(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid team id: %v", err))
+		return
+	}
+
+	team, err := h.store.FindById(r.Context(), id)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, team)
+}
+
+func (h *Handler) HandleListTeams(w http.ResponseWriter, r *http.Request) {
+	teams, err := h.store.FindAll(r.Context())
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, teams)
+}
+
+func (h *Handler) HandleCreateTeam(w http.ResponseWriter, r *http.Request) {
+	var team types.Team
+	if err := utils.ParseJSON(r, &team); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	id, err := h.store.CreateTeam(r.Context(), &team)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, map[string]int{"id": id})
+}
+
+func (h *Handler) HandleUpdateTeam(w http.ResponseWriter, r *http.Request) {
+	var team types.Team
+	if err := utils.ParseJSON(r, &team); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.store.UpdateTeam(r.Context(), &team); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"status": "updated"})
+}
+
+func (h *Handler) HandleDeleteTeam(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid team id: %v", err))
+		return
+	}
+
+	if err := h.store.DeleteTeam(r.Context(), id); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
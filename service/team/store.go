@@ -0,0 +1,223 @@
+package team
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/georgiwritescode/vacation-tool/db"
+	"github.com/georgiwritescode/vacation-tool/service/audit"
+	"github.com/georgiwritescode/vacation-tool/types"
+)
+
+const teamColumns = "id, name, COALESCE(department_id, 0), ts"
+
+type Store struct {
+	db *db.DB
+}
+
+func NewStore(db *db.DB) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) FindById(ctx context.Context, id int) (*types.Team, error) {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT "+teamColumns+" FROM tbl_teams WHERE id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	team := new(types.Team)
+	for rows.Next() {
+		team, err = scanRowsIntoTeam(rows)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if team.ID == 0 {
+		return nil, fmt.Errorf("team %w", types.ErrNotFound)
+	}
+
+	members, err := s.getMembersByTeamId(ctx, team.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching members: %v", err)
+	}
+	team.Members = members
+
+	return team, nil
+}
+
+func (s *Store) FindAll(ctx context.Context) ([]*types.Team, error) {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT "+teamColumns+" FROM tbl_teams ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	teams := make([]*types.Team, 0)
+	for rows.Next() {
+		t, err := scanRowsIntoTeam(rows)
+		if err != nil {
+			return nil, err
+		}
+		teams = append(teams, t)
+	}
+
+	return teams, nil
+}
+
+func (s *Store) CreateTeam(ctx context.Context, team *types.Team) (int, error) {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		return -1, err
+	}
+
+	id, err := tx.Insert("INSERT INTO tbl_teams (name, department_id) VALUES (?, NULLIF(?, 0))", team.Name, team.DepartmentID)
+	if err != nil {
+		tx.Rollback()
+		return -1, err
+	}
+
+	after, err := findTeam(tx, int(id))
+	if err == nil {
+		err = audit.Record(tx, audit.ActionCreate, audit.EntityTeam, id, nil, after)
+	}
+	if err != nil {
+		tx.Rollback()
+		return -1, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return -1, err
+	}
+
+	return int(id), nil
+}
+
+func (s *Store) UpdateTeam(ctx context.Context, team *types.Team) error {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+
+	before, err := findTeam(tx, team.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.Exec("UPDATE tbl_teams SET name=?, department_id=NULLIF(?, 0) WHERE id=?", team.Name, team.DepartmentID, team.ID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	after, err := findTeam(tx, team.ID)
+	if err == nil {
+		err = audit.Record(tx, audit.ActionUpdate, audit.EntityTeam, team.ID, before, after)
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *Store) DeleteTeam(ctx context.Context, id int) error {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+
+	before, err := findTeam(tx, id)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM tbl_teams WHERE id=?", id); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := audit.Record(tx, audit.ActionDelete, audit.EntityTeam, id, before, nil); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// findTeam reads a team's row within tx, locking it until tx ends.
+func findTeam(tx *db.Tx, id int) (*types.Team, error) {
+	rows, err := tx.Query("SELECT "+teamColumns+" FROM tbl_teams WHERE id = ? FOR UPDATE", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	team := new(types.Team)
+	for rows.Next() {
+		team, err = scanRowsIntoTeam(rows)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if team.ID == 0 {
+		return nil, fmt.Errorf("team %w", types.ErrNotFound)
+	}
+
+	return team, nil
+}
+
+func scanRowsIntoTeam(rows *sql.Rows) (*types.Team, error) {
+	team := new(types.Team)
+
+	err := rows.Scan(
+		&team.ID,
+		&team.Name,
+		&team.DepartmentID,
+		&team.Timestamp,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return team, nil
+}
+
+func (s *Store) getMembersByTeamId(ctx context.Context, teamId int) ([]*types.User, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT id, first_name, last_name, email, COALESCE(manager_id, 0) FROM tbl_users WHERE team_id = ?", teamId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := make([]*types.User, 0)
+	for rows.Next() {
+		u := &types.User{TeamID: teamId}
+		if err := rows.Scan(&u.ID, &u.FirstName, &u.LastName, &u.Email, &u.ManagerID); err != nil {
+			return nil, err
+		}
+		members = append(members, u)
+	}
+
+	return members, nil
+}
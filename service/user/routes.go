@@ -3,36 +3,52 @@ package user
 import (
 	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
 
+	"github.com/georgiwritescode/vacation-tool/policy"
+	"github.com/georgiwritescode/vacation-tool/service/auth"
 	"github.com/georgiwritescode/vacation-tool/types"
 	"github.com/georgiwritescode/vacation-tool/utils"
+	"github.com/georgiwritescode/vacation-tool/validation"
 )
 
 type Handler struct {
-	store types.UserStore
+	store  types.UserStore
+	policy *policy.Policy
 }
 
-func NewHandler(store types.UserStore) *Handler {
-	return &Handler{store: store}
+func NewHandler(store types.UserStore, policy *policy.Policy) *Handler {
+	return &Handler{store: store, policy: policy}
 }
 
 func (h *Handler) RegisterRoutes(router *http.ServeMux) {
 	router.HandleFunc("GET /api/v1/users/{id}", h.HandleGetByID)
 	router.HandleFunc("GET /api/v1/users/list", h.HandleListAllUsers)
+	router.HandleFunc("GET /api/v1/users/{id}/reports", h.HandleListReports)
 	router.HandleFunc("POST /api/v1/users/create", h.HandleCreateUser)
 	router.HandleFunc("PUT /api/v1/users/update", h.HandleUpdateUser)
 	router.HandleFunc("DELETE /api/v1/users/delete/{id}", h.HandleDeleteUser)
 }
 
 func (h *Handler) HandleUpdateUser(w http.ResponseWriter, r *http.Request) {
+	if err := h.policy.ManageUsers(auth.Actor(r)); err != nil {
+		utils.WriteError(w, http.StatusForbidden, err)
+		return
+	}
+
 	var user types.User
 	if err := utils.ParseJSON(r, &user); err != nil {
 		utils.WriteError(w, http.StatusBadRequest, err)
 		return
 	}
 
-	if err := h.store.UpdateUser(&user); err != nil {
+	if err := validation.User(&user); err != nil {
+		utils.WriteError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	if err := h.store.UpdateUser(r.Context(), &user); err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
@@ -48,7 +64,12 @@ func (h *Handler) HandleDeleteUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.store.DeleteUser(id); err != nil {
+	if err := h.policy.ManageUsers(auth.Actor(r)); err != nil {
+		utils.WriteError(w, http.StatusForbidden, err)
+		return
+	}
+
+	if err := h.store.DeleteUser(r.Context(), id); err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
@@ -65,7 +86,12 @@ func (h *Handler) HandleGetByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := h.store.FindById(id)
+	if err := h.policy.ViewUser(r.Context(), auth.Actor(r), id); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	user, err := h.store.FindById(r.Context(), id)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
@@ -75,22 +101,40 @@ func (h *Handler) HandleGetByID(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) HandleCreateUser(w http.ResponseWriter, r *http.Request) {
+	if err := h.policy.ManageUsers(auth.Actor(r)); err != nil {
+		utils.WriteError(w, http.StatusForbidden, err)
+		return
+	}
 
 	var user types.User
 
 	if err := utils.ParseJSON(r, &user); err != nil {
 		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := validation.User(&user); err != nil {
+		utils.WriteError(w, http.StatusUnprocessableEntity, err)
+		return
 	}
 
-	res, err := h.store.CreateUser(&types.User{
-		ID:           user.ID,
-		FirstName:    user.FirstName,
-		LastName:     user.LastName,
-		Age:          user.Age,
-		Email:        user.Email,
-		VacationDays: user.VacationDays,
-		NonPaidLeave: user.NonPaidLeave,
-		Timestamp:    user.Timestamp,
+	res, err := h.store.CreateUser(r.Context(), &types.User{
+		ID:              user.ID,
+		FirstName:       user.FirstName,
+		LastName:        user.LastName,
+		Age:             user.Age,
+		Email:           user.Email,
+		VacationDays:    user.VacationDays,
+		NonPaidLeave:    user.NonPaidLeave,
+		HolidayCalendar: user.HolidayCalendar,
+		ManagerID:       user.ManagerID,
+		TeamID:          user.TeamID,
+		HireDate:        user.HireDate,
+		WorkDays:        user.WorkDays,
+		FTE:             user.FTE,
+		Role:            user.Role,
+		Password:        user.Password,
+		Timestamp:       user.Timestamp,
 	})
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
@@ -100,12 +144,58 @@ func (h *Handler) HandleCreateUser(w http.ResponseWriter, r *http.Request) {
 	utils.WriteJSON(w, http.StatusOK, fmt.Sprintf("user with id: %d created", res))
 }
 
+// HandleListAllUsers lists one page of the users the caller may see. The
+// total count and the links to the neighbouring pages are sent as headers.
 func (h *Handler) HandleListAllUsers(w http.ResponseWriter, r *http.Request) {
+	filter, err := ParseFilter(r.URL.Query())
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
 
-	res, err := h.store.FetchAllUsers()
+	if filter.UserIDs, err = h.policy.VisibleUsers(r.Context(), auth.Actor(r)); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	res, total, err := h.store.FindPage(r.Context(), filter)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
 	}
 
+	utils.WritePageHeaders(w, r, filter.Page, total)
 	utils.WriteJSON(w, http.StatusOK, res)
 }
+
+// ParseFilter reads a user listing's filters from a query string: q searches
+// names and emails, and limit, offset, sort and order page the result.
+func ParseFilter(q url.Values) (types.UserFilter, error) {
+	page, err := utils.ParsePage(q, sortColumns)
+	if err != nil {
+		return types.UserFilter{}, err
+	}
+
+	return types.UserFilter{Search: q.Get("q"), Page: page}, nil
+}
+
+func (h *Handler) HandleListReports(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid user id: %v", err))
+		return
+	}
+
+	if err := h.policy.ViewUser(r.Context(), auth.Actor(r), id); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	reports, err := h.store.FindReports(r.Context(), id)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, reports)
+}
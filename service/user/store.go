@@ -1,23 +1,35 @@
 package user
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 
+	"github.com/georgiwritescode/vacation-tool/calendar"
+	"github.com/georgiwritescode/vacation-tool/db"
+	"github.com/georgiwritescode/vacation-tool/service/audit"
+	"github.com/georgiwritescode/vacation-tool/service/auth"
+	"github.com/georgiwritescode/vacation-tool/service/ledger"
 	"github.com/georgiwritescode/vacation-tool/types"
+	"github.com/georgiwritescode/vacation-tool/utils"
 )
 
+const userColumns = "id, first_name, last_name, age, email, vacation_days, non_paid_leave, holiday_calendar, COALESCE(manager_id, 0), COALESCE(team_id, 0), hire_date, work_days, fte, role, ts"
+
 type Store struct {
-	db *sql.DB
+	db *db.DB
 }
 
-func NewStore(db *sql.DB) *Store {
+func NewStore(db *db.DB) *Store {
 	return &Store{db: db}
 }
 
-func (s *Store) FindById(id int) (*types.User, error) {
+func (s *Store) FindById(ctx context.Context, id int) (*types.User, error) {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
 
-	rows, err := s.db.Query("SELECT id, first_name, last_name, age, email, vacation_days, non_paid_leave, ts from tbl_users where id = ?", id)
+	rows, err := s.db.QueryContext(ctx, "SELECT "+userColumns+" from tbl_users where id = ?", id)
 	if err != nil {
 		return nil, err
 	}
@@ -30,38 +42,114 @@ func (s *Store) FindById(id int) (*types.User, error) {
 		}
 	}
 	if user.ID == 0 {
-		return nil, fmt.Errorf("user not found")
+		return nil, fmt.Errorf("user %w", types.ErrNotFound)
 	}
 
-	vacations, err := s.getVacationsByUserId(user.ID)
+	vacations, err := s.getVacationsByUserId(ctx, user.ID)
 	if err != nil {
 		return nil, fmt.Errorf("error fetching vacations: %v", err)
 	}
 	user.Vacations = vacations
 
+	user.Balances, err = s.getBalances(ctx, user)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching balances: %v", err)
+	}
+
 	return user, nil
 }
 
-func (s *Store) CreateUser(req *types.User) (int, error) {
-	// Set default vacation days if 0 (or trust the DB default, but explicit is nicer if passed)
+func (s *Store) FindByEmail(ctx context.Context, email string) (*types.User, error) {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	var id int
+	err := s.db.QueryRowContext(ctx, "SELECT id FROM tbl_users WHERE email = ?", email).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("user %w", types.ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.FindById(ctx, id)
+}
+
+func (s *Store) CreateUser(ctx context.Context, req *types.User) (int, error) {
+	if err := normalizeSchedule(req); err != nil {
+		return -1, err
+	}
+	if req.Role == "" {
+		req.Role = types.RoleEmployee
+	}
+
+	var passwordHash string
+	if req.Password != "" {
+		var err error
+		if passwordHash, err = auth.HashPassword(req.Password); err != nil {
+			return -1, err
+		}
+	}
+
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		return -1, err
+	}
+
+	// Set default vacation days if 0, prorated to the user's FTE, unless paid leave is accrued instead
 	if req.VacationDays == 0 {
-		req.VacationDays = 20
+		var accrued bool
+		err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM tbl_accrual_policies WHERE leave_type = ?)", types.LeavePaid).Scan(&accrued)
+		if err != nil {
+			tx.Rollback()
+			return -1, err
+		}
+		if !accrued {
+			req.VacationDays = types.ProrateFTE(20, req.FTE)
+		}
 	}
-	res, err := s.db.Exec("INSERT INTO tbl_users (first_name, last_name, age, email, vacation_days, non_paid_leave) values (?, ?, ?, ?, ?, ?)", req.FirstName, req.LastName, req.Age, req.Email, req.VacationDays, req.NonPaidLeave)
+
+	// Balances start at zero and are granted through the ledger
+	id, err := tx.Insert("INSERT INTO tbl_users (first_name, last_name, age, email, password_hash, role, vacation_days, non_paid_leave, holiday_calendar, work_days, fte, manager_id, team_id, hire_date) values (?, ?, ?, ?, ?, ?, 0, 0, ?, ?, ?, NULLIF(?, 0), NULLIF(?, 0), ?)",
+		req.FirstName, req.LastName, req.Age, req.Email, passwordHash, req.Role, req.HolidayCalendar, req.WorkDays, req.FTE, req.ManagerID, req.TeamID, db.NullIfEmpty(req.HireDate))
+	if err != nil {
+		tx.Rollback()
+		return -1, err
+	}
+
+	err = ledger.Apply(tx,
+		&types.LedgerEntry{UserID: int(id), LeaveType: types.LeavePaid, Amount: req.VacationDays, Reason: types.ReasonGrant, Reference: "initial entitlement"},
+		&types.LedgerEntry{UserID: int(id), LeaveType: types.LeaveUnpaid, Amount: req.NonPaidLeave, Reason: types.ReasonGrant, Reference: "initial entitlement"},
+	)
 	if err != nil {
+		tx.Rollback()
 		return -1, err
 	}
 
-	id, err := res.LastInsertId()
+	after, err := findUser(tx, int(id))
+	if err == nil {
+		err = audit.Record(tx, audit.ActionCreate, audit.EntityUser, id, nil, after)
+	}
 	if err != nil {
+		tx.Rollback()
+		return -1, err
+	}
+
+	if err := tx.Commit(); err != nil {
 		return -1, err
 	}
 
 	return int(id), nil
 }
 
-func (s *Store) FetchAllUsers() ([]*types.User, error) {
-	rows, err := s.db.Query("SELECT id, first_name, last_name, age, email, vacation_days, non_paid_leave, ts from tbl_users")
+func (s *Store) FetchAllUsers(ctx context.Context) ([]*types.User, error) {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT "+userColumns+" from tbl_users")
 	if err != nil {
 		return nil, err
 	}
@@ -77,19 +165,273 @@ func (s *Store) FetchAllUsers() ([]*types.User, error) {
 	return users, nil
 }
 
-func (s *Store) UpdateUser(user *types.User) error {
-	_, err := s.db.Exec("UPDATE tbl_users SET first_name=?, last_name=?, age=?, email=?, vacation_days=?, non_paid_leave=? WHERE id=?",
-		user.FirstName, user.LastName, user.Age, user.Email, user.VacationDays, user.NonPaidLeave, user.ID)
-	return err
+// sortColumns maps the fields user lists may be sorted by to their columns.
+var sortColumns = map[string]string{
+	"id":             "id",
+	"first_name":     "first_name",
+	"last_name":      "last_name",
+	"email":          "email",
+	"vacation_days":  "vacation_days",
+	"non_paid_leave": "non_paid_leave",
+	"ts":             "ts",
+}
+
+// FindPage lists one page of the users matching filter, along with how many
+// match in total.
+func (s *Store) FindPage(ctx context.Context, filter types.UserFilter) ([]*types.User, int, error) {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	where, args := []string{"1 = 1"}, []any{}
+	if filter.Search != "" {
+		pattern := utils.ContainsPattern(filter.Search)
+		where, args = append(where, "(CONCAT(first_name, ' ', last_name) LIKE ? ESCAPE '!' OR email LIKE ? ESCAPE '!')"), append(args, pattern, pattern)
+	}
+	if filter.UserIDs != nil {
+		in, inArgs := utils.InList("id", filter.UserIDs)
+		where, args = append(where, in), append(args, inArgs...)
+	}
+	cond := " WHERE " + strings.Join(where, " AND ")
+
+	var total int
+	if err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM tbl_users"+cond, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	order, pageArgs := utils.OrderBy(sortColumns, filter.Page)
+	rows, err := s.db.QueryContext(ctx, "SELECT "+userColumns+" FROM tbl_users"+cond+order, append(args, pageArgs...)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	users := make([]*types.User, 0)
+	for rows.Next() {
+		user, err := scanRowsIntoUser(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		users = append(users, user)
+	}
+
+	return users, total, rows.Err()
 }
 
-func (s *Store) DeleteUser(id int) error {
-	_, err := s.db.Exec("DELETE FROM tbl_users WHERE id=?", id)
-	return err
+// FindReports lists the users whose manager is managerID.
+func (s *Store) FindReports(ctx context.Context, managerID int) ([]*types.User, error) {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT "+userColumns+" from tbl_users where manager_id = ?", managerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make([]*types.User, 0)
+	for rows.Next() {
+		user, err := scanRowsIntoUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+func (s *Store) UpdateUser(ctx context.Context, user *types.User) error {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	if err := s.checkManagerCycle(ctx, user.ID, user.ManagerID); err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+
+	before, err := findUser(tx, user.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// Clients that predate work schedules keep the user's current one
+	if user.WorkDays == "" {
+		user.WorkDays = before.WorkDays
+	}
+	if user.FTE == 0 {
+		user.FTE = before.FTE
+	}
+	if user.Role == "" {
+		user.Role = before.Role
+	}
+	if err := normalizeSchedule(user); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE tbl_users SET first_name=?, last_name=?, age=?, email=?, role=?, holiday_calendar=?, work_days=?, fte=?, manager_id=NULLIF(?, 0), team_id=NULLIF(?, 0), hire_date=? WHERE id=?",
+		user.FirstName, user.LastName, user.Age, user.Email, user.Role, user.HolidayCalendar, user.WorkDays, user.FTE, user.ManagerID, user.TeamID, db.NullIfEmpty(user.HireDate), user.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// An empty password keeps the current one
+	if user.Password != "" {
+		hash, err := auth.HashPassword(user.Password)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		if _, err := tx.Exec("UPDATE tbl_users SET password_hash=? WHERE id=?", hash, user.ID); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	// Edited balances are recorded as adjustments rather than overwritten
+	err = ledger.Apply(tx,
+		&types.LedgerEntry{UserID: user.ID, LeaveType: types.LeavePaid, Amount: types.RoundDays(user.VacationDays - before.VacationDays), Reason: types.ReasonAdjustment, Reference: "user profile edit"},
+		&types.LedgerEntry{UserID: user.ID, LeaveType: types.LeaveUnpaid, Amount: types.RoundDays(user.NonPaidLeave - before.NonPaidLeave), Reason: types.ReasonAdjustment, Reference: "user profile edit"},
+	)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	after, err := findUser(tx, user.ID)
+	if err == nil {
+		err = audit.Record(tx, audit.ActionUpdate, audit.EntityUser, user.ID, before, after)
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// normalizeSchedule validates the user's work schedule, defaulting to a
+// full-time Monday to Friday week, and stores the weekdays in canonical form.
+func normalizeSchedule(user *types.User) error {
+	schedule, err := calendar.ParseSchedule(user.WorkDays)
+	if err != nil {
+		return err
+	}
+	if schedule == (calendar.Schedule{}) {
+		return types.Invalid("work_days", "work days must include at least one weekday")
+	}
+	user.WorkDays = schedule.String()
+
+	if user.FTE == 0 {
+		user.FTE = types.FullTime
+	}
+	if user.FTE < 0 || user.FTE > types.FullTime {
+		return types.Invalid("fte", "fte must be between 1 and %d, got %d", types.FullTime, user.FTE)
+	}
+	return nil
+}
+
+// checkManagerCycle walks up the chain from managerID and fails if it reaches userID.
+func (s *Store) checkManagerCycle(ctx context.Context, userID, managerID int) error {
+	seen := make(map[int]bool)
+	for id := managerID; id != 0; {
+		if id == userID {
+			return types.Invalid("manager_id", "manager %d would create a reporting cycle for user %d", managerID, userID)
+		}
+		if seen[id] {
+			return nil
+		}
+		seen[id] = true
+
+		err := s.db.QueryRowContext(ctx, "SELECT COALESCE(manager_id, 0) FROM tbl_users WHERE id = ?", id).Scan(&id)
+		if err == sql.ErrNoRows {
+			return types.Invalid("manager_id", "manager %d not found", managerID)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Store) DeleteUser(ctx context.Context, id int) error {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+
+	before, err := findUser(tx, id)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM tbl_users WHERE id=?", id); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := audit.Record(tx, audit.ActionDelete, audit.EntityUser, id, before, nil); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// findUser reads a user's row and balances within tx, locking the row until tx ends.
+func findUser(tx *db.Tx, id int) (*types.User, error) {
+	rows, err := tx.Query("SELECT "+userColumns+" FROM tbl_users WHERE id = ? FOR UPDATE", id)
+	if err != nil {
+		return nil, err
+	}
+
+	user := new(types.User)
+	for rows.Next() {
+		user, err = scanRowsIntoUser(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+	}
+	rows.Close()
+	if user.ID == 0 {
+		return nil, fmt.Errorf("user %w", types.ErrNotFound)
+	}
+
+	user.Balances = map[string]float64{
+		types.LeavePaid:   user.VacationDays,
+		types.LeaveUnpaid: user.NonPaidLeave,
+	}
+	rows, err = tx.Query("SELECT leave_type, balance FROM tbl_balances WHERE user_id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var leaveType string
+		var balance float64
+		if err := rows.Scan(&leaveType, &balance); err != nil {
+			return nil, err
+		}
+		user.Balances[leaveType] = balance
+	}
+
+	return user, rows.Err()
 }
 
 func scanRowsIntoUser(rows *sql.Rows) (*types.User, error) {
 	user := new(types.User)
+	var hireDate sql.NullString
 
 	err := rows.Scan(
 		&user.ID,
@@ -99,17 +441,26 @@ func scanRowsIntoUser(rows *sql.Rows) (*types.User, error) {
 		&user.Email,
 		&user.VacationDays,
 		&user.NonPaidLeave,
+		&user.HolidayCalendar,
+		&user.ManagerID,
+		&user.TeamID,
+		&hireDate,
+		&user.WorkDays,
+		&user.FTE,
+		&user.Role,
 		&user.Timestamp,
 	)
 
 	if err != nil {
 		return nil, err
 	}
+
+	user.HireDate = calendar.DateOnly(hireDate.String)
 	return user, nil
 }
 
-func (s *Store) getVacationsByUserId(userId int) ([]*types.Vacation, error) {
-	rows, err := s.db.Query("SELECT * FROM tbl_vacations WHERE person_id = ?", userId)
+func (s *Store) getVacationsByUserId(ctx context.Context, userId int) ([]*types.Vacation, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT id, label, from_date, to_date, person_id, ts, days_used, status, leave_type, document, paid_days, non_paid_days, flagged, flag_reason, portion, hours FROM tbl_vacations WHERE person_id = ?", userId)
 	if err != nil {
 		return nil, err
 	}
@@ -126,6 +477,15 @@ func (s *Store) getVacationsByUserId(userId int) ([]*types.Vacation, error) {
 			&v.PersonId,
 			&v.Timestamp,
 			&v.DaysUsed,
+			&v.Status,
+			&v.LeaveType,
+			&v.Document,
+			&v.PaidDays,
+			&v.NonPaidDays,
+			&v.Flagged,
+			&v.FlagReason,
+			&v.Portion,
+			&v.Hours,
 		)
 		if err != nil {
 			return nil, err
@@ -135,3 +495,28 @@ func (s *Store) getVacationsByUserId(userId int) ([]*types.Vacation, error) {
 
 	return vacations, nil
 }
+
+// getBalances lists the user's balance of every leave type they hold one of.
+func (s *Store) getBalances(ctx context.Context, user *types.User) (map[string]float64, error) {
+	balances := map[string]float64{
+		types.LeavePaid:   user.VacationDays,
+		types.LeaveUnpaid: user.NonPaidLeave,
+	}
+
+	rows, err := s.db.QueryContext(ctx, "SELECT leave_type, balance FROM tbl_balances WHERE user_id = ?", user.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var leaveType string
+		var balance float64
+		if err := rows.Scan(&leaveType, &balance); err != nil {
+			return nil, err
+		}
+		balances[leaveType] = balance
+	}
+
+	return balances, nil
+}
@@ -1,21 +1,37 @@
 package vacation
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
+	"time"
 
+	"github.com/georgiwritescode/vacation-tool/calendar"
+	"github.com/georgiwritescode/vacation-tool/hierarchy"
+	"github.com/georgiwritescode/vacation-tool/policy"
+	"github.com/georgiwritescode/vacation-tool/service/auth"
+	"github.com/georgiwritescode/vacation-tool/service/leavetype"
+	"github.com/georgiwritescode/vacation-tool/service/rule"
 	"github.com/georgiwritescode/vacation-tool/types"
 	"github.com/georgiwritescode/vacation-tool/utils"
+	"github.com/georgiwritescode/vacation-tool/validation"
 )
 
 type Handler struct {
-	store types.VacationStore
+	store        types.VacationStore
+	userStore    types.UserStore
+	holidayStore types.HolidayStore
+	leaveTypes   types.LeaveTypeStore
+	rules        *rule.Evaluator
+	policy       *policy.Policy
 }
 
-func NewHandler(store types.VacationStore) *Handler {
-	return &Handler{store: store}
+func NewHandler(store types.VacationStore, userStore types.UserStore, holidayStore types.HolidayStore, leaveTypes types.LeaveTypeStore, rules *rule.Evaluator, policy *policy.Policy) *Handler {
+	return &Handler{store: store, userStore: userStore, holidayStore: holidayStore, leaveTypes: leaveTypes, rules: rules, policy: policy}
 }
 
 func (h *Handler) RegisterRoutes(router *http.ServeMux) {
@@ -24,6 +40,10 @@ func (h *Handler) RegisterRoutes(router *http.ServeMux) {
 	router.HandleFunc("POST /api/v1/vacations/create", h.HandleCreateVacation)
 	router.HandleFunc("PUT /api/v1/vacations/update", h.HandleUpdateVacation)
 	router.HandleFunc("DELETE /api/v1/vacations/delete/{id}", h.HandleDeleteVacation)
+	router.HandleFunc("GET /api/v1/vacations/{id}/approver", h.HandleGetApprover)
+	router.HandleFunc("POST /api/v1/vacations/{id}/approve", h.handleTransition(types.StatusApproved))
+	router.HandleFunc("POST /api/v1/vacations/{id}/reject", h.handleTransition(types.StatusRejected))
+	router.HandleFunc("POST /api/v1/vacations/{id}/cancel", h.handleTransition(types.StatusCancelled))
 }
 
 func (h *Handler) HandleGetByID(w http.ResponseWriter, r *http.Request) {
@@ -35,25 +55,83 @@ func (h *Handler) HandleGetByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	vacation, err := h.store.FindById(id)
+	vacation, err := h.store.FindById(r.Context(), id)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
 
+	if err := h.policy.ViewUser(r.Context(), auth.Actor(r), vacation.PersonId); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
 	utils.WriteJSON(w, http.StatusOK, vacation)
 }
 
+// HandleListVacations lists one page of the vacations the caller may see.
+// The total count and the links to the neighbouring pages are sent as headers.
 func (h *Handler) HandleListVacations(w http.ResponseWriter, r *http.Request) {
-	vacations, err := h.store.FindAll()
+	filter, err := ParseFilter(r.URL.Query())
 	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if filter.UserIDs, err = h.policy.VisibleUsers(r.Context(), auth.Actor(r)); err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
 
+	vacations, total, err := h.store.FindPage(r.Context(), filter)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WritePageHeaders(w, r, filter.Page, total)
 	utils.WriteJSON(w, http.StatusOK, vacations)
 }
 
+// ParseFilter reads a vacation listing's filters from a query string:
+// person_id, from and to (keeping requests that overlap the range), q (label
+// search), status, and the paging parameters limit, offset, sort and order.
+func ParseFilter(q url.Values) (types.VacationFilter, error) {
+	page, err := utils.ParsePage(q, sortColumns)
+	if err != nil {
+		return types.VacationFilter{}, err
+	}
+
+	filter := types.VacationFilter{
+		FromDate: q.Get("from"),
+		ToDate:   q.Get("to"),
+		Label:    q.Get("q"),
+		Status:   types.VacationStatus(q.Get("status")),
+		Page:     page,
+	}
+
+	if v := q.Get("person_id"); v != "" {
+		if filter.PersonID, err = strconv.Atoi(v); err != nil {
+			return types.VacationFilter{}, types.Invalid("person_id", "invalid person_id %q", v)
+		}
+	}
+	for param, date := range map[string]string{"from": filter.FromDate, "to": filter.ToDate} {
+		if date == "" {
+			continue
+		}
+		if _, err := calendar.ParseInputDate(date); err != nil {
+			return types.VacationFilter{}, types.Invalid(param, "%v", err)
+		}
+	}
+	switch filter.Status {
+	case "", types.StatusPending, types.StatusApproved, types.StatusRejected, types.StatusCancelled:
+	default:
+		return types.VacationFilter{}, types.Invalid("status", "unknown status %q", filter.Status)
+	}
+
+	return filter, nil
+}
+
 func (h *Handler) HandleCreateVacation(w http.ResponseWriter, r *http.Request) {
 	var vacation types.Vacation
 	if err := json.NewDecoder(r.Body).Decode(&vacation); err != nil {
@@ -61,7 +139,32 @@ func (h *Handler) HandleCreateVacation(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	id, err := h.store.CreateVacation(&vacation)
+	if err := validation.Vacation(r.Context(), h.userStore, &vacation); err != nil {
+		utils.WriteError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	if err := h.policy.FileVacation(auth.Actor(r), vacation.PersonId); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := h.resolveDaysUsed(r.Context(), &vacation); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := leavetype.CheckVacation(r.Context(), h.leaveTypes, &vacation); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.rules.CheckCreate(r.Context(), &vacation); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	id, err := h.store.CreateVacation(r.Context(), &vacation)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
@@ -77,7 +180,43 @@ func (h *Handler) HandleUpdateVacation(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.store.UpdateVacation(&vacation); err != nil {
+	if err := validation.Vacation(r.Context(), h.userStore, &vacation); err != nil {
+		utils.WriteError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	if err := h.policy.FileVacation(auth.Actor(r), vacation.PersonId); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := h.resolveDaysUsed(r.Context(), &vacation); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	current, err := h.store.FindById(r.Context(), vacation.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := h.policy.EditVacation(auth.Actor(r), current); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// Clients that predate leave types keep the request's current type
+	if vacation.LeaveType == "" {
+		vacation.LeaveType, vacation.Document = current.LeaveType, current.Document
+	}
+
+	if err := leavetype.CheckVacation(r.Context(), h.leaveTypes, &vacation); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.store.UpdateVacation(r.Context(), &vacation); err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
@@ -93,10 +232,100 @@ func (h *Handler) HandleDeleteVacation(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.store.DeleteVacation(id); err != nil {
+	vacation, err := h.store.FindById(r.Context(), id)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := h.policy.EditVacation(auth.Actor(r), vacation); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := h.store.DeleteVacation(r.Context(), id); err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	utils.WriteJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
 }
+
+// HandleGetApprover resolves who should approve the request today,
+// skipping managers who are on vacation themselves.
+func (h *Handler) HandleGetApprover(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid vacation id: %v", err))
+		return
+	}
+
+	vacation, err := h.store.FindById(r.Context(), id)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := h.policy.ViewUser(r.Context(), auth.Actor(r), vacation.PersonId); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	approver, err := hierarchy.ResolveApprover(r.Context(), h.userStore, h.store, vacation.PersonId, time.Now().Format(calendar.DateLayout))
+	if errors.Is(err, hierarchy.ErrNoApprover) {
+		utils.WriteError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, approver)
+}
+
+// handleTransition returns a handler moving the vacation in the path to status.
+// Approvals re-check the team rules; ?override=true lets flagged ones through.
+func (h *Handler) handleTransition(status types.VacationStatus) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid vacation id: %v", err))
+			return
+		}
+
+		vacation, err := h.store.FindById(r.Context(), id)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		if err := h.policy.Transition(r.Context(), auth.Actor(r), vacation, status); err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		if status == types.StatusApproved {
+			if err := h.rules.CheckApproval(r.Context(), vacation, r.URL.Query().Get("override") == "true"); err != nil {
+				utils.WriteError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		if err := h.store.TransitionVacation(r.Context(), id, status); err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		utils.WriteJSON(w, http.StatusOK, map[string]string{"status": string(status)})
+	}
+}
+
+func (h *Handler) resolveDaysUsed(ctx context.Context, vacation *types.Vacation) error {
+	schedule, holidays, err := calendar.ForUser(ctx, h.userStore, h.holidayStore, vacation.PersonId, vacation.FromDate, vacation.ToDate)
+	if err != nil {
+		return err
+	}
+
+	return calendar.ResolveDaysUsed(vacation, schedule, holidays)
+}
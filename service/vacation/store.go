@@ -1,26 +1,39 @@
 package vacation
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"math"
+	"sort"
+	"strings"
 
+	"github.com/georgiwritescode/vacation-tool/db"
+	"github.com/georgiwritescode/vacation-tool/service/audit"
+	"github.com/georgiwritescode/vacation-tool/service/ledger"
 	"github.com/georgiwritescode/vacation-tool/types"
+	"github.com/georgiwritescode/vacation-tool/utils"
 )
 
+const vacationColumns = "id, label, from_date, to_date, person_id, ts, days_used, status, leave_type, document, paid_days, non_paid_days, flagged, flag_reason, portion, hours"
+
 type Store struct {
-	db *sql.DB
+	db *db.DB
 }
 
-func NewStore(db *sql.DB) *Store {
+func NewStore(db *db.DB) *Store {
 	return &Store{db: db}
 }
 
-func (s *Store) FindById(id int) (*types.Vacation, error) {
+func (s *Store) FindById(ctx context.Context, id int) (*types.Vacation, error) {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
 
-	rows, err := s.db.Query("SELECT * from tbl_vacations where id = ?", id)
+	rows, err := s.db.QueryContext(ctx, "SELECT "+vacationColumns+" from tbl_vacations where id = ?", id)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
 	vacation := new(types.Vacation)
 	for rows.Next() {
@@ -31,14 +44,17 @@ func (s *Store) FindById(id int) (*types.Vacation, error) {
 	}
 
 	if vacation.ID == 0 {
-		return nil, fmt.Errorf("vacation not found :( ")
+		return nil, fmt.Errorf("vacation %w", types.ErrNotFound)
 	}
 
 	return vacation, nil
 }
 
-func (s *Store) FindAll() ([]*types.Vacation, error) {
-	rows, err := s.db.Query("SELECT * FROM tbl_vacations")
+func (s *Store) FindAll(ctx context.Context) ([]*types.Vacation, error) {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT "+vacationColumns+" FROM tbl_vacations")
 	if err != nil {
 		return nil, err
 	}
@@ -56,63 +72,132 @@ func (s *Store) FindAll() ([]*types.Vacation, error) {
 	return vacations, nil
 }
 
-func (s *Store) CreateVacation(vacation *types.Vacation) (int, error) {
-	// Start transaction
-	tx, err := s.db.Begin()
-	if err != nil {
-		return 0, err
+// sortColumns maps the fields vacation lists may be sorted by to their columns.
+var sortColumns = map[string]string{
+	"id":        "id",
+	"label":     "label",
+	"from_date": "from_date",
+	"to_date":   "to_date",
+	"person_id": "person_id",
+	"days_used": "days_used",
+	"status":    "status",
+	"ts":        "ts",
+}
+
+// FindPage lists one page of the vacations matching filter, along with how
+// many match in total.
+func (s *Store) FindPage(ctx context.Context, filter types.VacationFilter) ([]*types.Vacation, int, error) {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	where, args := []string{"1 = 1"}, []any{}
+	if filter.PersonID != 0 {
+		where, args = append(where, "person_id = ?"), append(args, filter.PersonID)
+	}
+	if filter.FromDate != "" {
+		where, args = append(where, "to_date >= ?"), append(args, filter.FromDate)
+	}
+	if filter.ToDate != "" {
+		where, args = append(where, "from_date <= ?"), append(args, filter.ToDate)
+	}
+	if filter.Label != "" {
+		where, args = append(where, "label LIKE ? ESCAPE '!'"), append(args, utils.ContainsPattern(filter.Label))
 	}
+	if filter.Status != "" {
+		where, args = append(where, "status = ?"), append(args, filter.Status)
+	}
+	if filter.UserIDs != nil {
+		in, inArgs := utils.InList("person_id", filter.UserIDs)
+		where, args = append(where, in), append(args, inArgs...)
+	}
+	cond := " WHERE " + strings.Join(where, " AND ")
 
-	// Check if user has enough days (Paid + NonPaid)
-	var currentDays int
-	var currentNonPaid int
-	err = tx.QueryRow("SELECT vacation_days, non_paid_leave FROM tbl_users WHERE id = ?", vacation.PersonId).Scan(&currentDays, &currentNonPaid)
+	var total int
+	if err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM tbl_vacations"+cond, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	order, pageArgs := utils.OrderBy(sortColumns, filter.Page)
+	rows, err := s.db.QueryContext(ctx, "SELECT "+vacationColumns+" FROM tbl_vacations"+cond+order, append(args, pageArgs...)...)
 	if err != nil {
-		tx.Rollback()
-		return 0, err
+		return nil, 0, err
 	}
+	defer rows.Close()
 
-	needed := vacation.DaysUsed
-	// Logic: Use paid days first. If paid days < 0 (unlikely) or < needed, use non-paid.
-	// Actually, if paid days are negative, we should probably just treat them as 0 available for this calculation?
-	// But let's assume standard logic: available paid = max(0, currentDays).
-	
-	availablePaid := currentDays
-	if availablePaid < 0 {
-		availablePaid = 0
+	vacations := make([]*types.Vacation, 0)
+	for rows.Next() {
+		v, err := scanRowsIntoVacation(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		vacations = append(vacations, v)
 	}
 
-	deductPaid := 0
-	deductNonPaid := 0
+	return vacations, total, rows.Err()
+}
 
-	if availablePaid >= needed {
-		deductPaid = needed
-	} else {
-		deductPaid = availablePaid
-		deductNonPaid = needed - availablePaid
+// CreateVacation files a request against its leave type. Nothing is deducted yet,
+// but the days of all pending requests are reserved so a person cannot overbook.
+// Leave types that need no approval are approved and charged straight away.
+func (s *Store) CreateVacation(ctx context.Context, vacation *types.Vacation) (int, error) {
+	if vacation.LeaveType == "" {
+		vacation.LeaveType = types.LeavePaid
 	}
 
-	if currentNonPaid < deductNonPaid {
-		tx.Rollback()
-		return 0, fmt.Errorf("insufficient leave: need %d, have %d paid + %d non-paid", vacation.DaysUsed, currentDays, currentNonPaid)
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	// Start transaction
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		return 0, err
 	}
 
-	// Update user's vacation days and non-paid leave
-	_, err = tx.Exec("UPDATE tbl_users SET vacation_days = vacation_days - ?, non_paid_leave = non_paid_leave - ? WHERE id = ?", deductPaid, deductNonPaid, vacation.PersonId)
+	chain, err := leaveChain(tx, vacation.LeaveType)
 	if err != nil {
 		tx.Rollback()
 		return 0, err
 	}
 
+	if err := checkOverlap(tx, vacation, 0); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	// Check if user has enough days along the fallback chain left after pending reservations
+	if err := checkAvailable(tx, vacation.PersonId, chain, vacation.DaysUsed, 0); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	status := types.StatusPending
+	if !chain[0].RequiresApproval {
+		status = types.StatusApproved
+	}
+
 	// Insert vacation
-	res, err := tx.Exec("INSERT INTO tbl_vacations (label, from_date, to_date, person_id, ts, days_used) VALUES (?, ?, ?, ?, ?, ?)",
-		vacation.Label, vacation.FromDate, vacation.ToDate, vacation.PersonId, vacation.Timestamp, vacation.DaysUsed)
+	id, err := tx.Insert("INSERT INTO tbl_vacations (label, from_date, to_date, person_id, ts, days_used, status, leave_type, document, flagged, flag_reason, portion, hours) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		vacation.Label, vacation.FromDate, vacation.ToDate, vacation.PersonId, vacation.Timestamp, vacation.DaysUsed, status, vacation.LeaveType, vacation.Document, vacation.Flagged, vacation.FlagReason, vacation.Portion, vacation.Hours)
 	if err != nil {
 		tx.Rollback()
 		return 0, err
 	}
 
-	id, err := res.LastInsertId()
+	if status == types.StatusApproved {
+		if err := deductDays(tx, int(id), vacation.PersonId, chain, vacation.DaysUsed); err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+		if err := recordCharged(tx, int(id), vacation.PersonId); err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+	}
+
+	after, err := findVacation(tx, int(id))
+	if err == nil {
+		err = audit.Record(tx, audit.ActionCreate, audit.EntityVacation, id, nil, after)
+	}
 	if err != nil {
 		tx.Rollback()
 		return 0, err
@@ -125,17 +210,434 @@ func (s *Store) CreateVacation(vacation *types.Vacation) (int, error) {
 	return int(id), nil
 }
 
-func (s *Store) UpdateVacation(vacation *types.Vacation) error {
-	_, err := s.db.Exec("UPDATE tbl_vacations SET label=?, from_date=?, to_date=?, person_id=?, ts=?, days_used=? WHERE id=?",
-		vacation.Label, vacation.FromDate, vacation.ToDate, vacation.PersonId, vacation.Timestamp, vacation.DaysUsed, vacation.ID)
-	return err
+// TransitionVacation moves a request to a new status. Approval deducts the days
+// from the user's balances and cancelling an approved request gives them back.
+func (s *Store) TransitionVacation(ctx context.Context, id int, status types.VacationStatus) error {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+
+	before, err := findVacation(tx, id)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := before.Status.CheckTransition(status); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	switch {
+	case status == types.StatusApproved:
+		var chain []*types.LeaveType
+		if chain, err = leaveChain(tx, before.LeaveType); err == nil {
+			err = deductDays(tx, id, before.PersonId, chain, before.DaysUsed)
+		}
+	case before.Status == types.StatusApproved && status == types.StatusCancelled:
+		err = refundDays(tx, id, before.PersonId)
+	}
+	if err == nil {
+		err = recordCharged(tx, id, before.PersonId)
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.Exec("UPDATE tbl_vacations SET status = ? WHERE id = ?", status, id); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	after, err := findVacation(tx, id)
+	if err == nil {
+		err = audit.Record(tx, string(status), audit.EntityVacation, id, before, after)
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
 }
 
-func (s *Store) DeleteVacation(id int) error {
-	_, err := s.db.Exec("DELETE FROM tbl_vacations WHERE id=?", id)
+// checkOverlap fails with a *types.OverlapError when the vacation's range overlaps
+// another pending or approved request of the same person. Parts of the same
+// day only overlap when they clash. excludeID is the request being edited, if any.
+func checkOverlap(tx *db.Tx, vacation *types.Vacation, excludeID int) error {
+	rows, err := tx.Query("SELECT id, portion, hours FROM tbl_vacations WHERE person_id = ? AND status IN (?, ?) AND id <> ? AND from_date <= ? AND to_date >= ? ORDER BY id FOR UPDATE",
+		vacation.PersonId, types.StatusPending, types.StatusApproved, excludeID, vacation.ToDate, vacation.FromDate)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var conflicts []int
+	for rows.Next() {
+		other := new(types.Vacation)
+		if err := rows.Scan(&other.ID, &other.Portion, &other.Hours); err != nil {
+			return err
+		}
+		if clashes(vacation, other) {
+			conflicts = append(conflicts, other.ID)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if len(conflicts) > 0 {
+		return &types.OverlapError{VacationIDs: conflicts}
+	}
+	return nil
+}
+
+// clashes reports whether two requests sharing a day cannot both be taken.
+// Portions are only allowed on single-day requests, so two requests with
+// portions are on the same day. Full days clash with anything, the same half
+// of a day clashes with itself, and otherwise the hours must fit in a day.
+func clashes(a, b *types.Vacation) bool {
+	if a.Portion == "" || b.Portion == "" {
+		return true
+	}
+	if a.Portion == b.Portion && a.Portion != types.PortionHours {
+		return true
+	}
+	return portionHours(a)+portionHours(b) > types.HoursPerDay
+}
+
+// portionHours is how many hours of its day a single-day request takes.
+func portionHours(v *types.Vacation) float64 {
+	switch v.Portion {
+	case types.PortionAM, types.PortionPM:
+		return types.HoursPerDay / 2
+	case types.PortionHours:
+		return v.Hours
+	}
+	return types.HoursPerDay
+}
+
+// leaveChain loads a leave type followed by its fallbacks, in the order their
+// balances are charged.
+func leaveChain(tx *db.Tx, code string) ([]*types.LeaveType, error) {
+	var chain []*types.LeaveType
+	seen := make(map[string]bool)
+
+	for code != "" && !seen[code] {
+		seen[code] = true
+
+		t := &types.LeaveType{Code: code}
+		err := tx.QueryRow("SELECT name, COALESCE(fallback, ''), unlimited, requires_approval, requires_document FROM tbl_leave_types WHERE code = ?", code).
+			Scan(&t.Name, &t.Fallback, &t.Unlimited, &t.RequiresApproval, &t.RequiresDocument)
+		if err == sql.ErrNoRows {
+			return nil, types.Invalid("leaveType", "unknown leave type %q", code)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		chain = append(chain, t)
+		code = t.Fallback
+	}
+	return chain, nil
+}
+
+// checkAvailable fails when needed days exceed what the user has left along the
+// fallback chain minus the days reserved by their pending requests on the same
+// chain. excludeID leaves one request out of the reservations, so a pending
+// request being edited does not count itself.
+func checkAvailable(tx *db.Tx, personId int, chain []*types.LeaveType, needed float64, excludeID int) error {
+	codes := make([]any, 0, len(chain))
+	var available float64
+	for _, t := range chain {
+		if t.Unlimited {
+			return nil
+		}
+
+		balance, err := ledger.Balance(tx, personId, t.Code)
+		if err != nil {
+			return err
+		}
+		// Negative balances count as nothing available.
+		if balance > 0 {
+			available += balance
+		}
+		codes = append(codes, t.Code)
+	}
+
+	var reserved float64
+	args := append([]any{personId, types.StatusPending, excludeID}, codes...)
+	err := tx.QueryRow("SELECT COALESCE(SUM(days_used), 0) FROM tbl_vacations WHERE person_id = ? AND status = ? AND id <> ? AND leave_type IN (?"+strings.Repeat(", ?", len(codes)-1)+")", args...).Scan(&reserved)
+	if err != nil {
+		return err
+	}
+
+	if needed > types.RoundDays(available-reserved) {
+		return fmt.Errorf("%w: need %g, have %g %s with %g reserved by pending requests", types.ErrInsufficientBalance, needed, available, chainNames(chain), reserved)
+	}
+	return nil
+}
+
+// deductDays books needed days of vacation id against the user's balances,
+// working down the leave type's fallback chain.
+func deductDays(tx *db.Tx, id, personId int, chain []*types.LeaveType, needed float64) error {
+	entries := make([]*types.LedgerEntry, 0, len(chain))
+	remaining := needed
+
+	for _, t := range chain {
+		if remaining <= 0 {
+			break
+		}
+
+		take := remaining
+		if !t.Unlimited {
+			balance, err := ledger.Balance(tx, personId, t.Code)
+			if err != nil {
+				return err
+			}
+			// Negative balances count as nothing available.
+			take = min(remaining, max(balance, 0))
+		}
+
+		entries = append(entries, &types.LedgerEntry{UserID: personId, LeaveType: t.Code, Amount: -take, Reason: types.ReasonBooking, VacationID: id})
+		remaining = types.RoundDays(remaining - take)
+	}
+
+	if remaining > 0 {
+		return fmt.Errorf("%w: need %g, %g short after %s", types.ErrInsufficientBalance, needed, remaining, chainNames(chain))
+	}
+
+	return ledger.Apply(tx, entries...)
+}
+
+// refundDays gives back everything vacation id still holds of the user's balances.
+func refundDays(tx *db.Tx, id, personId int) error {
+	charged, err := ledger.Charged(tx, id, personId)
+	if err != nil {
+		return err
+	}
+
+	return refund(tx, id, personId, charged)
+}
+
+// refund gives days of vacation id back to the user, per leave type.
+func refund(tx *db.Tx, id, personId int, days map[string]float64) error {
+	entries := make([]*types.LedgerEntry, 0, len(days))
+	for _, leaveType := range sortedKeys(days) {
+		entries = append(entries, &types.LedgerEntry{UserID: personId, LeaveType: leaveType, Amount: days[leaveType], Reason: types.ReasonRefund, VacationID: id})
+	}
+	return ledger.Apply(tx, entries...)
+}
+
+// recordCharged copies what vacation id holds of the built-in paid and unpaid
+// balances onto the request.
+func recordCharged(tx *db.Tx, id, personId int) error {
+	charged, err := ledger.Charged(tx, id, personId)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE tbl_vacations SET paid_days = ?, non_paid_days = ? WHERE id = ?", charged[types.LeavePaid], charged[types.LeaveUnpaid], id)
 	return err
 }
 
+// splitRefund divides a partial refund between the balances a booking was
+// charged to, in the proportion the booking originally took them. Shares are
+// whole days when the refund and the charges are, so whole-day bookings never
+// leave fractional balances. Rounding leftovers go to the balance that was
+// charged most.
+func splitRefund(days float64, charged map[string]float64) map[string]float64 {
+	var total float64
+	round := math.Round
+	if days != math.Trunc(days) {
+		round = types.RoundDays
+	}
+	for _, c := range charged {
+		total += c
+		if c != math.Trunc(c) {
+			round = types.RoundDays
+		}
+	}
+	if total == 0 {
+		return nil
+	}
+
+	leaveTypes := sortedKeys(charged)
+	sort.SliceStable(leaveTypes, func(i, j int) bool { return charged[leaveTypes[i]] > charged[leaveTypes[j]] })
+
+	split := make(map[string]float64, len(charged))
+	rest := days
+	for _, leaveType := range leaveTypes[1:] {
+		share := min(round(days*charged[leaveType]/total), charged[leaveType], rest)
+		split[leaveType] = share
+		rest = types.RoundDays(rest - share)
+	}
+	split[leaveTypes[0]] = rest
+	return split
+}
+
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func chainNames(chain []*types.LeaveType) string {
+	names := make([]string, len(chain))
+	for i, t := range chain {
+		names[i] = t.Code
+	}
+	return strings.Join(names, " + ")
+}
+
+// UpdateVacation edits a request and keeps the balances in step with it.
+// A pending request is re-checked against the balance. For an approved one a longer
+// range deducts the extra days along the fallback chain, a shorter one refunds the
+// difference in the booking's proportions, and moving it to another person or
+// leave type refunds it in full before charging it again.
+func (s *Store) UpdateVacation(ctx context.Context, vacation *types.Vacation) error {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+
+	before, err := findVacation(tx, vacation.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if vacation.LeaveType == "" {
+		vacation.LeaveType = before.LeaveType
+	}
+	chain, err := leaveChain(tx, vacation.LeaveType)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if before.Status == types.StatusPending || before.Status == types.StatusApproved {
+		if err := checkOverlap(tx, vacation, vacation.ID); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	switch {
+	case before.Status == types.StatusPending:
+		err = checkAvailable(tx, vacation.PersonId, chain, vacation.DaysUsed, vacation.ID)
+
+	case before.Status == types.StatusApproved && (before.PersonId != vacation.PersonId || before.LeaveType != vacation.LeaveType):
+		if err = refundDays(tx, vacation.ID, before.PersonId); err == nil {
+			err = deductDays(tx, vacation.ID, vacation.PersonId, chain, vacation.DaysUsed)
+		}
+
+	case before.Status == types.StatusApproved && vacation.DaysUsed > before.DaysUsed:
+		err = deductDays(tx, vacation.ID, before.PersonId, chain, types.RoundDays(vacation.DaysUsed-before.DaysUsed))
+
+	case before.Status == types.StatusApproved && vacation.DaysUsed < before.DaysUsed:
+		var charged map[string]float64
+		if charged, err = ledger.Charged(tx, vacation.ID, before.PersonId); err == nil {
+			err = refund(tx, vacation.ID, before.PersonId, splitRefund(types.RoundDays(before.DaysUsed-vacation.DaysUsed), charged))
+		}
+	}
+	if err == nil {
+		err = recordCharged(tx, vacation.ID, vacation.PersonId)
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE tbl_vacations SET label=?, from_date=?, to_date=?, person_id=?, ts=?, days_used=?, leave_type=?, document=?, portion=?, hours=? WHERE id=?",
+		vacation.Label, vacation.FromDate, vacation.ToDate, vacation.PersonId, vacation.Timestamp, vacation.DaysUsed, vacation.LeaveType, vacation.Document, vacation.Portion, vacation.Hours, vacation.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	after, err := findVacation(tx, vacation.ID)
+	if err == nil {
+		err = audit.Record(tx, audit.ActionUpdate, audit.EntityVacation, vacation.ID, before, after)
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeleteVacation removes a request, refunding its days first if it was approved.
+func (s *Store) DeleteVacation(ctx context.Context, id int) error {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+
+	before, err := findVacation(tx, id)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if before.Status == types.StatusApproved {
+		if err := refundDays(tx, id, before.PersonId); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if _, err := tx.Exec("DELETE FROM tbl_vacations WHERE id=?", id); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := audit.Record(tx, audit.ActionDelete, audit.EntityVacation, id, before, nil); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// findVacation reads a request's row within tx, locking it until tx ends.
+func findVacation(tx *db.Tx, id int) (*types.Vacation, error) {
+	rows, err := tx.Query("SELECT "+vacationColumns+" FROM tbl_vacations WHERE id = ? FOR UPDATE", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	vacation := new(types.Vacation)
+	for rows.Next() {
+		vacation, err = scanRowsIntoVacation(rows)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if vacation.ID == 0 {
+		return nil, fmt.Errorf("vacation %w", types.ErrNotFound)
+	}
+
+	return vacation, nil
+}
+
 func scanRowsIntoVacation(rows *sql.Rows) (*types.Vacation, error) {
 	vacation := new(types.Vacation)
 
@@ -147,6 +649,15 @@ func scanRowsIntoVacation(rows *sql.Rows) (*types.Vacation, error) {
 		&vacation.PersonId,
 		&vacation.Timestamp,
 		&vacation.DaysUsed,
+		&vacation.Status,
+		&vacation.LeaveType,
+		&vacation.Document,
+		&vacation.PaidDays,
+		&vacation.NonPaidDays,
+		&vacation.Flagged,
+		&vacation.FlagReason,
+		&vacation.Portion,
+		&vacation.Hours,
 	)
 	if err != nil {
 		return nil, err
@@ -1,11 +1,21 @@
 package vacation
 
 import (
+	"context"
+
 	"github.com/georgiwritescode/vacation-tool/types"
 )
 
-func (s *Store) GetActiveVacations(date string) ([]*types.Vacation, error) {
-	rows, err := s.db.Query("SELECT * FROM tbl_vacations WHERE ? BETWEEN from_date AND to_date", date)
+func (s *Store) GetActiveVacations(ctx context.Context, date string) ([]*types.Vacation, error) {
+	return s.GetVacationsBetween(ctx, date, date)
+}
+
+// GetVacationsBetween lists the approved vacations overlapping from..to, both inclusive.
+func (s *Store) GetVacationsBetween(ctx context.Context, from, to string) ([]*types.Vacation, error) {
+	ctx, cancel := s.db.WithTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT "+vacationColumns+" FROM tbl_vacations WHERE from_date <= ? AND to_date >= ? AND status = ?", to, from, types.StatusApproved)
 	if err != nil {
 		return nil, err
 	}
@@ -13,10 +23,7 @@ func (s *Store) GetActiveVacations(date string) ([]*types.Vacation, error) {
 
 	vacations := make([]*types.Vacation, 0)
 	for rows.Next() {
-		v := new(types.Vacation)
-		// We can reuse scanRowsIntoVacation if we export it or duplicate logic
-		// But scanRowsIntoVacation is in this file (unexported).
-		v, err = scanRowsIntoVacation(rows)
+		v, err := scanRowsIntoVacation(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,66 @@
+package vacation
+
+import (
+	"maps"
+	"testing"
+
+	"github.com/georgiwritescode/vacation-tool/types"
+)
+
+func TestSplitRefund(t *testing.T) {
+	tests := []struct {
+		name    string
+		days    float64
+		charged map[string]float64
+		want    map[string]float64
+	}{
+		{"whole days stay whole", 4, map[string]float64{"paid": 5, "unpaid": 3}, map[string]float64{"paid": 2, "unpaid": 2}},
+		{"remainder to the most charged", 3, map[string]float64{"paid": 2, "unpaid": 1, "sick": 1}, map[string]float64{"paid": 1, "sick": 1, "unpaid": 1}},
+		{"single balance", 2, map[string]float64{"paid": 5}, map[string]float64{"paid": 2}},
+		{"half day refund", 0.5, map[string]float64{"paid": 1, "unpaid": 1}, map[string]float64{"paid": 0.25, "unpaid": 0.25}},
+		{"fractional charges", 1, map[string]float64{"paid": 1.5, "unpaid": 0.5}, map[string]float64{"paid": 0.75, "unpaid": 0.25}},
+		{"nothing charged", 2, map[string]float64{}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitRefund(tt.days, tt.charged); !maps.Equal(got, tt.want) {
+				t.Errorf("splitRefund(%g, %v) = %v, want %v", tt.days, tt.charged, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClashes(t *testing.T) {
+	am := &types.Vacation{Portion: types.PortionAM}
+	pm := &types.Vacation{Portion: types.PortionPM}
+	fullDay := &types.Vacation{}
+	hours := func(h float64) *types.Vacation { return &types.Vacation{Portion: types.PortionHours, Hours: h} }
+
+	tests := []struct {
+		name string
+		a, b *types.Vacation
+		want bool
+	}{
+		{"both mornings", am, am, true},
+		{"both afternoons", pm, pm, true},
+		{"morning and afternoon", am, pm, false},
+		{"full day and morning", fullDay, am, true},
+		{"hours and full day", hours(1), fullDay, true},
+		{"hours filling the day", hours(3), hours(5), false},
+		{"hours beyond the day", hours(4), hours(5), true},
+		{"afternoon and morning hours", pm, hours(4), false},
+		{"afternoon and too many hours", pm, hours(5), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := clashes(tt.a, tt.b); got != tt.want {
+				t.Errorf("clashes(%+v, %+v) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+			if got := clashes(tt.b, tt.a); got != tt.want {
+				t.Errorf("clashes(%+v, %+v) = %v, want %v", tt.b, tt.a, got, tt.want)
+			}
+		})
+	}
+}
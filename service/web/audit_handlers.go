@@ -0,0 +1,42 @@
+package web
+
+import (
+	"net/http"
+
+	"github.com/georgiwritescode/vacation-tool/service/audit"
+	"github.com/georgiwritescode/vacation-tool/types"
+	"github.com/georgiwritescode/vacation-tool/utils"
+)
+
+type AuditData struct {
+	Entries []*types.AuditEntry
+	Filter  types.AuditFilter
+	Paging  Paging
+}
+
+// HandleAudit shows the audit log to HR admins, filtered like /api/v1/audit
+func (h *Handler) HandleAudit(w http.ResponseWriter, r *http.Request) {
+	filter, err := audit.ParseFilter(r.URL.Query())
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	entries, total, err := h.auditStore.FindPage(r.Context(), filter)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	tmpl, err := h.parseTemplate(r, "audit.html")
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	tmpl.Execute(w, AuditData{
+		Entries: entries,
+		Filter:  filter,
+		Paging:  newPaging(r, filter.Page, len(entries), total, "id", "actor_id", "action", "entity", "ts"),
+	})
+}
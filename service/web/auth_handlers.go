@@ -0,0 +1,115 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/georgiwritescode/vacation-tool/configs"
+	"github.com/georgiwritescode/vacation-tool/service/auth"
+	"github.com/georgiwritescode/vacation-tool/utils"
+)
+
+// PublicRoutes are the web routes reached without a session.
+var PublicRoutes = []string{"GET /login", "POST /login", "GET /login/sso", "GET /login/sso/callback"}
+
+type LoginData struct {
+	Email string
+	Next  string
+	Error string
+	SSO   bool
+}
+
+// HandleLogin shows the login form
+func (h *Handler) HandleLogin(w http.ResponseWriter, r *http.Request) {
+	h.renderLogin(w, r, http.StatusOK, LoginData{Next: safeNext(r.URL.Query().Get("next"))})
+}
+
+// HandleLoginSubmit checks the credentials and starts a session
+func (h *Handler) HandleLoginSubmit(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	data := LoginData{Email: r.FormValue("email"), Next: safeNext(r.FormValue("next"))}
+
+	userID, err := auth.Login(r.Context(), h.authStore, data.Email, r.FormValue("password"))
+	if errors.Is(err, auth.ErrBadCredentials) {
+		data.Error = "Invalid email or password."
+		h.renderLogin(w, r, http.StatusUnauthorized, data)
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	h.startSession(w, r, userID, data.Next)
+}
+
+// startSession logs the user in with a session cookie and sends them on to next.
+func (h *Handler) startSession(w http.ResponseWriter, r *http.Request, userID int, next string) {
+	secret, hash, err := auth.NewSecret()
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := h.authStore.CreateSession(r.Context(), userID, hash, auth.SessionTTL); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     auth.SessionCookie,
+		Value:    secret,
+		Path:     "/",
+		MaxAge:   int(auth.SessionTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   configs.Envs.SecureCookies,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, next, http.StatusSeeOther)
+}
+
+// HandleLogout ends the session
+func (h *Handler) HandleLogout(w http.ResponseWriter, r *http.Request) {
+	if cookie, err := r.Cookie(auth.SessionCookie); err == nil {
+		if err := h.authStore.DeleteSession(r.Context(), auth.HashSecret(cookie.Value)); err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     auth.SessionCookie,
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   configs.Envs.SecureCookies,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, "/login", http.StatusSeeOther)
+}
+
+func (h *Handler) renderLogin(w http.ResponseWriter, r *http.Request, status int, data LoginData) {
+	data.SSO = h.sso != nil
+
+	tmpl, err := h.parseTemplate(r, "login.html")
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(status)
+	tmpl.Execute(w, data)
+}
+
+// safeNext keeps redirects after logging in on this site.
+func safeNext(next string) string {
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.HasPrefix(next, "/\\") {
+		return "/"
+	}
+	return next
+}
@@ -1,18 +1,28 @@
 package web
 
 import (
+	"context"
 	"html/template"
 	"net/http"
 	"path/filepath"
+	"slices"
+	"sort"
 
 	"time"
 
+	"github.com/georgiwritescode/vacation-tool/calendar"
+	"github.com/georgiwritescode/vacation-tool/policy"
+	"github.com/georgiwritescode/vacation-tool/service/auth"
+	"github.com/georgiwritescode/vacation-tool/service/rule"
+	"github.com/georgiwritescode/vacation-tool/service/user"
+	"github.com/georgiwritescode/vacation-tool/service/vacation"
 	"github.com/georgiwritescode/vacation-tool/types"
 	"github.com/georgiwritescode/vacation-tool/utils"
 )
 
 type DashboardData struct {
-	ActiveVacations []ActiveVacationView
+	ActiveVacations  []ActiveVacationView
+	UpcomingHolidays []HolidayView
 }
 
 type ActiveVacationView struct {
@@ -21,20 +31,74 @@ type ActiveVacationView struct {
 	Dates    string
 }
 
+type HolidayView struct {
+	Date   string
+	Name   string
+	Today  bool
+	People []string
+}
+
+type UsersData struct {
+	Users  []*types.User
+	Filter types.UserFilter
+	Paging Paging
+}
+
+type VacationsData struct {
+	Vacations []*types.Vacation
+	Filter    types.VacationFilter
+	Users     []*types.User
+	Statuses  []types.VacationStatus
+	Paging    Paging
+}
+
+// Paging describes the page of a list being shown. Sort maps each sortable
+// field to the link sorting by it.
+type Paging struct {
+	Total       int
+	First, Last int
+	Prev, Next  string
+	Sort        map[string]string
+}
+
+// upcomingHolidayDays is how far ahead the dashboard looks for public holidays.
+const upcomingHolidayDays = 14
+
 type Handler struct {
 	userStore     types.UserStore
 	vacationStore types.VacationStore
+	holidayStore  types.HolidayStore
+	teamStore     types.TeamStore
+	leaveTypes    types.LeaveTypeStore
+	authStore     types.AuthStore
+	rules         *rule.Evaluator
+	policy        *policy.Policy
+	auditStore    types.AuditStore
+	sso           *auth.SSO
 }
 
-func NewHandler(userStore types.UserStore, vacationStore types.VacationStore) *Handler {
+func NewHandler(userStore types.UserStore, vacationStore types.VacationStore, holidayStore types.HolidayStore, teamStore types.TeamStore, leaveTypes types.LeaveTypeStore, authStore types.AuthStore, rules *rule.Evaluator, policy *policy.Policy, auditStore types.AuditStore, sso *auth.SSO) *Handler {
 	return &Handler{
 		userStore:     userStore,
 		vacationStore: vacationStore,
+		holidayStore:  holidayStore,
+		teamStore:     teamStore,
+		leaveTypes:    leaveTypes,
+		authStore:     authStore,
+		rules:         rules,
+		policy:        policy,
+		auditStore:    auditStore,
+		sso:           sso,
 	}
 }
 
 func (h *Handler) RegisterRoutes(router *http.ServeMux) {
 	router.HandleFunc("GET /", h.HandleIndex)
+	router.HandleFunc("GET /login", h.HandleLogin)
+	router.HandleFunc("POST /login", h.HandleLoginSubmit)
+	router.HandleFunc("GET /login/sso", h.HandleSSOLogin)
+	router.HandleFunc("GET /login/sso/callback", h.HandleSSOCallback)
+	router.HandleFunc("POST /logout", h.HandleLogout)
 	router.HandleFunc("GET /users", h.HandleUsers)
 	router.HandleFunc("GET /users/new", h.HandleUserNew)
 	router.HandleFunc("POST /users/new", h.HandleUserCreate)
@@ -49,11 +113,21 @@ func (h *Handler) RegisterRoutes(router *http.ServeMux) {
 	router.HandleFunc("GET /vacations/{id}/edit", h.HandleVacationEdit)
 	router.HandleFunc("POST /vacations/{id}/edit", h.HandleVacationUpdate)
 	router.HandleFunc("POST /vacations/{id}/delete", h.HandleVacationDelete)
+	router.HandleFunc("POST /vacations/{id}/approve", h.handleVacationTransition(types.StatusApproved))
+	router.HandleFunc("POST /vacations/{id}/reject", h.handleVacationTransition(types.StatusRejected))
+	router.HandleFunc("POST /vacations/{id}/cancel", h.handleVacationTransition(types.StatusCancelled))
+	router.HandleFunc("GET /audit", auth.AdminOnly(h.HandleAudit))
 }
 
 func (h *Handler) HandleIndex(w http.ResponseWriter, r *http.Request) {
 	today := time.Now().Format("2006-01-02")
-	activeVacations, err := h.vacationStore.GetActiveVacations(today)
+	activeVacations, err := h.vacationStore.GetActiveVacations(r.Context(), today)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	visible, err := h.policy.VisibleUsers(r.Context(), auth.Actor(r))
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
@@ -61,7 +135,11 @@ func (h *Handler) HandleIndex(w http.ResponseWriter, r *http.Request) {
 
 	var viewData DashboardData
 	for _, v := range activeVacations {
-		user, err := h.userStore.FindById(v.PersonId)
+		if visible != nil && !slices.Contains(visible, v.PersonId) {
+			continue
+		}
+
+		user, err := h.userStore.FindById(r.Context(), v.PersonId)
 		userName := "Unknown"
 		if err == nil {
 			userName = user.FirstName + " " + user.LastName
@@ -74,7 +152,13 @@ func (h *Handler) HandleIndex(w http.ResponseWriter, r *http.Request) {
 		})
 	}
 
-	tmpl, err := parseTemplate("index.html")
+	viewData.UpcomingHolidays, err = h.upcomingHolidays(r.Context(), time.Now())
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	tmpl, err := h.parseTemplate(r, "index.html")
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
@@ -83,41 +167,209 @@ func (h *Handler) HandleIndex(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) HandleUsers(w http.ResponseWriter, r *http.Request) {
-	users, err := h.userStore.FetchAllUsers()
+	filter, err := user.ParseFilter(r.URL.Query())
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if filter.UserIDs, err = h.policy.VisibleUsers(r.Context(), auth.Actor(r)); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	users, total, err := h.userStore.FindPage(r.Context(), filter)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	tmpl, err := parseTemplate("users.html")
+	tmpl, err := h.parseTemplate(r, "users.html")
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
-	
+
 	// Pass data to template
-	tmpl.Execute(w, users)
+	tmpl.Execute(w, UsersData{
+		Users:  users,
+		Filter: filter,
+		Paging: newPaging(r, filter.Page, len(users), total, "id", "first_name", "last_name", "email", "vacation_days", "non_paid_leave"),
+	})
 }
 
 func (h *Handler) HandleVacations(w http.ResponseWriter, r *http.Request) {
-	vacations, err := h.vacationStore.FindAll()
+	filter, err := vacation.ParseFilter(r.URL.Query())
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if filter.UserIDs, err = h.policy.VisibleUsers(r.Context(), auth.Actor(r)); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	vacations, total, err := h.vacationStore.FindPage(r.Context(), filter)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	users, err := h.usersAmong(r.Context(), filter.UserIDs)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	tmpl, err := parseTemplate("vacations.html")
+	tmpl, err := h.parseTemplate(r, "vacations.html")
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	tmpl.Execute(w, vacations)
+	tmpl.Execute(w, VacationsData{
+		Vacations: vacations,
+		Filter:    filter,
+		Users:     users,
+		Statuses:  []types.VacationStatus{types.StatusPending, types.StatusApproved, types.StatusRejected, types.StatusCancelled},
+		Paging:    newPaging(r, filter.Page, len(vacations), total, "id", "label", "person_id", "from_date", "days_used", "status"),
+	})
 }
 
-func parseTemplate(name string) (*template.Template, error) {
-	// Parse base layout and the specific page template
-	return template.ParseFiles(
+// newPaging builds the paging and sort links of a list page showing count of
+// total rows. Each sortable field links to sorting by it, flipping the order
+// when the list is already sorted by that field.
+func newPaging(r *http.Request, page types.Page, count, total int, sortable ...string) Paging {
+	paging := Paging{Total: total, Sort: make(map[string]string, len(sortable))}
+	if count > 0 {
+		paging.First, paging.Last = page.Offset+1, page.Offset+count
+	}
+	if page.Offset > 0 {
+		paging.Prev = utils.PageURL(r, max(page.Offset-page.Limit, 0))
+	}
+	if page.Offset+page.Limit < total {
+		paging.Next = utils.PageURL(r, page.Offset+page.Limit)
+	}
+
+	for _, field := range sortable {
+		q := r.URL.Query()
+		q.Del("offset")
+		q.Set("sort", field)
+		q.Set("order", "asc")
+		if field == page.Sort && !page.Desc {
+			q.Set("order", "desc")
+		}
+		paging.Sort[field] = r.URL.Path + "?" + q.Encode()
+	}
+	return paging
+}
+
+// fieldErrors maps each input of a form to the first thing wrong with it,
+// for the templates to show next to the input.
+func fieldErrors(fields []types.FieldError) map[string]string {
+	errs := make(map[string]string, len(fields))
+	for _, f := range fields {
+		if _, ok := errs[f.Field]; !ok {
+			errs[f.Field] = f.Message
+		}
+	}
+	return errs
+}
+
+// upcomingHolidays lists the public holidays in the next upcomingHolidayDays days
+// together with the people whose calendar observes them.
+func (h *Handler) upcomingHolidays(ctx context.Context, now time.Time) ([]HolidayView, error) {
+	users, err := h.userStore.FetchAllUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	from := now.Format(calendar.DateLayout)
+	to := now.AddDate(0, 0, upcomingHolidayDays).Format(calendar.DateLayout)
+
+	byCalendar := make(map[string][]*types.User)
+	for _, u := range users {
+		if u.HolidayCalendar != "" {
+			byCalendar[u.HolidayCalendar] = append(byCalendar[u.HolidayCalendar], u)
+		}
+	}
+
+	views := make(map[string]*HolidayView)
+	for code, members := range byCalendar {
+		rows, err := h.holidayStore.FindBetween(ctx, calendar.Scopes(code), from, to)
+		if err != nil {
+			return nil, err
+		}
+
+		for date, holiday := range calendar.NewHolidays(rows) {
+			key := date + " " + holiday.Name
+			view, ok := views[key]
+			if !ok {
+				view = &HolidayView{Date: date, Name: holiday.Name, Today: date == from}
+				views[key] = view
+			}
+			for _, u := range members {
+				view.People = append(view.People, u.FirstName+" "+u.LastName)
+			}
+		}
+	}
+
+	upcoming := make([]HolidayView, 0, len(views))
+	for _, view := range views {
+		sort.Strings(view.People)
+		upcoming = append(upcoming, *view)
+	}
+	sort.Slice(upcoming, func(i, j int) bool {
+		if upcoming[i].Date != upcoming[j].Date {
+			return upcoming[i].Date < upcoming[j].Date
+		}
+		return upcoming[i].Name < upcoming[j].Name
+	})
+
+	return upcoming, nil
+}
+
+// usersAmong returns the users with the given ids, or everyone for nil ids,
+// as VisibleUsers reports them.
+func (h *Handler) usersAmong(ctx context.Context, ids []int) ([]*types.User, error) {
+	users, err := h.userStore.FetchAllUsers(ctx)
+	if err != nil || ids == nil {
+		return users, err
+	}
+
+	among := make([]*types.User, 0, len(ids))
+	for _, u := range users {
+		if slices.Contains(ids, u.ID) {
+			among = append(among, u)
+		}
+	}
+	return among, nil
+}
+
+// parseTemplate parses the base layout and a page template. The pages ask the
+// template functions what the logged in user may do to hide other actions,
+// and for the session's CSRF token, which every form posts back.
+func (h *Handler) parseTemplate(r *http.Request, name string) (*template.Template, error) {
+	actor := auth.Actor(r)
+	allowed := func(check func(context.Context, *types.User, *types.Vacation) error) func(*types.Vacation) bool {
+		return func(v *types.Vacation) bool { return check(r.Context(), actor, v) == nil }
+	}
+
+	funcs := template.FuncMap{
+		"csrfToken": func() string { return auth.CSRFToken(r) },
+		"viewer":    func() *types.User { return actor },
+		"isAdmin":   func() bool { return policy.IsAdmin(actor) },
+		"canEdit": allowed(func(_ context.Context, actor *types.User, v *types.Vacation) error {
+			return h.policy.EditVacation(actor, v)
+		}),
+		"canDecide": allowed(h.policy.DecideVacation),
+		"canCancel": allowed(h.policy.CancelVacation),
+		// hoursPerDay bounds the hours of an hourly request
+		"hoursPerDay": func() int { return types.HoursPerDay },
+	}
+
+	return template.New("base.html").Funcs(funcs).ParseFiles(
 		filepath.Join("templates", "base.html"),
 		filepath.Join("templates", name),
 	)
@@ -0,0 +1,121 @@
+package web
+
+import (
+	"crypto/subtle"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/georgiwritescode/vacation-tool/configs"
+	"github.com/georgiwritescode/vacation-tool/service/audit"
+	"github.com/georgiwritescode/vacation-tool/service/auth"
+	"github.com/georgiwritescode/vacation-tool/utils"
+)
+
+// ssoCookie holds the state, nonce and PKCE verifier of a login in progress
+// at the identity provider, and where to go afterwards.
+const ssoCookie = "vt_sso"
+
+// ssoTTL is how long a login at the identity provider may take.
+const ssoTTL = 10 * time.Minute
+
+// HandleSSOLogin sends the browser to the identity provider to log in
+func (h *Handler) HandleSSOLogin(w http.ResponseWriter, r *http.Request) {
+	if h.sso == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	var secrets [3]string
+	for i := range secrets {
+		secret, _, err := auth.NewSecret()
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+		secrets[i] = secret
+	}
+	state, nonce, verifier := secrets[0], secrets[1], secrets[2]
+
+	target, err := h.sso.Client.AuthCodeURL(state, nonce, verifier)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadGateway, err)
+		return
+	}
+
+	h.setSSOCookie(w, url.Values{
+		"state":    {state},
+		"nonce":    {nonce},
+		"verifier": {verifier},
+		"next":     {safeNext(r.URL.Query().Get("next"))},
+	}.Encode(), int(ssoTTL.Seconds()))
+	http.Redirect(w, r, target, http.StatusFound)
+}
+
+// HandleSSOCallback finishes a login at the identity provider and starts a session
+func (h *Handler) HandleSSOCallback(w http.ResponseWriter, r *http.Request) {
+	if h.sso == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	var pending url.Values
+	if cookie, err := r.Cookie(ssoCookie); err == nil {
+		pending, _ = url.ParseQuery(cookie.Value)
+	}
+	h.setSSOCookie(w, "", -1)
+
+	q := r.URL.Query()
+	data := LoginData{Next: safeNext(pending.Get("next"))}
+
+	if q.Get("error") != "" {
+		data.Error = "The identity provider refused the login: " + q.Get("error")
+		h.renderLogin(w, r, http.StatusUnauthorized, data)
+		return
+	}
+	if pending.Get("state") == "" || subtle.ConstantTimeCompare([]byte(pending.Get("state")), []byte(q.Get("state"))) != 1 {
+		data.Error = "The login expired or was started elsewhere. Please try again."
+		h.renderLogin(w, r, http.StatusBadRequest, data)
+		return
+	}
+
+	claims, err := h.sso.Client.Exchange(q.Get("code"), pending.Get("verifier"), pending.Get("nonce"))
+	if err != nil {
+		log.Printf("single sign-on failed: %v", err)
+		data.Error = "Single sign-on failed. Please try again."
+		h.renderLogin(w, r, http.StatusUnauthorized, data)
+		return
+	}
+
+	// The provisioning and role changes Login makes are the identity's own
+	ctx := audit.WithActor(r.Context(), 0, claims.Email)
+	userID, err := h.sso.Login(ctx, h.authStore, h.userStore, claims)
+	if errors.Is(err, auth.ErrNoAccount) {
+		data.Error = "No account matches your login. Ask HR to add you."
+		h.renderLogin(w, r, http.StatusForbidden, data)
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	h.startSession(w, r, userID, data.Next)
+}
+
+// setSSOCookie stores a pending login; a negative maxAge clears it. It is
+// scoped to the login routes and sent on the provider's top-level redirect
+// back, which SameSite Lax allows.
+func (h *Handler) setSSOCookie(w http.ResponseWriter, value string, maxAge int) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     ssoCookie,
+		Value:    value,
+		Path:     "/login/sso",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   configs.Envs.SecureCookies,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
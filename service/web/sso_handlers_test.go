@@ -0,0 +1,194 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/georgiwritescode/vacation-tool/db"
+	"github.com/georgiwritescode/vacation-tool/db/migrations"
+	"github.com/georgiwritescode/vacation-tool/oidc"
+	"github.com/georgiwritescode/vacation-tool/oidc/oidctest"
+	"github.com/georgiwritescode/vacation-tool/service/audit"
+	"github.com/georgiwritescode/vacation-tool/service/auth"
+	"github.com/georgiwritescode/vacation-tool/service/user"
+	"github.com/georgiwritescode/vacation-tool/types"
+)
+
+const callbackURL = "http://vt.test/login/sso/callback"
+
+// TestMain runs from the repository root, where the handlers find the templates.
+func TestMain(m *testing.M) {
+	if err := os.Chdir("../.."); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// newSSOHandler is a web handler on a fresh in-memory database, logging in
+// at iss.
+func newSSOHandler(t *testing.T, iss *oidctest.Issuer, provision bool) *Handler {
+	t.Helper()
+	database, err := db.MemoryStorage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Close() })
+	if _, err := migrations.New(database).Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	sso := &auth.SSO{
+		Client:      oidc.New(iss.Config(callbackURL), nil),
+		Provision:   provision,
+		GroupsClaim: "groups",
+		GroupRoles:  map[string]string{"leads": types.RoleManager},
+	}
+	return &Handler{userStore: user.NewStore(database), authStore: auth.NewStore(database, audit.Record), sso: sso}
+}
+
+// ssoLogin starts a login, lets the issuer authorize it and returns the
+// pending-login cookie with the callback query the issuer redirected back with.
+func ssoLogin(t *testing.T, h *Handler) (*http.Cookie, url.Values) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	h.HandleSSOLogin(w, httptest.NewRequest("GET", "/login/sso?next=/vacations", nil))
+	if w.Code != http.StatusFound {
+		t.Fatalf("login: status %d", w.Code)
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != ssoCookie {
+		t.Fatalf("login set cookies %v", cookies)
+	}
+
+	noRedirect := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := noRedirect.Get(w.Header().Get("Location"))
+	if err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+	resp.Body.Close()
+	back, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil || !strings.HasPrefix(back.String(), callbackURL) {
+		t.Fatalf("authorize redirected to %q", resp.Header.Get("Location"))
+	}
+	return cookies[0], back.Query()
+}
+
+// callback finishes a login with the given cookie and query.
+func callback(h *Handler, cookie *http.Cookie, query url.Values) *httptest.ResponseRecorder {
+	r := httptest.NewRequest("GET", "/login/sso/callback?"+query.Encode(), nil)
+	if cookie != nil {
+		r.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	h.HandleSSOCallback(w, r)
+	return w
+}
+
+func sessionCookie(w *httptest.ResponseRecorder) *http.Cookie {
+	for _, c := range w.Result().Cookies() {
+		if c.Name == auth.SessionCookie && c.Value != "" {
+			return c
+		}
+	}
+	return nil
+}
+
+func TestSSOCallbackProvisions(t *testing.T) {
+	iss, err := oidctest.NewIssuer("vt", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer iss.Close()
+	iss.SetClaims(map[string]any{"email": "lead@example.com", "email_verified": true, "name": "Lena Lead", "groups": []string{"leads"}})
+	h := newSSOHandler(t, iss, true)
+
+	cookie, query := ssoLogin(t, h)
+	w := callback(h, cookie, query)
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/vacations" {
+		t.Fatalf("callback: status %d to %q: %s", w.Code, w.Header().Get("Location"), w.Body)
+	}
+
+	session := sessionCookie(w)
+	if session == nil {
+		t.Fatal("callback started no session")
+	}
+	userID, err := h.authStore.FindSession(context.Background(), auth.HashSecret(session.Value))
+	if err != nil {
+		t.Fatalf("FindSession: %v", err)
+	}
+	u, err := h.userStore.FindById(context.Background(), userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Email != "lead@example.com" || u.FirstName != "Lena" || u.Role != types.RoleManager {
+		t.Errorf("provisioned %+v", u)
+	}
+
+	// The code and the pending login are spent.
+	if w := callback(h, cookie, query); w.Code == http.StatusSeeOther {
+		t.Error("a callback was replayed")
+	}
+}
+
+func TestSSOCallbackRejects(t *testing.T) {
+	iss, err := oidctest.NewIssuer("vt", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer iss.Close()
+	iss.SetClaims(map[string]any{"email": "someone@example.com", "email_verified": true})
+
+	tests := []struct {
+		name      string
+		provision bool
+		tamper    func(cookie *http.Cookie, query url.Values) *http.Cookie
+		status    int
+	}{
+		{"state mismatch", true, func(cookie *http.Cookie, query url.Values) *http.Cookie {
+			query.Set("state", "forged")
+			return cookie
+		}, http.StatusBadRequest},
+		{"no pending login", true, func(*http.Cookie, url.Values) *http.Cookie {
+			return nil
+		}, http.StatusBadRequest},
+		{"nonce mismatch", true, func(cookie *http.Cookie, query url.Values) *http.Cookie {
+			return withPending(cookie, "nonce", "forged")
+		}, http.StatusUnauthorized},
+		{"PKCE verifier mismatch", true, func(cookie *http.Cookie, query url.Values) *http.Cookie {
+			return withPending(cookie, "verifier", "forged")
+		}, http.StatusUnauthorized},
+		{"provider error", true, func(cookie *http.Cookie, query url.Values) *http.Cookie {
+			query.Set("error", "access_denied")
+			return cookie
+		}, http.StatusUnauthorized},
+		{"unknown user without provisioning", false, func(cookie *http.Cookie, query url.Values) *http.Cookie {
+			return cookie
+		}, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newSSOHandler(t, iss, tt.provision)
+			cookie, query := ssoLogin(t, h)
+			w := callback(h, tt.tamper(cookie, query), query)
+			if w.Code != tt.status {
+				t.Errorf("status %d, want %d", w.Code, tt.status)
+			}
+			if sessionCookie(w) != nil {
+				t.Error("a session was started")
+			}
+		})
+	}
+}
+
+// withPending is cookie with one value of the pending login replaced.
+func withPending(cookie *http.Cookie, key, value string) *http.Cookie {
+	pending, _ := url.ParseQuery(cookie.Value)
+	pending.Set(key, value)
+	return &http.Cookie{Name: cookie.Name, Value: pending.Encode()}
+}
@@ -1,53 +1,106 @@
 package web
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/georgiwritescode/vacation-tool/calendar"
+	"github.com/georgiwritescode/vacation-tool/service/auth"
 	"github.com/georgiwritescode/vacation-tool/types"
 	"github.com/georgiwritescode/vacation-tool/utils"
+	"github.com/georgiwritescode/vacation-tool/validation"
 )
 
 type UserFormData struct {
-	User *types.User
+	User     *types.User
+	Managers []*types.User
+	Teams    []*types.Team
+	Weekdays []WeekdayOption
+	Roles    []string
+	Error    string
+	Errors   map[string]string
+}
+
+// WeekdayOption is one work day checkbox of the user form.
+type WeekdayOption struct {
+	Code    string
+	Name    string
+	Checked bool
+}
+
+type UserDetailData struct {
+	*types.User
+	Manager    *types.User
+	Team       *types.Team
+	Reports    []*types.User
+	LeaveTypes []*types.LeaveType
 }
 
 // HandleUserNew shows the create user form
 func (h *Handler) HandleUserNew(w http.ResponseWriter, r *http.Request) {
-	tmpl, err := parseTemplate("user_form.html")
+	if err := h.policy.ManageUsers(auth.Actor(r)); err != nil {
+		utils.WriteError(w, http.StatusForbidden, err)
+		return
+	}
+
+	data, err := h.userFormData(r.Context(), &types.User{})
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
-	tmpl.Execute(w, UserFormData{User: &types.User{}})
+
+	tmpl, err := h.parseTemplate(r, "user_form.html")
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	tmpl.Execute(w, data)
 }
 
 // HandleUserCreate processes the create user form
 func (h *Handler) HandleUserCreate(w http.ResponseWriter, r *http.Request) {
+	if err := h.policy.ManageUsers(auth.Actor(r)); err != nil {
+		utils.WriteError(w, http.StatusForbidden, err)
+		return
+	}
+
 	if err := r.ParseForm(); err != nil {
 		utils.WriteError(w, http.StatusBadRequest, err)
 		return
 	}
 
-	age, _ := strconv.Atoi(r.FormValue("age"))
-	vacationDays, _ := strconv.Atoi(r.FormValue("vacation_days"))
-	nonPaidLeave, _ := strconv.Atoi(r.FormValue("non_paid_leave"))
+	form := validation.NewForm(r.Form)
 
 	user := &types.User{
-		FirstName:    r.FormValue("first_name"),
-		LastName:     r.FormValue("last_name"),
-		Age:          age,
-		Email:        r.FormValue("email"),
-		VacationDays: vacationDays,
-		NonPaidLeave: nonPaidLeave,
-		Timestamp:    time.Now().Format("2006-01-02 15:04:05"),
+		FirstName:       r.FormValue("first_name"),
+		LastName:        r.FormValue("last_name"),
+		Age:             form.Int("age"),
+		Email:           r.FormValue("email"),
+		VacationDays:    form.Float("vacation_days"),
+		NonPaidLeave:    form.Float("non_paid_leave"),
+		HolidayCalendar: r.FormValue("holiday_calendar"),
+		ManagerID:       form.Int("manager_id"),
+		TeamID:          form.Int("team_id"),
+		HireDate:        r.FormValue("hire_date"),
+		WorkDays:        strings.Join(r.Form["work_days"], ","),
+		FTE:             form.Int("fte"),
+		Role:            r.FormValue("role"),
+		Password:        r.FormValue("password"),
+		Timestamp:       time.Now().Format("2006-01-02 15:04:05"),
+	}
+
+	if err := validation.Join(form.Err(), validation.User(user)); err != nil {
+		h.renderUserFormError(w, r, user, err)
+		return
 	}
 
-	id, err := h.userStore.CreateUser(user)
+	id, err := h.userStore.CreateUser(r.Context(), user)
 	if err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, err)
+		h.renderUserFormError(w, r, user, err)
 		return
 	}
 
@@ -63,18 +116,45 @@ func (h *Handler) HandleUserDetail(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := h.userStore.FindById(id)
+	if err := h.policy.ViewUser(r.Context(), auth.Actor(r), id); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	user, err := h.userStore.FindById(r.Context(), id)
 	if err != nil {
 		utils.WriteError(w, http.StatusNotFound, err)
 		return
 	}
 
-	tmpl, err := parseTemplate("user_detail.html")
+	data := UserDetailData{User: user}
+	if user.ManagerID != 0 {
+		if data.Manager, err = h.userStore.FindById(r.Context(), user.ManagerID); err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	if user.TeamID != 0 {
+		if data.Team, err = h.teamStore.FindById(r.Context(), user.TeamID); err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	if data.Reports, err = h.userStore.FindReports(r.Context(), user.ID); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if data.LeaveTypes, err = h.leaveTypes.FindAll(r.Context()); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	tmpl, err := h.parseTemplate(r, "user_detail.html")
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
-	tmpl.Execute(w, user)
+	tmpl.Execute(w, data)
 }
 
 // HandleUserEdit shows the edit user form
@@ -86,18 +166,29 @@ func (h *Handler) HandleUserEdit(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := h.userStore.FindById(id)
+	if err := h.policy.ManageUsers(auth.Actor(r)); err != nil {
+		utils.WriteError(w, http.StatusForbidden, err)
+		return
+	}
+
+	user, err := h.userStore.FindById(r.Context(), id)
 	if err != nil {
 		utils.WriteError(w, http.StatusNotFound, err)
 		return
 	}
 
-	tmpl, err := parseTemplate("user_form.html")
+	data, err := h.userFormData(r.Context(), user)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	tmpl, err := h.parseTemplate(r, "user_form.html")
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
-	tmpl.Execute(w, UserFormData{User: user})
+	tmpl.Execute(w, data)
 }
 
 // HandleUserUpdate processes the edit user form
@@ -109,27 +200,43 @@ func (h *Handler) HandleUserUpdate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := h.policy.ManageUsers(auth.Actor(r)); err != nil {
+		utils.WriteError(w, http.StatusForbidden, err)
+		return
+	}
+
 	if err := r.ParseForm(); err != nil {
 		utils.WriteError(w, http.StatusBadRequest, err)
 		return
 	}
 
-	age, _ := strconv.Atoi(r.FormValue("age"))
-	vacationDays, _ := strconv.Atoi(r.FormValue("vacation_days"))
-	nonPaidLeave, _ := strconv.Atoi(r.FormValue("non_paid_leave"))
+	form := validation.NewForm(r.Form)
 
 	user := &types.User{
-		ID:           id,
-		FirstName:    r.FormValue("first_name"),
-		LastName:     r.FormValue("last_name"),
-		Age:          age,
-		Email:        r.FormValue("email"),
-		VacationDays: vacationDays,
-		NonPaidLeave: nonPaidLeave,
+		ID:              id,
+		FirstName:       r.FormValue("first_name"),
+		LastName:        r.FormValue("last_name"),
+		Age:             form.Int("age"),
+		Email:           r.FormValue("email"),
+		VacationDays:    form.Float("vacation_days"),
+		NonPaidLeave:    form.Float("non_paid_leave"),
+		HolidayCalendar: r.FormValue("holiday_calendar"),
+		ManagerID:       form.Int("manager_id"),
+		TeamID:          form.Int("team_id"),
+		HireDate:        r.FormValue("hire_date"),
+		WorkDays:        strings.Join(r.Form["work_days"], ","),
+		FTE:             form.Int("fte"),
+		Role:            r.FormValue("role"),
+		Password:        r.FormValue("password"),
 	}
 
-	if err := h.userStore.UpdateUser(user); err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, err)
+	if err := validation.Join(form.Err(), validation.User(user)); err != nil {
+		h.renderUserFormError(w, r, user, err)
+		return
+	}
+
+	if err := h.userStore.UpdateUser(r.Context(), user); err != nil {
+		h.renderUserFormError(w, r, user, err)
 		return
 	}
 
@@ -145,10 +252,82 @@ func (h *Handler) HandleUserDelete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.userStore.DeleteUser(id); err != nil {
+	if err := h.policy.ManageUsers(auth.Actor(r)); err != nil {
+		utils.WriteError(w, http.StatusForbidden, err)
+		return
+	}
+
+	if err := h.userStore.DeleteUser(r.Context(), id); err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	http.Redirect(w, r, "/users", http.StatusSeeOther)
 }
+
+// renderUserFormError shows the submitted form again with the message of each
+// invalid field next to its input. Errors outside the error model are
+// reported as internal errors.
+func (h *Handler) renderUserFormError(w http.ResponseWriter, r *http.Request, user *types.User, cause error) {
+	problem := utils.NewProblem(http.StatusInternalServerError, cause)
+	if problem.Status == http.StatusInternalServerError {
+		utils.WriteError(w, http.StatusInternalServerError, cause)
+		return
+	}
+
+	data, err := h.userFormData(r.Context(), user)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	data.Errors = fieldErrors(problem.Errors)
+	if len(problem.Errors) == 0 {
+		data.Error = problem.Detail
+	}
+
+	tmpl, err := h.parseTemplate(r, "user_form.html")
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(problem.Status)
+	tmpl.Execute(w, data)
+}
+
+// userFormData loads the manager and team choices for the user form
+func (h *Handler) userFormData(ctx context.Context, user *types.User) (UserFormData, error) {
+	users, err := h.userStore.FetchAllUsers(ctx)
+	if err != nil {
+		return UserFormData{}, err
+	}
+
+	managers := make([]*types.User, 0, len(users))
+	for _, u := range users {
+		if u.ID != user.ID {
+			managers = append(managers, u)
+		}
+	}
+
+	teams, err := h.teamStore.FindAll(ctx)
+	if err != nil {
+		return UserFormData{}, err
+	}
+
+	schedule, err := calendar.ParseSchedule(user.WorkDays)
+	if err != nil {
+		return UserFormData{}, err
+	}
+
+	// Monday first
+	weekdays := make([]WeekdayOption, 0, 7)
+	for i := 1; i <= 7; i++ {
+		day := time.Weekday(i % 7)
+		weekdays = append(weekdays, WeekdayOption{Code: strings.ToLower(day.String()[:3]), Name: day.String(), Checked: schedule[day]})
+	}
+
+	roles := []string{types.RoleEmployee, types.RoleManager, types.RoleHRAdmin}
+
+	return UserFormData{User: user, Managers: managers, Teams: teams, Weekdays: weekdays, Roles: roles}, nil
+}
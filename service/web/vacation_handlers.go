@@ -1,34 +1,53 @@
 package web
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
 	"time"
 
+	"github.com/georgiwritescode/vacation-tool/calendar"
+	"github.com/georgiwritescode/vacation-tool/hierarchy"
+	"github.com/georgiwritescode/vacation-tool/policy"
+	"github.com/georgiwritescode/vacation-tool/service/auth"
+	"github.com/georgiwritescode/vacation-tool/service/leavetype"
 	"github.com/georgiwritescode/vacation-tool/types"
 	"github.com/georgiwritescode/vacation-tool/utils"
+	"github.com/georgiwritescode/vacation-tool/validation"
 )
 
 type VacationFormData struct {
-	Vacation *types.Vacation
-	Users    []*types.User
+	Vacation   *types.Vacation
+	Users      []*types.User
+	LeaveTypes []*types.LeaveType
+	Error      string
+	Errors     map[string]string
+	Conflicts  []int
+}
+
+type VacationDetailData struct {
+	*types.Vacation
+	Approver *types.User
+	Error    string
 }
 
 // HandleVacationNew shows the create vacation form
 func (h *Handler) HandleVacationNew(w http.ResponseWriter, r *http.Request) {
-	users, err := h.userStore.FetchAllUsers()
+	actor := auth.Actor(r)
+	data, err := h.vacationFormData(r.Context(), actor, &types.Vacation{PersonId: actor.ID, LeaveType: types.LeavePaid})
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	tmpl, err := parseTemplate("vacation_form.html")
+	tmpl, err := h.parseTemplate(r, "vacation_form.html")
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
-	tmpl.Execute(w, VacationFormData{Vacation: &types.Vacation{}, Users: users})
+	tmpl.Execute(w, data)
 }
 
 // HandleVacationCreate processes the create vacation form
@@ -38,24 +57,52 @@ func (h *Handler) HandleVacationCreate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	personId, _ := strconv.Atoi(r.FormValue("person_id"))
-	daysUsed, _ := strconv.Atoi(r.FormValue("days_used"))
+	form := validation.NewForm(r.Form)
 
 	vacation := &types.Vacation{
 		Label:     r.FormValue("label"),
-		FromDate:  r.FormValue("from_date"),
-		ToDate:    r.FormValue("to_date"),
-		PersonId:  personId,
-		DaysUsed:  daysUsed,
+		FromDate:  r.FormValue("fromDate"),
+		ToDate:    r.FormValue("toDate"),
+		PersonId:  form.Int("personId"),
+		DaysUsed:  form.Float("daysUsed"),
+		Portion:   r.FormValue("portion"),
+		Hours:     form.Float("hours"),
+		LeaveType: r.FormValue("leaveType"),
+		Document:  r.FormValue("document"),
 		Timestamp: time.Now().Format("2006-01-02 15:04:05"),
 	}
 
-	id, err := h.vacationStore.CreateVacation(vacation)
-	if err != nil {
+	if err := validation.Join(form.Err(), validation.Vacation(r.Context(), h.userStore, vacation)); err != nil {
+		h.renderVacationFormError(w, r, vacation, err)
+		return
+	}
+
+	if err := h.policy.FileVacation(auth.Actor(r), vacation.PersonId); err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
 
+	if err := h.resolveDaysUsed(r.Context(), vacation); err != nil {
+		h.renderVacationFormError(w, r, vacation, err)
+		return
+	}
+
+	if err := leavetype.CheckVacation(r.Context(), h.leaveTypes, vacation); err != nil {
+		h.renderVacationFormError(w, r, vacation, err)
+		return
+	}
+
+	if err := h.rules.CheckCreate(r.Context(), vacation); err != nil {
+		h.renderVacationFormError(w, r, vacation, err)
+		return
+	}
+
+	id, err := h.vacationStore.CreateVacation(r.Context(), vacation)
+	if err != nil {
+		h.renderVacationFormError(w, r, vacation, err)
+		return
+	}
+
 	http.Redirect(w, r, fmt.Sprintf("/vacations/%d", id), http.StatusSeeOther)
 }
 
@@ -68,18 +115,38 @@ func (h *Handler) HandleVacationDetail(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	vacation, err := h.vacationStore.FindById(id)
+	vacation, err := h.vacationStore.FindById(r.Context(), id)
 	if err != nil {
-		utils.WriteError(w, http.StatusNotFound, err)
+		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	tmpl, err := parseTemplate("vacation_detail.html")
+	if err := h.policy.ViewUser(r.Context(), auth.Actor(r), vacation.PersonId); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	h.renderVacationDetail(w, r, http.StatusOK, VacationDetailData{Vacation: vacation})
+}
+
+// renderVacationDetail fills in the approver for pending requests and renders the detail page
+func (h *Handler) renderVacationDetail(w http.ResponseWriter, r *http.Request, status int, data VacationDetailData) {
+	if data.Status == types.StatusPending {
+		var err error
+		data.Approver, err = hierarchy.ResolveApprover(r.Context(), h.userStore, h.vacationStore, data.PersonId, time.Now().Format(calendar.DateLayout))
+		if err != nil && !errors.Is(err, hierarchy.ErrNoApprover) {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	tmpl, err := h.parseTemplate(r, "vacation_detail.html")
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
-	tmpl.Execute(w, vacation)
+	w.WriteHeader(status)
+	tmpl.Execute(w, data)
 }
 
 // HandleVacationEdit shows the edit vacation form
@@ -91,24 +158,29 @@ func (h *Handler) HandleVacationEdit(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	vacation, err := h.vacationStore.FindById(id)
+	vacation, err := h.vacationStore.FindById(r.Context(), id)
 	if err != nil {
-		utils.WriteError(w, http.StatusNotFound, err)
+		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	users, err := h.userStore.FetchAllUsers()
+	if err := h.policy.EditVacation(auth.Actor(r), vacation); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	data, err := h.vacationFormData(r.Context(), auth.Actor(r), vacation)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	tmpl, err := parseTemplate("vacation_form.html")
+	tmpl, err := h.parseTemplate(r, "vacation_form.html")
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
-	tmpl.Execute(w, VacationFormData{Vacation: vacation, Users: users})
+	tmpl.Execute(w, data)
 }
 
 // HandleVacationUpdate processes the edit vacation form
@@ -120,29 +192,63 @@ func (h *Handler) HandleVacationUpdate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	current, err := h.vacationStore.FindById(r.Context(), id)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := h.policy.EditVacation(auth.Actor(r), current); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
 	if err := r.ParseForm(); err != nil {
 		utils.WriteError(w, http.StatusBadRequest, err)
 		return
 	}
 
-	personId, _ := strconv.Atoi(r.FormValue("person_id"))
-	daysUsed, _ := strconv.Atoi(r.FormValue("days_used"))
+	form := validation.NewForm(r.Form)
 
 	vacation := &types.Vacation{
 		ID:        id,
 		Label:     r.FormValue("label"),
-		FromDate:  r.FormValue("from_date"),
-		ToDate:    r.FormValue("to_date"),
-		PersonId:  personId,
-		DaysUsed:  daysUsed,
+		FromDate:  r.FormValue("fromDate"),
+		ToDate:    r.FormValue("toDate"),
+		PersonId:  form.Int("personId"),
+		DaysUsed:  form.Float("daysUsed"),
+		Portion:   r.FormValue("portion"),
+		Hours:     form.Float("hours"),
+		LeaveType: r.FormValue("leaveType"),
+		Document:  r.FormValue("document"),
 		Timestamp: time.Now().Format("2006-01-02 15:04:05"),
 	}
 
-	if err := h.vacationStore.UpdateVacation(vacation); err != nil {
+	if err := validation.Join(form.Err(), validation.Vacation(r.Context(), h.userStore, vacation)); err != nil {
+		h.renderVacationFormError(w, r, vacation, err)
+		return
+	}
+
+	if err := h.policy.FileVacation(auth.Actor(r), vacation.PersonId); err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
 
+	if err := h.resolveDaysUsed(r.Context(), vacation); err != nil {
+		h.renderVacationFormError(w, r, vacation, err)
+		return
+	}
+
+	if err := leavetype.CheckVacation(r.Context(), h.leaveTypes, vacation); err != nil {
+		h.renderVacationFormError(w, r, vacation, err)
+		return
+	}
+
+	if err := h.vacationStore.UpdateVacation(r.Context(), vacation); err != nil {
+		h.renderVacationFormError(w, r, vacation, err)
+		return
+	}
+
 	http.Redirect(w, r, fmt.Sprintf("/vacations/%d", id), http.StatusSeeOther)
 }
 
@@ -155,10 +261,138 @@ func (h *Handler) HandleVacationDelete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.vacationStore.DeleteVacation(id); err != nil {
+	vacation, err := h.vacationStore.FindById(r.Context(), id)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := h.policy.EditVacation(auth.Actor(r), vacation); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := h.vacationStore.DeleteVacation(r.Context(), id); err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	http.Redirect(w, r, "/vacations", http.StatusSeeOther)
 }
+
+// renderVacationFormError shows the submitted form again with what was wrong:
+// invalid fields next to their inputs, anything else, such as overlapping
+// requests or broken team rules, above the form. Errors outside the error
+// model are reported as internal errors.
+func (h *Handler) renderVacationFormError(w http.ResponseWriter, r *http.Request, vacation *types.Vacation, cause error) {
+	problem := utils.NewProblem(http.StatusInternalServerError, cause)
+	if problem.Status == http.StatusInternalServerError {
+		utils.WriteError(w, http.StatusInternalServerError, cause)
+		return
+	}
+
+	data, err := h.vacationFormData(r.Context(), auth.Actor(r), vacation)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	data.Errors = fieldErrors(problem.Errors)
+	if len(problem.Errors) == 0 {
+		data.Error = problem.Detail
+	}
+	var overlap *types.OverlapError
+	if errors.As(cause, &overlap) {
+		data.Error = "These dates overlap another request by the same employee."
+		data.Conflicts = overlap.VacationIDs
+	}
+
+	tmpl, err := h.parseTemplate(r, "vacation_form.html")
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(problem.Status)
+	tmpl.Execute(w, data)
+}
+
+// handleVacationTransition processes the approve, reject and cancel buttons.
+// Approving a request that breaks flag rules needs the override checkbox ticked.
+func (h *Handler) handleVacationTransition(status types.VacationStatus) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		idStr := r.PathValue("id")
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid vacation id"))
+			return
+		}
+
+		vacation, err := h.vacationStore.FindById(r.Context(), id)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		if err := h.policy.Transition(r.Context(), auth.Actor(r), vacation, status); err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		if status == types.StatusApproved {
+			if err := r.ParseForm(); err != nil {
+				utils.WriteError(w, http.StatusBadRequest, err)
+				return
+			}
+
+			err = h.rules.CheckApproval(r.Context(), vacation, r.FormValue("override") == "on")
+			var violation *types.RuleViolationError
+			if errors.As(err, &violation) {
+				h.renderVacationDetail(w, r, http.StatusConflict, VacationDetailData{Vacation: vacation, Error: violation.Error()})
+				return
+			}
+			if err != nil {
+				utils.WriteError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		if err := h.vacationStore.TransitionVacation(r.Context(), id, status); err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		http.Redirect(w, r, fmt.Sprintf("/vacations/%d", id), http.StatusSeeOther)
+	}
+}
+
+// vacationFormData loads the employee and leave type choices for the vacation
+// form. Only HR admins choose among all employees; everyone else files for
+// themselves.
+func (h *Handler) vacationFormData(ctx context.Context, actor *types.User, vacation *types.Vacation) (VacationFormData, error) {
+	var ids []int
+	if !policy.IsAdmin(actor) {
+		ids = []int{actor.ID}
+	}
+
+	users, err := h.usersAmong(ctx, ids)
+	if err != nil {
+		return VacationFormData{}, err
+	}
+
+	leaveTypes, err := h.leaveTypes.FindAll(ctx)
+	if err != nil {
+		return VacationFormData{}, err
+	}
+
+	return VacationFormData{Vacation: vacation, Users: users, LeaveTypes: leaveTypes}, nil
+}
+
+func (h *Handler) resolveDaysUsed(ctx context.Context, vacation *types.Vacation) error {
+	schedule, holidays, err := calendar.ForUser(ctx, h.userStore, h.holidayStore, vacation.PersonId, vacation.FromDate, vacation.ToDate)
+	if err != nil {
+		return err
+	}
+
+	return calendar.ResolveDaysUsed(vacation, schedule, holidays)
+}
@@ -6,39 +6,66 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"regexp"
 	"strconv"
+	"strings"
 	"time"
 )
 
 const baseURL = "http://localhost:8080"
 
+// apiToken authenticates makeRequest; main issues it for the administrator
+// bootstrapped from ADMIN_EMAIL and ADMIN_PASSWORD.
+var apiToken string
+
 // Structures related to API
 type User struct {
-	ID           int         `json:"id"`
-	FirstName    string      `json:"first_name"`
-	LastName     string      `json:"last_name"`
-	Age          int         `json:"age"`
-	Email        string      `json:"email"`
-	VacationDays int         `json:"vacation_days"`
-	NonPaidLeave int         `json:"non_paid_leave"`
-	Timestamp    string      `json:"ts"`
-	Vacations    []*Vacation `json:"vacations,omitempty"`
+	ID              int            `json:"id"`
+	FirstName       string         `json:"first_name"`
+	LastName        string         `json:"last_name"`
+	Age             int            `json:"age"`
+	Email           string         `json:"email"`
+	HolidayCalendar string         `json:"holiday_calendar,omitempty"`
+	VacationDays    int            `json:"vacation_days"`
+	NonPaidLeave    int            `json:"non_paid_leave"`
+	ManagerID       int            `json:"manager_id,omitempty"`
+	TeamID          int            `json:"team_id,omitempty"`
+	HireDate        string         `json:"hire_date,omitempty"`
+	WorkDays        string         `json:"work_days,omitempty"`
+	FTE             int            `json:"fte,omitempty"`
+	Password        string         `json:"password,omitempty"`
+	Role            string         `json:"role,omitempty"`
+	Timestamp       string         `json:"ts"`
+	Vacations       []*Vacation    `json:"vacations,omitempty"`
+	Balances        map[string]int `json:"balances,omitempty"`
 }
 
 type Vacation struct {
-	ID        int    `json:"id"`
-	Label     string `json:"label"`
-	FromDate  string `json:"fromDate"`
-	ToDate    string `json:"toDate"`
-	PersonId  int    `json:"personId"`
-	DaysUsed  int    `json:"daysUsed"`
-	Timestamp string `json:"ts"`
+	ID        int     `json:"id"`
+	Label     string  `json:"label"`
+	FromDate  string  `json:"fromDate"`
+	ToDate    string  `json:"toDate"`
+	PersonId  int     `json:"personId"`
+	DaysUsed  int     `json:"daysUsed"`
+	Timestamp string  `json:"ts"`
+	Status    string  `json:"status"`
+	Flagged   bool    `json:"flagged"`
+	LeaveType string  `json:"leaveType,omitempty"`
+	Document  string  `json:"document,omitempty"`
+	Portion   string  `json:"portion,omitempty"`
+	Hours     float64 `json:"hours,omitempty"`
 }
 
-type ErrorResponse struct {
-	Error string `json:"error"`
+type Problem struct {
+	Type   string `json:"type"`
+	Status int    `json:"status"`
+	Detail string `json:"detail"`
+	Errors []struct {
+		Field   string `json:"field"`
+		Message string `json:"message"`
+	} `json:"errors"`
 }
 
 func main() {
@@ -47,10 +74,31 @@ func main() {
 	// Ensure server is running (implied, but we can do a health check if needed)
 	// For now, simpler to just run.
 
+	apiToken = issueToken(getEnv("ADMIN_EMAIL", "admin@example.com"), getEnv("ADMIN_PASSWORD", "change-me-now"), "read", "write")
+
 	runUserFeaturesTest()
 	runRelationTest()
 	runDaysLogicTest()
 	runNonPaidTest()
+	runApprovalTest()
+	runHierarchyTest()
+	runRefundTest()
+	runLedgerTest()
+	runOverlapTest()
+	runRulesTest()
+	runLeaveTypesTest()
+	runAccrualTest()
+	runRolloverTest()
+	runPartialDayTest()
+	runPartTimeTest()
+	runListTest()
+	runProblemTest()
+	runValidationTest()
+	runAuthTest()
+	runRolesTest()
+	runCSRFTest()
+	runAuditTest()
+	runHolidayTest()
 
 	fmt.Println("\n✅ All Tests Passed Successfully!")
 }
@@ -95,8 +143,8 @@ func runRelationTest() {
 	userID := createUser(u)
 
 	// 2. Create Vacations
-	createVacation(Vacation{Label: "V1", FromDate: "2024-01", ToDate: "2024-01", PersonId: userID, DaysUsed: 2, Timestamp: "2024-01-01"})
-	createVacation(Vacation{Label: "V2", FromDate: "2024-02", ToDate: "2024-02", PersonId: userID, DaysUsed: 3, Timestamp: "2024-01-01"})
+	createVacation(Vacation{Label: "V1", FromDate: "2024-01-01", ToDate: "2024-01-02", PersonId: userID, DaysUsed: 2, Timestamp: "2024-01-01"})
+	createVacation(Vacation{Label: "V2", FromDate: "2024-02-05", ToDate: "2024-02-07", PersonId: userID, DaysUsed: 3, Timestamp: "2024-01-01"})
 
 	// 3. Fetch User and check vacations
 	fetched := getUser(userID)
@@ -112,19 +160,28 @@ func runDaysLogicTest() {
 	userID := createUser(u)
 
 	// 2. Use 5 days
-	createVacation(Vacation{PersonId: userID, DaysUsed: 5, FromDate: "2024-01-01", ToDate: "2024-01-05", Label: "5 Days", Timestamp: "2024-01-01 00:00:00"})
+	createApprovedVacation(Vacation{PersonId: userID, DaysUsed: 5, FromDate: "2024-01-01", ToDate: "2024-01-05", Label: "5 Days", Timestamp: "2024-01-01 00:00:00"})
 	
 	// 3. Check Balance (Expect 15)
 	fetched := getUser(userID)
 	assert(fetched.VacationDays == 15, fmt.Sprintf("Balance decreased to 15 (Found %d)", fetched.VacationDays))
 
-	// 4. Attempt Overdraft (Use 20 more) -> Should Fail
-	_, status := createVacationRaw(Vacation{PersonId: userID, DaysUsed: 20, FromDate: "2024-02-01", ToDate: "2024-02-20", Label: "Fail", Timestamp: "2024-01-01 00:00:00"})
+	// 4. Attempt Overdraft (Use 21 more) -> Should Fail
+	_, status := createVacationRaw(Vacation{PersonId: userID, DaysUsed: 21, FromDate: "2024-02-01", ToDate: "2024-02-29", Label: "Fail", Timestamp: "2024-01-01 00:00:00"})
 	assert(status != 201, "Overdraft request failed")
 
 	// 5. Verify Balance Unchanged
 	fetched = getUser(userID)
 	assert(fetched.VacationDays == 15, fmt.Sprintf("Balance remained 15 (Found %d)", fetched.VacationDays))
+
+	// 6. Mon-Fri claimed as 2 days -> Rejected
+	_, status = createVacationRaw(Vacation{PersonId: userID, DaysUsed: 2, FromDate: "2024-03-04", ToDate: "2024-03-08", Label: "Mismatch", Timestamp: "2024-01-01 00:00:00"})
+	assert(status == 422, fmt.Sprintf("Mismatched daysUsed rejected (Status %d)", status))
+
+	// 7. Omitted daysUsed is derived from the dates (Mon-Sun = 5)
+	createApprovedVacation(Vacation{PersonId: userID, FromDate: "2024-03-04", ToDate: "2024-03-10", Label: "Derived", Timestamp: "2024-01-01 00:00:00"})
+	fetched = getUser(userID)
+	assert(fetched.VacationDays == 10, fmt.Sprintf("Weekend not deducted, balance 10 (Found %d)", fetched.VacationDays))
 }
 
 func runNonPaidTest() {
@@ -139,7 +196,7 @@ func runNonPaidTest() {
 	assert(init.NonPaidLeave == 10, fmt.Sprintf("Init NonPaid 10 (Found %d)", init.NonPaidLeave))
 
 	// 2. Request 8 days (Uses 5 Paid, 3 NonPaid)
-	createVacation(Vacation{PersonId: userID, DaysUsed: 8, FromDate: "2024-01-01", ToDate: "2024-01-08", Label: "Mixed", Timestamp: "2024-01-01 00:00:00"})
+	createApprovedVacation(Vacation{PersonId: userID, DaysUsed: 8, FromDate: "2024-01-01", ToDate: "2024-01-10", Label: "Mixed", Timestamp: "2024-01-01 00:00:00"})
 	
 	// 3. Check Balance (Expect 0 Paid, 7 NonPaid)
 	fetched := getUser(userID)
@@ -147,11 +204,11 @@ func runNonPaidTest() {
 	assert(fetched.NonPaidLeave == 7, fmt.Sprintf("NonPaid becomes 7 (Found %d)", fetched.NonPaidLeave))
 
 	// 4. Request 8 days again (Have 7 NonPaid) -> Fail
-	_, status := createVacationRaw(Vacation{PersonId: userID, DaysUsed: 8, FromDate: "2024-03-01", ToDate: "2024-03-08", Label: "Fail", Timestamp: "2024-01-01 00:00:00"})
+	_, status := createVacationRaw(Vacation{PersonId: userID, DaysUsed: 8, FromDate: "2024-03-01", ToDate: "2024-03-12", Label: "Fail", Timestamp: "2024-01-01 00:00:00"})
 	assert(status != 201, "Insufficient non-paid request failed")
 
 	// 5. Request 7 days -> Success
-	createVacation(Vacation{PersonId: userID, DaysUsed: 7, FromDate: "2024-04-01", ToDate: "2024-04-07", Label: "Last", Timestamp: "2024-01-01 00:00:00"})
+	createApprovedVacation(Vacation{PersonId: userID, DaysUsed: 7, FromDate: "2024-04-01", ToDate: "2024-04-09", Label: "Last", Timestamp: "2024-01-01 00:00:00"})
 	
 	// 6. Check Balance (Expect 0, 0)
 	fetched = getUser(userID)
@@ -159,6 +216,606 @@ func runNonPaidTest() {
 	assert(fetched.NonPaidLeave == 0, fmt.Sprintf("NonPaid is 0 (Found %d)", fetched.NonPaidLeave))
 }
 
+func runApprovalTest() {
+	fmt.Println("\n[5] Testing Approval Workflow")
+
+	// 1. Create User (10 Paid)
+	u := User{FirstName: "Approval", LastName: "Flow", Age: 35, Email: "approval@test.com", VacationDays: 10}
+	userID := createUser(u)
+
+	// 2. Pending request reserves but does not deduct
+	first := createVacation(Vacation{PersonId: userID, FromDate: "2024-05-06", ToDate: "2024-05-10", Label: "Pending", Timestamp: "2024-01-01 00:00:00"})
+	fetched := getUser(userID)
+	assert(fetched.VacationDays == 10, fmt.Sprintf("Pending request not deducted (Found %d)", fetched.VacationDays))
+
+	// 3. 6 more days would overbook the 5 left after the reservation
+	_, status := createVacationRaw(Vacation{PersonId: userID, FromDate: "2024-06-03", ToDate: "2024-06-10", Label: "Overbook", Timestamp: "2024-01-01 00:00:00"})
+	assert(status != 201, "Overbooking past pending reservation failed")
+
+	// 4. Approve deducts
+	transitionVacation(first, "approve")
+	fetched = getUser(userID)
+	assert(fetched.VacationDays == 5, fmt.Sprintf("Approved request deducted (Found %d)", fetched.VacationDays))
+
+	// 5. Approved cannot be rejected
+	_, status = makeRequest("POST", fmt.Sprintf("/api/v1/vacations/%d/reject", first), nil)
+	assert(status == 409, fmt.Sprintf("Rejecting approved request conflicts (Status %d)", status))
+
+	// 6. Cancel refunds
+	transitionVacation(first, "cancel")
+	fetched = getUser(userID)
+	assert(fetched.VacationDays == 10, fmt.Sprintf("Cancelled request refunded (Found %d)", fetched.VacationDays))
+
+	// 7. Rejected request leaves balance alone
+	second := createVacation(Vacation{PersonId: userID, FromDate: "2024-07-01", ToDate: "2024-07-02", Label: "Rejected", Timestamp: "2024-01-01 00:00:00"})
+	transitionVacation(second, "reject")
+	fetched = getUser(userID)
+	assert(fetched.VacationDays == 10, fmt.Sprintf("Rejected request not deducted (Found %d)", fetched.VacationDays))
+}
+
+func runHierarchyTest() {
+	fmt.Println("\n[6] Testing Approver Resolution")
+
+	// 1. Boss <- Manager <- Employee
+	bossID := createUser(User{FirstName: "Big", LastName: "Boss", Age: 50, Email: "boss@test.com"})
+	managerID := createUser(User{FirstName: "Middle", LastName: "Manager", Age: 40, Email: "manager@test.com", ManagerID: bossID})
+	employeeID := createUser(User{FirstName: "Hard", LastName: "Worker", Age: 30, Email: "worker@test.com", ManagerID: managerID})
+
+	// 2. Employee files a request, approver is the direct manager
+	today := time.Now()
+	requestID := createVacation(Vacation{PersonId: employeeID, FromDate: today.AddDate(0, 1, 0).Format("2006-01-02"), ToDate: today.AddDate(0, 1, 7).Format("2006-01-02"), Label: "Trip", Timestamp: "2024-01-01 00:00:00"})
+	assert(getApprover(requestID) == managerID, "Approver is the direct manager")
+
+	// 3. Manager is away today, approval skips to the boss
+	createApprovedVacation(Vacation{PersonId: managerID, FromDate: today.Format("2006-01-02"), ToDate: today.AddDate(0, 0, 7).Format("2006-01-02"), Label: "Away", Timestamp: "2024-01-01 00:00:00"})
+	assert(getApprover(requestID) == bossID, "Approver skips to the boss while the manager is away")
+}
+
+func runRefundTest() {
+	fmt.Println("\n[7] Testing Refunds on Update/Delete")
+
+	// 1. Create User (5 Paid, 10 NonPaid) and book 8 days (5 Paid, 3 NonPaid)
+	userID := createUser(User{FirstName: "Refund", LastName: "Logic", Age: 33, Email: "refund@test.com", VacationDays: 5, NonPaidLeave: 10})
+	v := Vacation{PersonId: userID, FromDate: "2024-08-05", ToDate: "2024-08-14", Label: "Refundable", Timestamp: "2024-01-01 00:00:00"}
+	v.ID = createApprovedVacation(v)
+	fetched := getUser(userID)
+	assert(fetched.VacationDays == 0 && fetched.NonPaidLeave == 7, fmt.Sprintf("Booked 5 paid + 3 non-paid (Found %d/%d)", fetched.VacationDays, fetched.NonPaidLeave))
+
+	// 2. Shrink to 4 days, refunding 4 in the 5:3 proportion (2 paid, 2 non-paid)
+	v.ToDate = "2024-08-08"
+	_, status := makeRequest("PUT", "/api/v1/vacations/update", v)
+	assert(status == 200, "Update Vacation status 200")
+	fetched = getUser(userID)
+	assert(fetched.VacationDays == 2 && fetched.NonPaidLeave == 9, fmt.Sprintf("Shrink refunded proportionally (Found %d/%d)", fetched.VacationDays, fetched.NonPaidLeave))
+
+	// 3. Delete refunds the rest
+	_, status = makeRequest("DELETE", fmt.Sprintf("/api/v1/vacations/delete/%d", v.ID), nil)
+	assert(status == 200, "Delete Vacation status 200")
+	fetched = getUser(userID)
+	assert(fetched.VacationDays == 5 && fetched.NonPaidLeave == 10, fmt.Sprintf("Delete restored the balance (Found %d/%d)", fetched.VacationDays, fetched.NonPaidLeave))
+}
+
+func runLedgerTest() {
+	fmt.Println("\n[8] Testing Balance Ledger")
+
+	// 1. Grant, book, adjust
+	userID := createUser(User{FirstName: "Ledger", LastName: "Trail", Age: 29, Email: "ledger@test.com", VacationDays: 20})
+	createApprovedVacation(Vacation{PersonId: userID, FromDate: "2024-09-02", ToDate: "2024-09-06", Label: "Ledgered", Timestamp: "2024-01-01 00:00:00"})
+	u := getUser(userID)
+	u.VacationDays = 18
+	_, status := makeRequest("PUT", "/api/v1/users/update", u)
+	assert(status == 200, "Update User status 200")
+
+	// 2. Ledger explains the balance
+	data, status := makeRequest("GET", fmt.Sprintf("/api/v1/users/%d/ledger", userID), nil)
+	assert(status == 200, "Get Ledger status 200")
+	var summary struct {
+		Entries []struct {
+			Reason string `json:"reason"`
+			Amount int    `json:"amount"`
+		} `json:"entries"`
+		Ledger map[string]int `json:"ledger"`
+		InSync bool           `json:"in_sync"`
+	}
+	json.Unmarshal(data, &summary)
+	assert(len(summary.Entries) == 3, fmt.Sprintf("Grant, booking and adjustment recorded (Found %d)", len(summary.Entries)))
+	assert(summary.Entries[2].Reason == "adjustment" && summary.Entries[2].Amount == 3, "Profile edit recorded as +3 adjustment")
+	assert(summary.Ledger["paid"] == 18 && summary.InSync, fmt.Sprintf("Ledger sums to the balance (Found %d)", summary.Ledger["paid"]))
+}
+
+func runOverlapTest() {
+	fmt.Println("\n[9] Testing Overlap Detection")
+
+	userID := createUser(User{FirstName: "Double", LastName: "Booker", Age: 27, Email: "overlap@test.com", VacationDays: 20})
+	first := createVacation(Vacation{PersonId: userID, FromDate: "2024-10-07", ToDate: "2024-10-11", Label: "First", Timestamp: "2024-01-01 00:00:00"})
+
+	// 1. Overlapping range is rejected with the conflicting id
+	data, status := createVacationRaw(Vacation{PersonId: userID, FromDate: "2024-10-10", ToDate: "2024-10-15", Label: "Overlap", Timestamp: "2024-01-01 00:00:00"})
+	assert(status == 409, fmt.Sprintf("Overlapping request conflicts (Status %d)", status))
+	var conflict struct {
+		Conflicts []int `json:"conflicts"`
+	}
+	json.Unmarshal(data, &conflict)
+	assert(len(conflict.Conflicts) == 1 && conflict.Conflicts[0] == first, fmt.Sprintf("Conflict lists vacation %d (Found %v)", first, conflict.Conflicts))
+
+	// 2. Once the first is cancelled the range is free again
+	transitionVacation(first, "cancel")
+	createVacation(Vacation{PersonId: userID, FromDate: "2024-10-10", ToDate: "2024-10-15", Label: "Rebooked", Timestamp: "2024-01-01 00:00:00"})
+}
+
+func runRulesTest() {
+	fmt.Println("\n[10] Testing Team Rules")
+
+	data, _ := makeRequest("POST", "/api/v1/teams/create", map[string]string{"name": "Rules Team"})
+	var team struct {
+		ID int `json:"id"`
+	}
+	json.Unmarshal(data, &team)
+
+	first := createUser(User{FirstName: "Rule", LastName: "One", Age: 30, Email: "rule1@test.com", VacationDays: 20, TeamID: team.ID})
+	second := createUser(User{FirstName: "Rule", LastName: "Two", Age: 30, Email: "rule2@test.com", VacationDays: 20, TeamID: team.ID})
+	capacity := createRule(map[string]any{"kind": "max_concurrent", "label": "One out at a time", "team_id": team.ID, "max_absent": 1, "action": "flag"})
+	blackout := createRule(map[string]any{"kind": "blackout", "label": "Release week", "fromDate": "2024-11-18", "toDate": "2024-11-22", "action": "block"})
+
+	// 1. Second person out on the same days is flagged, not rejected
+	createApprovedVacation(Vacation{PersonId: first, FromDate: "2024-11-04", ToDate: "2024-11-08", Label: "Out first", Timestamp: "2024-01-01 00:00:00"})
+	flagged := createVacation(Vacation{PersonId: second, FromDate: "2024-11-06", ToDate: "2024-11-07", Label: "Out second", Timestamp: "2024-01-01 00:00:00"})
+	data, _ = makeRequest("GET", fmt.Sprintf("/api/v1/vacations/%d", flagged), nil)
+	var v Vacation
+	json.Unmarshal(data, &v)
+	assert(v.Flagged, "Capacity breach flagged for manager")
+
+	// 2. Approval needs an override
+	_, status := makeRequest("POST", fmt.Sprintf("/api/v1/vacations/%d/approve", flagged), nil)
+	assert(status == 409, fmt.Sprintf("Approval without override conflicts (Status %d)", status))
+	_, status = makeRequest("POST", fmt.Sprintf("/api/v1/vacations/%d/approve?override=true", flagged), nil)
+	assert(status == 200, fmt.Sprintf("Approval with override succeeds (Status %d)", status))
+
+	// 3. Blackout blocks the booking outright
+	_, status = createVacationRaw(Vacation{PersonId: second, FromDate: "2024-11-20", ToDate: "2024-11-21", Label: "Release", Timestamp: "2024-01-01 00:00:00"})
+	assert(status == 409, fmt.Sprintf("Booking in blackout conflicts (Status %d)", status))
+
+	makeRequest("DELETE", fmt.Sprintf("/api/v1/rules/delete/%d", capacity), nil)
+	makeRequest("DELETE", fmt.Sprintf("/api/v1/rules/delete/%d", blackout), nil)
+}
+
+func runLeaveTypesTest() {
+	fmt.Println("\n[11] Testing Leave Types")
+
+	userID := createUser(User{FirstName: "Leave", LastName: "Types", Age: 33, Email: "leavetypes@test.com", VacationDays: 20})
+
+	// 1. Sick leave needs a document
+	_, status := createVacationRaw(Vacation{PersonId: userID, FromDate: "2024-12-02", ToDate: "2024-12-03", Label: "Flu", LeaveType: "sick", Timestamp: "2024-01-01 00:00:00"})
+	assert(status == 422, fmt.Sprintf("Sick leave without document rejected (Status %d)", status))
+
+	// 2. With one it is approved straight away and leaves paid days alone
+	sick := createVacation(Vacation{PersonId: userID, FromDate: "2024-12-02", ToDate: "2024-12-03", Label: "Flu", LeaveType: "sick", Document: "note-123", Timestamp: "2024-01-01 00:00:00"})
+	data, _ := makeRequest("GET", fmt.Sprintf("/api/v1/vacations/%d", sick), nil)
+	var v Vacation
+	json.Unmarshal(data, &v)
+	assert(v.Status == "approved", fmt.Sprintf("Sick leave needs no approval (Found %s)", v.Status))
+	fetched := getUser(userID)
+	assert(fetched.Balances["sick"] == -2 && fetched.VacationDays == 20, fmt.Sprintf("Sick days recorded separately (Found %v)", fetched.Balances))
+
+	// 3. Comp time falls back to paid days
+	makeRequest("POST", fmt.Sprintf("/api/v1/users/%d/ledger", userID), map[string]any{"leave_type": "comp", "amount": 1, "reference": "overtime"})
+	comp := createVacation(Vacation{PersonId: userID, FromDate: "2024-12-09", ToDate: "2024-12-11", Label: "Comp", LeaveType: "comp", Timestamp: "2024-01-01 00:00:00"})
+	transitionVacation(comp, "approve")
+	fetched = getUser(userID)
+	assert(fetched.Balances["comp"] == 0 && fetched.VacationDays == 18, fmt.Sprintf("Comp time used before paid days (Found %v)", fetched.Balances))
+}
+
+func runAccrualTest() {
+	fmt.Println("\n[12] Testing Accruals")
+
+	makeRequest("POST", "/api/v1/leave-types/create", map[string]any{"code": "training", "name": "Training", "requires_approval": true})
+	makeRequest("POST", "/api/v1/accruals/policies/create", map[string]any{"leave_type": "training", "frequency": "monthly", "min_tenure_years": 0, "annual_days": 12})
+	makeRequest("POST", "/api/v1/accruals/policies/create", map[string]any{"leave_type": "training", "frequency": "monthly", "min_tenure_years": 1, "annual_days": 24})
+	userID := createUser(User{FirstName: "Accrual", LastName: "Engine", Age: 31, Email: "accrual@test.com", VacationDays: 20, HireDate: "2023-07-15"})
+
+	// 1. Three months at 12 a year
+	runAccrual("2024-03-10")
+	fetched := getUser(userID)
+	assert(fetched.Balances["training"] == 3, fmt.Sprintf("Accrued 3 days by March (Found %d)", fetched.Balances["training"]))
+
+	// 2. Rerun credits nothing
+	runAccrual("2024-03-10")
+	fetched = getUser(userID)
+	assert(fetched.Balances["training"] == 3, fmt.Sprintf("Rerun is idempotent (Found %d)", fetched.Balances["training"]))
+
+	// 3. After a year of tenure the higher band applies to the whole year so far
+	runAccrual("2024-08-01")
+	fetched = getUser(userID)
+	assert(fetched.Balances["training"] == 16, fmt.Sprintf("Tenure band raises entitlement (Found %d)", fetched.Balances["training"]))
+}
+
+func runRolloverTest() {
+	fmt.Println("\n[13] Testing Year-End Carry-Over")
+
+	makeRequest("POST", "/api/v1/leave-types/create", map[string]any{"code": "flex", "name": "Flex days", "requires_approval": true})
+	makeRequest("POST", "/api/v1/rollover/policies/create", map[string]any{"leave_type": "flex", "max_days": 5, "expires_on": "03-31"})
+	userID := createUser(User{FirstName: "Roll", LastName: "Over", Age: 45, Email: "rollover@test.com", VacationDays: 20})
+	makeRequest("POST", fmt.Sprintf("/api/v1/users/%d/ledger", userID), map[string]any{"leave_type": "flex", "amount": 8, "reference": "test grant"})
+
+	// Close the current year, so the grant above counts as year-end balance
+	year := time.Now().Year()
+	find := func(data []byte) (carried, forfeited int) {
+		var lines []struct {
+			UserID    int `json:"user_id"`
+			Carried   int `json:"carried"`
+			Forfeited int `json:"forfeited"`
+		}
+		json.Unmarshal(data, &lines)
+		for _, l := range lines {
+			if l.UserID == userID {
+				return l.Carried, l.Forfeited
+			}
+		}
+		return -1, -1
+	}
+
+	// 1. Preview reports without booking
+	data, _ := makeRequest("GET", fmt.Sprintf("/api/v1/rollover/%d/preview", year), nil)
+	carried, forfeited := find(data)
+	assert(carried == 5 && forfeited == 3, fmt.Sprintf("Preview carries 5, forfeits 3 (Found %d/%d)", carried, forfeited))
+	fetched := getUser(userID)
+	assert(fetched.Balances["flex"] == 8, fmt.Sprintf("Preview leaves balance alone (Found %d)", fetched.Balances["flex"]))
+
+	// 2. Commit forfeits the excess once
+	makeRequest("POST", fmt.Sprintf("/api/v1/rollover/%d/commit", year), nil)
+	data, _ = makeRequest("POST", fmt.Sprintf("/api/v1/rollover/%d/commit", year), nil)
+	carried, _ = find(data)
+	assert(carried == -1, "Second commit skips rolled over user")
+	fetched = getUser(userID)
+	assert(fetched.Balances["flex"] == 5, fmt.Sprintf("Excess forfeited (Found %d)", fetched.Balances["flex"]))
+
+	// 3. Unused carried days expire
+	makeRequest("POST", fmt.Sprintf("/api/v1/rollover/expire/commit?as_of=%d-04-01", year+1), nil)
+	fetched = getUser(userID)
+	assert(fetched.Balances["flex"] == 0, fmt.Sprintf("Carried days expired (Found %d)", fetched.Balances["flex"]))
+}
+
+func runPartialDayTest() {
+	fmt.Println("\n[14] Testing Half-Day and Hourly Leave")
+
+	userID := createUser(User{FirstName: "Half", LastName: "Day", Age: 29, Email: "halfday@test.com", VacationDays: 20})
+	paidBalance := func() float64 {
+		data, _ := makeRequest("GET", fmt.Sprintf("/api/v1/users/%d", userID), nil)
+		var u struct {
+			VacationDays float64 `json:"vacation_days"`
+		}
+		json.Unmarshal(data, &u)
+		return u.VacationDays
+	}
+
+	// 1. A morning off costs half a day
+	createApprovedVacation(Vacation{Label: "Dentist", FromDate: "2030-03-05", ToDate: "2030-03-05", PersonId: userID, Portion: "am"})
+	assert(paidBalance() == 19.5, fmt.Sprintf("Half day deducted 0.5 (Found %g)", paidBalance()))
+
+	// 2. Two hours cost a quarter of an 8-hour day
+	createApprovedVacation(Vacation{Label: "Errand", FromDate: "2030-03-06", ToDate: "2030-03-06", PersonId: userID, Portion: "hours", Hours: 2})
+	assert(paidBalance() == 19.25, fmt.Sprintf("Two hours deducted 0.25 (Found %g)", paidBalance()))
+
+	// 3. Partial days only work for a single day
+	_, status := createVacationRaw(Vacation{Label: "Bad", FromDate: "2030-03-11", ToDate: "2030-03-12", PersonId: userID, Portion: "pm"})
+	assert(status == 422, fmt.Sprintf("Multi-day half day rejected (Status %d)", status))
+}
+
+func runPartTimeTest() {
+	fmt.Println("\n[15] Testing Part-Time Schedules")
+
+	userID := createUser(User{FirstName: "Part", LastName: "Time", Age: 38, Email: "parttime@test.com", WorkDays: "mon,tue,wed,thu", FTE: 80})
+
+	// 1. Default entitlement is prorated to the FTE
+	fetched := getUser(userID)
+	assert(fetched.VacationDays == 16, fmt.Sprintf("80%% FTE gets 16 days (Found %d)", fetched.VacationDays))
+
+	// 2. A full week only costs the four days worked
+	id := createApprovedVacation(Vacation{Label: "Week off", FromDate: "2030-04-01", ToDate: "2030-04-07", PersonId: userID})
+	fetched = getUser(userID)
+	assert(fetched.VacationDays == 12, fmt.Sprintf("Week costs 4 days (Found %d)", fetched.VacationDays))
+	transitionVacation(id, "cancel")
+
+	// 3. Days off by schedule cannot be booked alone
+	_, status := createVacationRaw(Vacation{Label: "Friday", FromDate: "2030-04-12", ToDate: "2030-04-12", PersonId: userID})
+	assert(status == 422, fmt.Sprintf("Friday off is not a working day (Status %d)", status))
+}
+
+func runListTest() {
+	fmt.Println("\n[16] Testing List Filters and Paging")
+
+	userID := createUser(User{FirstName: "Page", LastName: "Turner", Age: 33, Email: "page_turner@test.com", VacationDays: 20})
+	createVacation(Vacation{Label: "Spring", FromDate: "2031-03-03", ToDate: "2031-03-04", PersonId: userID})
+	createVacation(Vacation{Label: "Summer", FromDate: "2031-07-07", ToDate: "2031-07-08", PersonId: userID})
+	createVacation(Vacation{Label: "Autumn", FromDate: "2031-10-06", ToDate: "2031-10-07", PersonId: userID})
+	type item struct {
+		ID    int    `json:"id"`
+		Label string `json:"label"`
+	}
+	list := func(path string) []item {
+		data, _ := makeRequest("GET", path, nil)
+		var items []item
+		json.Unmarshal(data, &items)
+		return items
+	}
+
+	// 1. Search users by email
+	users := list("/api/v1/users/list?q=page_turner")
+	assert(len(users) == 1 && users[0].ID == userID, fmt.Sprintf("User found by email (Found %d)", len(users)))
+
+	// 2. Page through a person's vacations, newest first
+	page := list(fmt.Sprintf("/api/v1/vacations/list?person_id=%d&sort=from_date&order=desc&limit=2", userID))
+	assert(len(page) == 2 && page[0].Label == "Autumn", fmt.Sprintf("First page sorted by date (Found %v)", page))
+	page = list(fmt.Sprintf("/api/v1/vacations/list?person_id=%d&sort=from_date&order=desc&limit=2&offset=2", userID))
+	assert(len(page) == 1 && page[0].Label == "Spring", fmt.Sprintf("Second page holds the rest (Found %v)", page))
+
+	// 3. Date range and label filters
+	page = list(fmt.Sprintf("/api/v1/vacations/list?person_id=%d&from=2031-06-01&to=2031-08-31", userID))
+	assert(len(page) == 1 && page[0].Label == "Summer", fmt.Sprintf("Date range filter (Found %v)", page))
+	page = list(fmt.Sprintf("/api/v1/vacations/list?person_id=%d&q=umn", userID))
+	assert(len(page) == 1 && page[0].Label == "Autumn", fmt.Sprintf("Label search (Found %v)", page))
+
+	// 4. Unknown sort fields are rejected
+	_, status := makeRequest("GET", "/api/v1/vacations/list?sort=password", nil)
+	assert(status == 422, fmt.Sprintf("Unknown sort rejected (Status %d)", status))
+}
+
+func runProblemTest() {
+	fmt.Println("\n[17] Testing Problem Details")
+
+	// 1. Missing entities are 404
+	data, status := makeRequest("GET", "/api/v1/vacations/999999", nil)
+	var problem Problem
+	json.Unmarshal(data, &problem)
+	assert(status == 404 && problem.Type == "/problems/not-found", fmt.Sprintf("Missing vacation is 404 (Status %d, %s)", status, problem.Type))
+
+	// 2. Invalid input is 422 naming the field
+	userID := createUser(User{FirstName: "Prob", LastName: "Lem", Age: 27, Email: "problem@test.com", VacationDays: 20})
+	data, status = createVacationRaw(Vacation{Label: "Backwards", FromDate: "2030-05-10", ToDate: "2030-05-06", PersonId: userID})
+	problem = Problem{}
+	json.Unmarshal(data, &problem)
+	assert(status == 422 && len(problem.Errors) == 1 && problem.Errors[0].Field == "toDate", fmt.Sprintf("Backwards range names toDate (Status %d, %v)", status, problem.Errors))
+
+	// 3. Booking more than the balance is 422 too
+	data, status = createVacationRaw(Vacation{Label: "Too long", FromDate: "2030-05-06", ToDate: "2030-06-28", PersonId: userID, LeaveType: "paid"})
+	problem = Problem{}
+	json.Unmarshal(data, &problem)
+	assert(status == 422 && problem.Type == "/problems/insufficient-balance", fmt.Sprintf("Overdraft is 422 (Status %d, %s)", status, problem.Type))
+}
+
+func runValidationTest() {
+	fmt.Println("\n[18] Testing Validation")
+
+	// 1. Every invalid field of a vacation is reported at once
+	data, status := createVacationRaw(Vacation{Label: "Broken", FromDate: "2030-07-10", ToDate: "2030-07-01", PersonId: 999999, DaysUsed: -1})
+	var problem Problem
+	json.Unmarshal(data, &problem)
+	fields := map[string]bool{}
+	for _, f := range problem.Errors {
+		fields[f.Field] = true
+	}
+	assert(status == 422 && fields["toDate"] && fields["personId"] && fields["daysUsed"], fmt.Sprintf("Vacation lists toDate, personId and daysUsed (Status %d, %v)", status, problem.Errors))
+
+	// 2. So is every invalid field of a user
+	data, status = makeRequest("POST", "/api/v1/users/create", User{FirstName: "No", LastName: "Mail", Age: 5})
+	problem = Problem{}
+	json.Unmarshal(data, &problem)
+	assert(status == 422 && len(problem.Errors) == 2, fmt.Sprintf("User lists email and age (Status %d, %v)", status, problem.Errors))
+}
+
+func runAuthTest() {
+	fmt.Println("\n[19] Testing Authentication")
+
+	// 1. The API needs a token
+	_, status := makeRequestAs("", "GET", "/api/v1/users/list", nil)
+	assert(status == 401, fmt.Sprintf("Anonymous request is 401 (Status %d)", status))
+
+	// 2. A wrong password gets no token
+	createUser(User{FirstName: "Auth", LastName: "Test", Age: 30, Email: "auth@test.com", Password: "s3cret-pass"})
+	_, status = makeRequestAs("", "POST", "/api/v1/auth/tokens", map[string]any{"email": "auth@test.com", "password": "wrong", "name": "bad", "scopes": []string{"read"}})
+	assert(status == 401, fmt.Sprintf("Wrong password is 401 (Status %d)", status))
+
+	// 3. A read token reads but cannot write
+	readToken := issueToken("auth@test.com", "s3cret-pass", "read")
+	_, status = makeRequestAs(readToken, "GET", "/api/v1/users/list", nil)
+	assert(status == 200, fmt.Sprintf("Read token lists users (Status %d)", status))
+	_, status = makeRequestAs(readToken, "POST", "/api/v1/users/create", User{FirstName: "No", LastName: "Write", Age: 30, Email: "nowrite@test.com"})
+	assert(status == 403, fmt.Sprintf("Read token cannot create users (Status %d)", status))
+
+	// 4. A revoked token stops working
+	data, _ := makeRequestAs(readToken, "GET", "/api/v1/auth/tokens", nil)
+	var tokens []struct {
+		ID int `json:"id"`
+	}
+	json.Unmarshal(data, &tokens)
+	assert(len(tokens) == 1, fmt.Sprintf("User has one token (Found %d)", len(tokens)))
+	writeToken := issueToken("auth@test.com", "s3cret-pass", "write")
+	_, status = makeRequestAs(writeToken, "DELETE", fmt.Sprintf("/api/v1/auth/tokens/%d", tokens[0].ID), nil)
+	assert(status == 200, fmt.Sprintf("Token revoked (Status %d)", status))
+	_, status = makeRequestAs(readToken, "GET", "/api/v1/users/list", nil)
+	assert(status == 401, fmt.Sprintf("Revoked token is 401 (Status %d)", status))
+
+	// 5. The web UI sends visitors to the login page and accepts a session afterwards
+	client := &http.Client{Timeout: 5 * time.Second, CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(baseURL + "/vacations")
+	assert(err == nil && resp.StatusCode == 303 && strings.HasPrefix(resp.Header.Get("Location"), "/login"), "Anonymous visitor is sent to the login page")
+	resp, err = client.PostForm(baseURL+"/login", url.Values{"email": {"auth@test.com"}, "password": {"s3cret-pass"}})
+	assert(err == nil && resp.StatusCode == 303 && len(resp.Cookies()) == 1, "Login sets a session cookie")
+	req, _ := http.NewRequest("GET", baseURL+"/vacations", nil)
+	req.AddCookie(resp.Cookies()[0])
+	resp, err = client.Do(req)
+	assert(err == nil && resp.StatusCode == 200, "Session opens the vacations page")
+}
+
+func runRolesTest() {
+	fmt.Println("\n[20] Testing Roles & Permissions")
+
+	managerID := createUser(User{FirstName: "Role", LastName: "Manager", Age: 40, Email: "rolemanager@test.com", VacationDays: 20, Password: "manager-pass", Role: "manager"})
+	employeeID := createUser(User{FirstName: "Role", LastName: "Employee", Age: 30, Email: "roleemployee@test.com", VacationDays: 20, ManagerID: managerID, Password: "employee-pass"})
+	otherID := createUser(User{FirstName: "Role", LastName: "Other", Age: 30, Email: "roleother@test.com", VacationDays: 20})
+	createVacation(Vacation{Label: "Someone else's", FromDate: "2025-03-03", ToDate: "2025-03-04", PersonId: otherID})
+
+	managerToken := issueToken("rolemanager@test.com", "manager-pass", "write")
+	employeeToken := issueToken("roleemployee@test.com", "employee-pass", "write")
+
+	// 1. Employees cannot manage users or see other people
+	_, status := makeRequestAs(employeeToken, "POST", "/api/v1/users/create", User{FirstName: "No", LastName: "Admin", Age: 30, Email: "noadmin@test.com"})
+	assert(status == 403, fmt.Sprintf("Employee cannot create users (Status %d)", status))
+	_, status = makeRequestAs(employeeToken, "GET", fmt.Sprintf("/api/v1/users/%d", otherID), nil)
+	assert(status == 403, fmt.Sprintf("Employee cannot view another user (Status %d)", status))
+
+	// 2. Employees file and list only their own requests
+	_, status = makeRequestAs(employeeToken, "POST", "/api/v1/vacations/create", Vacation{Label: "Not mine", FromDate: "2025-03-10", ToDate: "2025-03-11", PersonId: otherID})
+	assert(status == 403, fmt.Sprintf("Employee cannot request leave for others (Status %d)", status))
+	data, status := makeRequestAs(employeeToken, "POST", "/api/v1/vacations/create", Vacation{Label: "Mine", FromDate: "2025-03-10", ToDate: "2025-03-11", PersonId: employeeID})
+	assert(status == 201, fmt.Sprintf("Employee requests own leave (Status %d)", status))
+	var created map[string]int
+	json.Unmarshal(data, &created)
+
+	data, _ = makeRequestAs(employeeToken, "GET", "/api/v1/vacations/list?limit=100", nil)
+	var vacations []Vacation
+	json.Unmarshal(data, &vacations)
+	own := len(vacations) > 0
+	for _, v := range vacations {
+		own = own && v.PersonId == employeeID
+	}
+	assert(own, fmt.Sprintf("Employee lists only own requests (Found %d)", len(vacations)))
+
+	// 3. Nobody decides their own request; their manager does
+	_, status = makeRequestAs(employeeToken, "POST", fmt.Sprintf("/api/v1/vacations/%d/approve", created["id"]), nil)
+	assert(status == 403, fmt.Sprintf("Employee cannot approve own request (Status %d)", status))
+	_, status = makeRequestAs(managerToken, "POST", fmt.Sprintf("/api/v1/vacations/%d/approve", created["id"]), nil)
+	assert(status == 200, fmt.Sprintf("Manager approves a report's request (Status %d)", status))
+
+	// 4. Managers only decide their reports' requests
+	managerVacation, status := makeRequestAs(managerToken, "POST", "/api/v1/vacations/create", Vacation{Label: "Manager's", FromDate: "2025-03-17", ToDate: "2025-03-18", PersonId: managerID})
+	assert(status == 201, fmt.Sprintf("Manager requests own leave (Status %d)", status))
+	json.Unmarshal(managerVacation, &created)
+	_, status = makeRequestAs(managerToken, "POST", fmt.Sprintf("/api/v1/vacations/%d/approve", created["id"]), nil)
+	assert(status == 403, fmt.Sprintf("Manager cannot approve own request (Status %d)", status))
+}
+
+func runCSRFTest() {
+	fmt.Println("\n[21] Testing CSRF Protection")
+
+	createUser(User{FirstName: "Csrf", LastName: "Test", Age: 30, Email: "csrf@test.com", Password: "csrf-pass"})
+	client := &http.Client{Timeout: 5 * time.Second, CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.PostForm(baseURL+"/login", url.Values{"email": {"csrf@test.com"}, "password": {"csrf-pass"}})
+	assert(err == nil && resp.StatusCode == 303 && len(resp.Cookies()) == 1, "Login sets a session cookie")
+	session := resp.Cookies()[0]
+
+	post := func(form url.Values) int {
+		req, _ := http.NewRequest("POST", baseURL+"/logout", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.AddCookie(session)
+		resp, err := client.Do(req)
+		if err != nil {
+			fmt.Printf("FATAL: POST /logout failed: %v\n", err)
+			os.Exit(1)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// 1. A form post without the session's token is refused
+	status := post(url.Values{})
+	assert(status == 403, fmt.Sprintf("Post without a CSRF token is 403 (Status %d)", status))
+	status = post(url.Values{"csrf_token": {"forged"}})
+	assert(status == 403, fmt.Sprintf("Post with a wrong CSRF token is 403 (Status %d)", status))
+
+	// 2. The token rendered into the page's forms is accepted
+	req, _ := http.NewRequest("GET", baseURL+"/vacations", nil)
+	req.AddCookie(session)
+	resp, err = client.Do(req)
+	assert(err == nil && resp.StatusCode == 200, "Session opens the vacations page")
+	page, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	match := regexp.MustCompile(`name="csrf_token" value="([^"]+)"`).FindSubmatch(page)
+	assert(match != nil, "Page forms carry a CSRF token")
+	status = post(url.Values{"csrf_token": {string(match[1])}})
+	assert(status == 303, fmt.Sprintf("Post with the page's CSRF token goes through (Status %d)", status))
+
+	// 3. The API stays token-authenticated and needs no CSRF token
+	_, status = makeRequest("POST", "/api/v1/users/create", User{FirstName: "Csrf", LastName: "Api", Age: 30, Email: "csrfapi@test.com"})
+	assert(status == 200, fmt.Sprintf("API post needs no CSRF token (Status %d)", status))
+}
+
+func runAuditTest() {
+	fmt.Println("\n[22] Testing Audit Log")
+
+	u := User{FirstName: "Audit", LastName: "Test", Age: 30, Email: "audit@test.com", VacationDays: 20, Password: "audit-pass"}
+	userID := createUser(u)
+	u.ID = userID
+	u.VacationDays = 25
+	u.Password = ""
+	_, status := makeRequest("PUT", "/api/v1/users/update", u)
+	assert(status == 200, "Update User status 200")
+
+	// 1. The change is logged with the user before and after
+	data, status := makeRequest("GET", fmt.Sprintf("/api/v1/audit?entity=user&entity_id=%d", userID), nil)
+	assert(status == 200, fmt.Sprintf("Audit log lists the user's changes (Status %d)", status))
+	var entries []struct {
+		Action    string          `json:"action"`
+		Before    json.RawMessage `json:"before"`
+		After     json.RawMessage `json:"after"`
+		RequestID string          `json:"request_id"`
+	}
+	json.Unmarshal(data, &entries)
+	assert(len(entries) == 2 && entries[0].Action == "update" && entries[1].Action == "create", fmt.Sprintf("Creation and update are logged, newest first (Found %d)", len(entries)))
+
+	var before, after User
+	json.Unmarshal(entries[0].Before, &before)
+	json.Unmarshal(entries[0].After, &after)
+	assert(before.VacationDays == 20 && after.VacationDays == 25, fmt.Sprintf("Snapshots show the change (%v -> %v)", before.VacationDays, after.VacationDays))
+	assert(!strings.Contains(string(entries[1].After), "audit-pass"), "Snapshots leave out the password")
+	assert(entries[0].RequestID != "", "Entry carries the request id")
+
+	// 2. Only HR admins read the log
+	employeeToken := issueToken("audit@test.com", "audit-pass", "read")
+	_, status = makeRequestAs(employeeToken, "GET", "/api/v1/audit", nil)
+	assert(status == 403, fmt.Sprintf("Employee cannot read the audit log (Status %d)", status))
+}
+
+func runHolidayTest() {
+	fmt.Println("\n[23] Testing Holiday Calendars")
+
+	// 1. Import a two-day country holiday from iCalendar (DTEND is exclusive)
+	ics := "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nSUMMARY:Spring Days\r\nDTSTART;VALUE=DATE:20320302\r\nDTEND;VALUE=DATE:20320304\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+	parsed, imported, status := importHolidays("XH", "text/calendar", ics)
+	assert(status == 200 && parsed == 2 && imported == 2, fmt.Sprintf("ICS import parsed 2, imported 2 (Found %d/%d)", parsed, imported))
+
+	// 2. Re-importing changes nothing
+	_, imported, _ = importHolidays("XH", "text/calendar", ics)
+	assert(imported == 0, fmt.Sprintf("Re-import is idempotent (Found %d)", imported))
+
+	// 3. Region holidays from CSV, and a region override cancelling a country holiday
+	parsed, _, status = importHolidays("XH-R", "text/csv", "date,name\n2032-03-05,Region Day\n")
+	assert(status == 200 && parsed == 1, fmt.Sprintf("CSV import parsed 1 (Found %d)", parsed))
+	_, status = makeRequest("POST", "/api/v1/holidays/create", map[string]any{"calendar": "XH-R", "date": "2032-03-03", "name": "Worked", "observed": false})
+	assert(status == 201, fmt.Sprintf("Region override created (Status %d)", status))
+
+	// 4. Malformed files are rejected
+	_, _, status = importHolidays("XH", "text/calendar", "BEGIN:VEVENT\r\nSUMMARY:Broken\r\nDTSTART:soon\r\nEND:VEVENT\r\n")
+	assert(status == 400, fmt.Sprintf("Malformed ICS rejected (Status %d)", status))
+	_, _, status = importHolidays("XH", "text/csv", "2032-13-01,Thirteenth\n")
+	assert(status == 400, fmt.Sprintf("Malformed CSV rejected (Status %d)", status))
+
+	// 5. The country's holidays are not deducted
+	countryID := createUser(User{FirstName: "Country", LastName: "Holidays", Age: 31, Email: "country_holidays@test.com", VacationDays: 20, HolidayCalendar: "XH"})
+	createApprovedVacation(Vacation{Label: "Spring week", FromDate: "2032-03-01", ToDate: "2032-03-05", PersonId: countryID})
+	fetched := getUser(countryID)
+	assert(fetched.VacationDays == 17, fmt.Sprintf("Week skips 2 holidays (Found %d)", fetched.VacationDays))
+	_, status = createVacationRaw(Vacation{Label: "Holiday only", FromDate: "2032-03-03", ToDate: "2032-03-03", PersonId: countryID})
+	assert(status == 422, fmt.Sprintf("Holiday alone has no working days (Status %d)", status))
+
+	// 6. The region observes its own holidays and its override beats the country
+	regionID := createUser(User{FirstName: "Region", LastName: "Holidays", Age: 31, Email: "region_holidays@test.com", VacationDays: 20, HolidayCalendar: "XH-R"})
+	createApprovedVacation(Vacation{Label: "Override", FromDate: "2032-03-03", ToDate: "2032-03-03", PersonId: regionID})
+	fetched = getUser(regionID)
+	assert(fetched.VacationDays == 19, fmt.Sprintf("Cancelled holiday is a working day (Found %d)", fetched.VacationDays))
+	_, status = createVacationRaw(Vacation{Label: "Region day", FromDate: "2032-03-05", ToDate: "2032-03-05", PersonId: regionID})
+	assert(status == 422, fmt.Sprintf("Region holiday has no working days (Status %d)", status))
+}
 
 // --- Helper Functions ---
 
@@ -171,6 +828,10 @@ func assert(condition bool, msg string) {
 }
 
 func makeRequest(method, urlPath string, body interface{}) ([]byte, int) {
+	return makeRequestAs(apiToken, method, urlPath, body)
+}
+
+func makeRequestAs(token, method, urlPath string, body interface{}) ([]byte, int) {
 	var bodyReader io.Reader
 	if body != nil {
 		jsonBody, _ := json.Marshal(body)
@@ -179,6 +840,9 @@ func makeRequest(method, urlPath string, body interface{}) ([]byte, int) {
 
 	req, _ := http.NewRequest(method, baseURL+urlPath, bodyReader)
 	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
 	
 	// Basic retry for connection refused if server is just starting
 	client := &http.Client{Timeout: 5 * time.Second}
@@ -193,6 +857,28 @@ func makeRequest(method, urlPath string, body interface{}) ([]byte, int) {
 	return data, resp.StatusCode
 }
 
+// issueToken logs in for an API token with the given scopes.
+func issueToken(email, password string, scopes ...string) string {
+	data, status := makeRequestAs("", "POST", "/api/v1/auth/tokens", map[string]any{"email": email, "password": password, "name": "e2e", "scopes": scopes})
+	if status != 201 {
+		fmt.Printf("Issuing a token for %s failed: %s\n", email, string(data))
+		os.Exit(1)
+	}
+
+	var token struct {
+		Token string `json:"token"`
+	}
+	json.Unmarshal(data, &token)
+	return token.Token
+}
+
+func getEnv(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+	return fallback
+}
+
 func createUser(u User) int {
 	data, status := makeRequest("POST", "/api/v1/users/create", u)
 	if status != 200 {
@@ -225,14 +911,85 @@ func getUser(id int) *User {
 	return &u
 }
 
-func createVacation(v Vacation) {
+func createVacation(v Vacation) int {
 	data, status := createVacationRaw(v)
 	if status != 201 {
 		fmt.Printf("Create Vacation failed (Status %d): %s\n", status, string(data))
 		os.Exit(1)
 	}
+	var res map[string]int
+	json.Unmarshal(data, &res)
+	return res["id"]
+}
+
+func createApprovedVacation(v Vacation) int {
+	id := createVacation(v)
+	transitionVacation(id, "approve")
+	return id
+}
+
+func transitionVacation(id int, action string) {
+	data, status := makeRequest("POST", fmt.Sprintf("/api/v1/vacations/%d/%s", id, action), nil)
+	if status != 200 {
+		fmt.Printf("Vacation %s failed (Status %d): %s\n", action, status, string(data))
+		os.Exit(1)
+	}
+}
+
+func createRule(rule map[string]any) int {
+	data, status := makeRequest("POST", "/api/v1/rules/create", rule)
+	if status != 201 {
+		fmt.Printf("Create Rule failed: %s\n", string(data))
+		os.Exit(1)
+	}
+	var res struct {
+		ID int `json:"id"`
+	}
+	json.Unmarshal(data, &res)
+	return res.ID
+}
+
+func runAccrual(asOf string) {
+	data, status := makeRequest("POST", "/api/v1/accruals/run?as_of="+asOf, nil)
+	if status != 200 {
+		fmt.Printf("Accrual run failed: %s\n", string(data))
+		os.Exit(1)
+	}
+}
+
+// importHolidays posts a holiday file to the import endpoint and returns the parsed and imported counts.
+func importHolidays(calendarCode, contentType, body string) (int, int, int) {
+	req, _ := http.NewRequest("POST", baseURL+"/api/v1/holidays/import?calendar="+url.QueryEscape(calendarCode), strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Authorization", "Bearer "+apiToken)
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		fmt.Printf("FATAL: Holiday import failed: %v\n", err)
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+
+	var res struct {
+		Parsed   int `json:"parsed"`
+		Imported int `json:"imported"`
+	}
+	json.NewDecoder(resp.Body).Decode(&res)
+	return res.Parsed, res.Imported, resp.StatusCode
 }
 
 func createVacationRaw(v Vacation) ([]byte, int) {
 	return makeRequest("POST", "/api/v1/vacations/create", v)
 }
+
+func getApprover(vacationID int) int {
+	data, status := makeRequest("GET", fmt.Sprintf("/api/v1/vacations/%d/approver", vacationID), nil)
+	if status != 200 {
+		fmt.Printf("Get Approver failed (Status %d): %s\n", status, string(data))
+		os.Exit(1)
+	}
+	var u User
+	json.Unmarshal(data, &u)
+	return u.ID
+}
@@ -0,0 +1,45 @@
+package types
+
+import "context"
+
+// Accrual frequencies.
+const (
+	// AccrueMonthly credits a twelfth of the annual entitlement at the start of every month.
+	AccrueMonthly = "monthly"
+	// AccrueAnnual credits the whole entitlement on January 1.
+	AccrueAnnual = "annual"
+)
+
+// AccrualPolicy is one tenure band of a leave type's entitlement: people with
+// at least MinTenureYears of service earn AnnualDays a year. The band with the
+// highest MinTenureYears a person qualifies for applies.
+type AccrualPolicy struct {
+	ID             int     `json:"id"`
+	LeaveType      string  `json:"leave_type"`
+	Frequency      string  `json:"frequency"`
+	MinTenureYears int     `json:"min_tenure_years"`
+	AnnualDays     float64 `json:"annual_days"`
+	Timestamp      string  `json:"ts"`
+}
+
+// AccrualCredit reports what an accrual run credited one user for one leave
+// type. Due is the entitlement earned so far in the year, Credited what
+// earlier runs already booked.
+type AccrualCredit struct {
+	UserID    int     `json:"user_id"`
+	LeaveType string  `json:"leave_type"`
+	Year      int     `json:"year"`
+	Due       float64 `json:"due"`
+	Credited  float64 `json:"credited"`
+	Amount    float64 `json:"amount"`
+}
+
+type AccrualStore interface {
+	FindAll(ctx context.Context) ([]*AccrualPolicy, error)
+	CreatePolicy(ctx context.Context, policy *AccrualPolicy) (int, error)
+	UpdatePolicy(ctx context.Context, policy *AccrualPolicy) error
+	DeletePolicy(ctx context.Context, id int) error
+	// Accrue credits every user what they have earned up to asOf (YYYY-MM-DD)
+	// and not been credited yet. Running it again for the same date credits nothing.
+	Accrue(ctx context.Context, asOf string) ([]*AccrualCredit, error)
+}
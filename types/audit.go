@@ -0,0 +1,39 @@
+package types
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// AuditEntry records one change: who made it, to what, and the entity before
+// and after. Before is empty for creations and After for deletions.
+type AuditEntry struct {
+	ID int `json:"id"`
+	// ActorID is 0 for changes made outside a request, such as at startup.
+	ActorID    int             `json:"actor_id"`
+	ActorEmail string          `json:"actor_email"`
+	Action     string          `json:"action"`
+	Entity     string          `json:"entity"`
+	EntityID   string          `json:"entity_id"`
+	Before     json.RawMessage `json:"before,omitempty"`
+	After      json.RawMessage `json:"after,omitempty"`
+	RequestID  string          `json:"request_id"`
+	IP         string          `json:"ip"`
+	Timestamp  string          `json:"ts"`
+}
+
+// AuditFilter narrows an audit log listing. Zero values match everything.
+type AuditFilter struct {
+	ActorID  int
+	Action   string
+	Entity   string
+	EntityID string
+	// FromDate and ToDate keep the entries recorded on those days or between.
+	FromDate string
+	ToDate   string
+	Page
+}
+
+type AuditStore interface {
+	FindPage(ctx context.Context, filter AuditFilter) ([]*AuditEntry, int, error)
+}
@@ -0,0 +1,73 @@
+package types
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+// Roles of users. Employees manage their own requests, managers also see and
+// decide their reports' requests, and HR admins manage users, balances and
+// settings.
+const (
+	RoleEmployee = "employee"
+	RoleManager  = "manager"
+	RoleHRAdmin  = "hr_admin"
+)
+
+// Scopes an API token can be granted. Read covers GET and HEAD requests,
+// write every other method; a write token may read as well.
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+)
+
+// APIToken is a personal token for /api/v1. Only its hash is stored; Token
+// carries the secret once, in the response creating it.
+type APIToken struct {
+	ID         int      `json:"id"`
+	UserID     int      `json:"user_id"`
+	Name       string   `json:"name"`
+	Scopes     []string `json:"scopes"`
+	Token      string   `json:"token,omitempty"`
+	ExpiresAt  string   `json:"expires_at,omitempty"`
+	LastUsedAt string   `json:"last_used_at,omitempty"`
+	RevokedAt  string   `json:"revoked_at,omitempty"`
+	Timestamp  string   `json:"ts"`
+}
+
+// Allows reports whether the token was granted scope.
+func (t *APIToken) Allows(scope string) bool {
+	return slices.Contains(t.Scopes, scope) || (scope == ScopeRead && slices.Contains(t.Scopes, ScopeWrite))
+}
+
+// AuthStore keeps the credentials: password hashes, web sessions and API
+// tokens. Sessions and tokens are looked up by the hash of their secret.
+type AuthStore interface {
+	FindPasswordHash(ctx context.Context, email string) (userID int, hash string, err error)
+	SetPasswordHash(ctx context.Context, userID int, hash string) error
+	SetRole(ctx context.Context, userID int, role string) error
+	CreateSession(ctx context.Context, userID int, tokenHash string, ttl time.Duration) error
+	FindSession(ctx context.Context, tokenHash string) (userID int, err error)
+	DeleteSession(ctx context.Context, tokenHash string) error
+	CreateToken(ctx context.Context, token *APIToken, tokenHash string) (int, error)
+	FindToken(ctx context.Context, tokenHash string) (*APIToken, error)
+	FindTokensByUser(ctx context.Context, userID int) ([]*APIToken, error)
+	RevokeToken(ctx context.Context, userID, id int) error
+}
+
+// TokenRequest asks for a new API token. It is authenticated by the email
+// and password rather than by a token.
+type TokenRequest struct {
+	Email     string   `json:"email"`
+	Password  string   `json:"password"`
+	Name      string   `json:"name"`
+	Scopes    []string `json:"scopes"`
+	ExpiresAt string   `json:"expires_at,omitempty"`
+}
+
+// PasswordChange sets a new password for the authenticated user.
+type PasswordChange struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
@@ -0,0 +1,52 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Error classes returned by the stores and checks. Test for them with
+// errors.Is; utils.WriteError maps them to HTTP statuses.
+var (
+	// ErrNotFound means the entity asked for does not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrConflict means the change clashes with the current state, e.g. an
+	// overlapping booking. OverlapError and RuleViolationError match it;
+	// ErrInvalidTransition is reported the same way.
+	ErrConflict = errors.New("conflict")
+	// ErrValidation means the input is invalid. ValidationError matches it.
+	ErrValidation = errors.New("validation failed")
+	// ErrInsufficientBalance means the user does not have the days a booking needs.
+	ErrInsufficientBalance = errors.New("insufficient leave")
+	// ErrForbidden means the authenticated user's role does not allow the action.
+	ErrForbidden = errors.New("forbidden")
+)
+
+// FieldError is the problem with one input field.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists what is wrong with the input, field by field.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// Invalid is a ValidationError for a single field.
+func Invalid(field, format string, args ...any) error {
+	return &ValidationError{Fields: []FieldError{{Field: field, Message: fmt.Sprintf(format, args...)}}}
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		messages[i] = f.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
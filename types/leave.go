@@ -0,0 +1,46 @@
+package types
+
+import (
+	"context"
+	"math"
+)
+
+// Day portions of a single-day request.
+const (
+	PortionAM    = "am"
+	PortionPM    = "pm"
+	PortionHours = "hours"
+)
+
+// HoursPerDay converts hourly leave into days.
+const HoursPerDay = 8
+
+// RoundDays rounds a number of days to the precision balances are stored
+// with, three decimals, so an hour (1/8 day) is exact.
+func RoundDays(days float64) float64 {
+	return math.Round(days*1000) / 1000
+}
+
+// LeaveType configures one kind of leave and the balance it draws from.
+// Code doubles as the leave type of the ledger entries moving that balance.
+type LeaveType struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+	// Fallback is the leave type charged once this balance runs out; empty
+	// means the booking fails instead.
+	Fallback string `json:"fallback,omitempty"`
+	// Unlimited types are not capped by their balance; the days taken are
+	// still recorded, so the balance goes negative.
+	Unlimited        bool   `json:"unlimited"`
+	RequiresApproval bool   `json:"requires_approval"`
+	RequiresDocument bool   `json:"requires_document"`
+	Timestamp        string `json:"ts"`
+}
+
+type LeaveTypeStore interface {
+	FindByCode(ctx context.Context, code string) (*LeaveType, error)
+	FindAll(ctx context.Context) ([]*LeaveType, error)
+	CreateLeaveType(ctx context.Context, leaveType *LeaveType) error
+	UpdateLeaveType(ctx context.Context, leaveType *LeaveType) error
+	DeleteLeaveType(ctx context.Context, code string) error
+}
@@ -0,0 +1,50 @@
+package types
+
+import "context"
+
+// Built-in leave types. Their balances are cached on tbl_users as
+// vacation_days and non_paid_leave; further types are configured in tbl_leave_types.
+const (
+	LeavePaid   = "paid"
+	LeaveUnpaid = "unpaid"
+)
+
+// Reasons recorded on ledger entries.
+const (
+	ReasonGrant      = "grant"
+	ReasonBooking    = "booking"
+	ReasonRefund     = "refund"
+	ReasonAccrual    = "accrual"
+	ReasonAdjustment = "adjustment"
+	ReasonForfeit    = "forfeit"
+	ReasonExpiry     = "expiry"
+)
+
+// LedgerEntry is one signed movement of a user's leave balance. Entries are
+// never updated or deleted; the balance is the sum of a user's entries.
+type LedgerEntry struct {
+	ID         int     `json:"id"`
+	UserID     int     `json:"user_id"`
+	LeaveType  string  `json:"leave_type"`
+	Amount     float64 `json:"amount"`
+	Reason     string  `json:"reason"`
+	VacationID int     `json:"vacation_id,omitempty"`
+	Reference  string  `json:"reference,omitempty"`
+	Timestamp  string  `json:"ts"`
+}
+
+// LedgerSummary sets the balances derived from the ledger against the
+// balances stored on the user.
+type LedgerSummary struct {
+	UserID   int                `json:"user_id"`
+	Entries  []*LedgerEntry     `json:"entries"`
+	Ledger   map[string]float64 `json:"ledger"`
+	Balances map[string]float64 `json:"balances"`
+	InSync   bool               `json:"in_sync"`
+}
+
+type LedgerStore interface {
+	FindByUser(ctx context.Context, userID int) ([]*LedgerEntry, error)
+	Summarize(ctx context.Context, userID int) (*LedgerSummary, error)
+	Adjust(ctx context.Context, entry *LedgerEntry) error
+}
@@ -0,0 +1,38 @@
+package types
+
+// Page sizes of list endpoints.
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 500
+)
+
+// Page selects one page of a sorted list.
+type Page struct {
+	Limit  int
+	Offset int
+	// Sort names the field to sort by; empty sorts by id.
+	Sort string
+	Desc bool
+}
+
+// VacationFilter narrows a vacation listing. Zero values match everything.
+type VacationFilter struct {
+	PersonID int
+	// FromDate and ToDate keep the requests overlapping that range.
+	FromDate string
+	ToDate   string
+	// Label keeps the requests whose label contains it.
+	Label  string
+	Status VacationStatus
+	// UserIDs restricts the list to the requests of these users; nil does not.
+	UserIDs []int
+	Page
+}
+
+// UserFilter narrows a user listing. Search matches the name or the email.
+type UserFilter struct {
+	Search string
+	// UserIDs restricts the list to these users; nil does not.
+	UserIDs []int
+	Page
+}
@@ -0,0 +1,46 @@
+package types
+
+import "context"
+
+// CarryoverPolicy caps how many days of a leave type carry over into the next
+// leave year; the rest is forfeited at year end. Carried days still unused on
+// ExpiresOn ("MM-DD" in the new year, empty for never) expire as well. An empty
+// Country is the default for users whose country has no policy of its own.
+type CarryoverPolicy struct {
+	ID        int     `json:"id"`
+	LeaveType string  `json:"leave_type"`
+	Country   string  `json:"country,omitempty"`
+	MaxDays   float64 `json:"max_days"`
+	ExpiresOn string  `json:"expires_on,omitempty"`
+	Timestamp string  `json:"ts"`
+}
+
+// Carryover is one user's year-end rollover of one leave type. Leave years
+// are calendar years; Year is the year being closed.
+type Carryover struct {
+	ID        int     `json:"id,omitempty"`
+	UserID    int     `json:"user_id"`
+	LeaveType string  `json:"leave_type"`
+	Year      int     `json:"year"`
+	Country   string  `json:"country,omitempty"`
+	Balance   float64 `json:"balance"`
+	Carried   float64 `json:"carried"`
+	Forfeited float64 `json:"forfeited"`
+	ExpiresOn string  `json:"expires_on,omitempty"`
+	// Used and Expired are filled in once the carried days' expiry date has passed.
+	Used      float64 `json:"used"`
+	Expired   float64 `json:"expired"`
+	Timestamp string  `json:"ts,omitempty"`
+}
+
+type RolloverStore interface {
+	FindPolicies(ctx context.Context) ([]*CarryoverPolicy, error)
+	CreatePolicy(ctx context.Context, policy *CarryoverPolicy) (int, error)
+	UpdatePolicy(ctx context.Context, policy *CarryoverPolicy) error
+	DeletePolicy(ctx context.Context, id int) error
+	// Rollover closes a leave year. Without commit it only reports what would happen.
+	Rollover(ctx context.Context, year int, commit bool) ([]*Carryover, error)
+	// Expire forfeits carried days still unused on their expiry date, up to asOf.
+	// Without commit it only reports what would happen.
+	Expire(ctx context.Context, asOf string, commit bool) ([]*Carryover, error)
+}
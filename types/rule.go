@@ -0,0 +1,75 @@
+package types
+
+import (
+	"context"
+	"strings"
+)
+
+// Rule kinds.
+const (
+	// RuleMaxConcurrent caps how many people of a team (or of the whole company
+	// when TeamID is 0) may be out on the same day.
+	RuleMaxConcurrent = "max_concurrent"
+	// RuleBlackout forbids leave between FromDate and ToDate.
+	RuleBlackout = "blackout"
+)
+
+// What happens when a rule is violated.
+const (
+	// ActionBlock rejects the booking.
+	ActionBlock = "block"
+	// ActionFlag lets the booking through but approval then needs a manager override.
+	ActionFlag = "flag"
+)
+
+type Rule struct {
+	ID        int    `json:"id"`
+	Kind      string `json:"kind"`
+	Label     string `json:"label"`
+	TeamID    int    `json:"team_id,omitempty"`
+	MaxAbsent int    `json:"max_absent,omitempty"`
+	FromDate  string `json:"fromDate,omitempty"`
+	ToDate    string `json:"toDate,omitempty"`
+	Action    string `json:"action"`
+	Timestamp string `json:"ts"`
+}
+
+type RuleViolation struct {
+	RuleID  int    `json:"rule_id"`
+	Label   string `json:"label"`
+	Action  string `json:"action"`
+	Message string `json:"message"`
+}
+
+// RuleViolationError is returned when a booking or approval breaks team rules.
+// NeedsOverride is set when every violation is a flag a manager may override.
+type RuleViolationError struct {
+	Violations    []RuleViolation
+	NeedsOverride bool
+}
+
+func (e *RuleViolationError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		messages[i] = v.Message
+	}
+
+	prefix := "violates team rules: "
+	if e.NeedsOverride {
+		prefix = "needs a manager override: "
+	}
+	return prefix + strings.Join(messages, "; ")
+}
+
+func (e *RuleViolationError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+type RuleStore interface {
+	FindById(ctx context.Context, id int) (*Rule, error)
+	FindAll(ctx context.Context) ([]*Rule, error)
+	FindApplicable(ctx context.Context, teamID int) ([]*Rule, error)
+	CreateRule(ctx context.Context, rule *Rule) (int, error)
+	UpdateRule(ctx context.Context, rule *Rule) error
+	DeleteRule(ctx context.Context, id int) error
+}
@@ -0,0 +1,62 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// VacationStatus is the approval state of a vacation request.
+type VacationStatus string
+
+const (
+	StatusPending   VacationStatus = "pending"
+	StatusApproved  VacationStatus = "approved"
+	StatusRejected  VacationStatus = "rejected"
+	StatusCancelled VacationStatus = "cancelled"
+)
+
+var ErrInvalidTransition = errors.New("invalid status transition")
+
+// transitions lists the states each state may move to. Rejected and cancelled are final.
+var transitions = map[VacationStatus][]VacationStatus{
+	StatusPending:  {StatusApproved, StatusRejected, StatusCancelled},
+	StatusApproved: {StatusCancelled},
+}
+
+// CanTransitionTo reports whether a request in status s may move to next.
+func (s VacationStatus) CanTransitionTo(next VacationStatus) bool {
+	for _, allowed := range transitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckTransition returns an error wrapping ErrInvalidTransition when s may not move to next.
+func (s VacationStatus) CheckTransition(next VacationStatus) error {
+	if !s.CanTransitionTo(next) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, s, next)
+	}
+	return nil
+}
+
+// OverlapError is returned when a request overlaps the same person's pending or
+// approved requests. VacationIDs lists the conflicting requests.
+type OverlapError struct {
+	VacationIDs []int
+}
+
+func (e *OverlapError) Error() string {
+	ids := make([]string, len(e.VacationIDs))
+	for i, id := range e.VacationIDs {
+		ids[i] = strconv.Itoa(id)
+	}
+	return "overlaps existing vacation(s) " + strings.Join(ids, ", ")
+}
+
+func (e *OverlapError) Is(target error) bool {
+	return target == ErrConflict
+}
@@ -1,40 +1,145 @@
 package types
 
+import "context"
+
 type Vacation struct {
-	ID        int    `json:"id"`
-	Label     string `json:"label"`
-	FromDate  string `json:"fromDate"`
-	ToDate    string `json:"toDate"`
-	PersonId  int    `json:"personId"`
-	Timestamp string `json:"ts"`
-	DaysUsed  int    `json:"daysUsed"`
+	ID        int            `json:"id"`
+	Label     string         `json:"label"`
+	FromDate  string         `json:"fromDate"`
+	ToDate    string         `json:"toDate"`
+	PersonId  int            `json:"personId"`
+	Timestamp string         `json:"ts"`
+	DaysUsed  float64        `json:"daysUsed"`
+	Status    VacationStatus `json:"status"`
+	// Portion takes only part of a single day off: "am" or "pm" for half a
+	// day, or "hours" for Hours of it. Empty means whole days.
+	Portion string  `json:"portion,omitempty"`
+	Hours   float64 `json:"hours,omitempty"`
+	// LeaveType is the code of the balance the request draws from, "paid" by default.
+	LeaveType string `json:"leaveType"`
+	// Document references the supporting document (e.g. a sick note) for
+	// leave types that require one.
+	Document string `json:"document,omitempty"`
+	// PaidDays and NonPaidDays show how much of an approved request was charged
+	// to the built-in paid and unpaid balances.
+	PaidDays    float64 `json:"paidDays"`
+	NonPaidDays float64 `json:"nonPaidDays"`
+	// Flagged requests broke a flag rule when filed; FlagReason says which.
+	Flagged    bool   `json:"flagged"`
+	FlagReason string `json:"flagReason,omitempty"`
 }
 
 type User struct {
+	ID              int         `json:"id"`
+	FirstName       string      `json:"first_name"`
+	LastName        string      `json:"last_name"`
+	Age             int         `json:"age"`
+	Email           string      `json:"email"`
+	VacationDays    float64     `json:"vacation_days"`
+	NonPaidLeave    float64     `json:"non_paid_leave"`
+	HolidayCalendar string      `json:"holiday_calendar"`
+	ManagerID       int         `json:"manager_id,omitempty"`
+	TeamID          int         `json:"team_id,omitempty"`
+	HireDate        string      `json:"hire_date,omitempty"`
+	Timestamp       string      `json:"ts"`
+	Vacations       []*Vacation `json:"vacations,omitempty"`
+	// WorkDays lists the weekdays the user works, e.g. "mon,tue,wed,thu";
+	// empty means Monday to Friday. FTE is their working time in percent of a
+	// full-time position and prorates the entitlements they are granted.
+	WorkDays string `json:"work_days,omitempty"`
+	FTE      int    `json:"fte,omitempty"`
+	// Role is RoleEmployee, RoleManager or RoleHRAdmin.
+	Role string `json:"role,omitempty"`
+	// Password sets the user's password when creating or updating them. It is
+	// stored as a hash and never returned.
+	Password string `json:"password,omitempty"`
+	// Balances holds one balance per leave type, keyed by leave type code.
+	Balances map[string]float64 `json:"balances,omitempty"`
+}
+
+// FullTime is the FTE percentage of a full-time position.
+const FullTime = 100
+
+// ProrateFTE scales a full-time number of days to an FTE percentage.
+func ProrateFTE(days float64, fte int) float64 {
+	return RoundDays(days * float64(fte) / FullTime)
+}
+
+// Holiday is a public holiday in a country or region calendar such as "DE" or "DE-BY".
+// Rows with Source "manual" are admin overrides: they survive re-imports, and an
+// override with Observed set to false cancels the holiday.
+type Holiday struct {
 	ID        int    `json:"id"`
-	FirstName string `json:"first_name"`
-	LastName  string `json:"last_name"`
-	Age       int    `json:"age"`
-	Email     string      `json:"email"`
-	VacationDays int      `json:"vacation_days"`
-	NonPaidLeave int      `json:"non_paid_leave"`
-	Timestamp string      `json:"ts"`
-	Vacations []*Vacation `json:"vacations,omitempty"`
+	Calendar  string `json:"calendar"`
+	Date      string `json:"date"`
+	Name      string `json:"name"`
+	Observed  bool   `json:"observed"`
+	Source    string `json:"source"`
+	Timestamp string `json:"ts"`
 }
 
+type Team struct {
+	ID           int     `json:"id"`
+	Name         string  `json:"name"`
+	DepartmentID int     `json:"department_id,omitempty"`
+	Timestamp    string  `json:"ts"`
+	Members      []*User `json:"members,omitempty"`
+}
+
+type Department struct {
+	ID        int     `json:"id"`
+	Name      string  `json:"name"`
+	Timestamp string  `json:"ts"`
+	Teams     []*Team `json:"teams,omitempty"`
+}
+
+// UserStore and VacationStore give up when ctx is done, so a request's
+// queries stop once its client has gone.
 type UserStore interface {
-	FindById(id int) (*User, error)
-	CreateUser(user *User) (int, error)
-	FetchAllUsers() ([]*User, error)
-	UpdateUser(user *User) error
-	DeleteUser(id int) error
+	FindById(ctx context.Context, id int) (*User, error)
+	FindByEmail(ctx context.Context, email string) (*User, error)
+	CreateUser(ctx context.Context, user *User) (int, error)
+	FetchAllUsers(ctx context.Context) ([]*User, error)
+	FindPage(ctx context.Context, filter UserFilter) ([]*User, int, error)
+	FindReports(ctx context.Context, managerID int) ([]*User, error)
+	UpdateUser(ctx context.Context, user *User) error
+	DeleteUser(ctx context.Context, id int) error
 }
 
 type VacationStore interface {
-	FindById(int) (*Vacation, error)
-	FindAll() ([]*Vacation, error)
-	CreateVacation(*Vacation) (int, error)
-	UpdateVacation(*Vacation) error
-	DeleteVacation(int) error
-	GetActiveVacations(string) ([]*Vacation, error)
+	FindById(ctx context.Context, id int) (*Vacation, error)
+	FindAll(ctx context.Context) ([]*Vacation, error)
+	FindPage(ctx context.Context, filter VacationFilter) ([]*Vacation, int, error)
+	CreateVacation(ctx context.Context, vacation *Vacation) (int, error)
+	UpdateVacation(ctx context.Context, vacation *Vacation) error
+	DeleteVacation(ctx context.Context, id int) error
+	TransitionVacation(ctx context.Context, id int, status VacationStatus) error
+	GetActiveVacations(ctx context.Context, date string) ([]*Vacation, error)
+	GetVacationsBetween(ctx context.Context, from, to string) ([]*Vacation, error)
+}
+
+type HolidayStore interface {
+	FindById(ctx context.Context, id int) (*Holiday, error)
+	FindAll(ctx context.Context, calendar string) ([]*Holiday, error)
+	FindBetween(ctx context.Context, calendars []string, from, to string) ([]*Holiday, error)
+	CreateHoliday(ctx context.Context, holiday *Holiday) (int, error)
+	UpdateHoliday(ctx context.Context, holiday *Holiday) error
+	DeleteHoliday(ctx context.Context, id int) error
+	ImportHolidays(ctx context.Context, holidays []*Holiday) (int, error)
+}
+
+type TeamStore interface {
+	FindById(ctx context.Context, id int) (*Team, error)
+	FindAll(ctx context.Context) ([]*Team, error)
+	CreateTeam(ctx context.Context, team *Team) (int, error)
+	UpdateTeam(ctx context.Context, team *Team) error
+	DeleteTeam(ctx context.Context, id int) error
+}
+
+type DepartmentStore interface {
+	FindById(ctx context.Context, id int) (*Department, error)
+	FindAll(ctx context.Context) ([]*Department, error)
+	CreateDepartment(ctx context.Context, department *Department) (int, error)
+	UpdateDepartment(ctx context.Context, department *Department) error
+	DeleteDepartment(ctx context.Context, id int) error
 }
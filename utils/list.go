@@ -0,0 +1,112 @@
+package utils
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/georgiwritescode/vacation-tool/types"
+)
+
+// ParsePage reads limit, offset, sort and order from a list request's query.
+// sortable maps the fields the list may be sorted by to their columns.
+func ParsePage(q url.Values, sortable map[string]string) (types.Page, error) {
+	page := types.Page{Limit: types.DefaultPageSize, Sort: q.Get("sort")}
+
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			return types.Page{}, types.Invalid("limit", "invalid limit %q", v)
+		}
+		page.Limit = min(limit, types.MaxPageSize)
+	}
+	if v := q.Get("offset"); v != "" {
+		offset, err := strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			return types.Page{}, types.Invalid("offset", "invalid offset %q", v)
+		}
+		page.Offset = offset
+	}
+	if _, ok := sortable[page.Sort]; page.Sort != "" && !ok {
+		return types.Page{}, types.Invalid("sort", "cannot sort by %q", page.Sort)
+	}
+
+	switch q.Get("order") {
+	case "", "asc":
+	case "desc":
+		page.Desc = true
+	default:
+		return types.Page{}, types.Invalid("order", "order must be asc or desc")
+	}
+
+	return page, nil
+}
+
+// OrderBy builds the ORDER BY and LIMIT clause of a page, breaking ties by id
+// so pages never overlap. Its placeholders take the returned arguments.
+func OrderBy(sortable map[string]string, page types.Page) (string, []any) {
+	if page.Limit < 1 {
+		page.Limit = types.DefaultPageSize
+	}
+
+	order := "id"
+	if column, ok := sortable[page.Sort]; ok && column != "id" {
+		order = column
+		if page.Desc {
+			order += " DESC"
+		}
+		order += ", id"
+	}
+	if page.Desc {
+		order += " DESC"
+	}
+
+	return " ORDER BY " + order + " LIMIT ? OFFSET ?", []any{page.Limit, page.Offset}
+}
+
+// InList is the condition that column is one of ids. No ids match nothing.
+func InList(column string, ids []int) (string, []any) {
+	if len(ids) == 0 {
+		return "1 = 0", nil
+	}
+
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	return column + " IN (?" + strings.Repeat(", ?", len(ids)-1) + ")", args
+}
+
+// ContainsPattern is a LIKE pattern matching values that contain s literally,
+// for use with LIKE ? ESCAPE '!'. Databases disagree on LIKE's default escape
+// character and on backslashes in string literals, so it escapes with !.
+func ContainsPattern(s string) string {
+	s = strings.NewReplacer(`!`, `!!`, `%`, `!%`, `_`, `!_`).Replace(s)
+	return "%" + s + "%"
+}
+
+// WritePageHeaders reports the size of the whole list in X-Total-Count and
+// links the neighbouring pages in a Link header.
+func WritePageHeaders(w http.ResponseWriter, r *http.Request, page types.Page, total int) {
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+
+	var links []string
+	if next := page.Offset + page.Limit; next < total {
+		links = append(links, fmt.Sprintf(`<%s>; rel="next"`, PageURL(r, next)))
+	}
+	if page.Offset > 0 {
+		links = append(links, fmt.Sprintf(`<%s>; rel="prev"`, PageURL(r, max(page.Offset-page.Limit, 0))))
+	}
+	if len(links) > 0 {
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
+}
+
+// PageURL is the request's URL with its offset replaced.
+func PageURL(r *http.Request, offset int) string {
+	q := r.URL.Query()
+	q.Set("offset", strconv.Itoa(offset))
+	return r.URL.Path + "?" + q.Encode()
+}
@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/georgiwritescode/vacation-tool/types"
+)
+
+// Problem is an RFC 7807 problem details object. Extensions holds the
+// problem-specific members, e.g. the conflicting vacation ids.
+type Problem struct {
+	Type       string             `json:"type"`
+	Title      string             `json:"title"`
+	Status     int                `json:"status"`
+	Detail     string             `json:"detail,omitempty"`
+	Errors     []types.FieldError `json:"errors,omitempty"`
+	Extensions map[string]any     `json:"-"`
+}
+
+// MarshalJSON inlines the extension members next to the standard ones.
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	type problem Problem
+	body, err := json.Marshal((*problem)(p))
+	if err != nil || len(p.Extensions) == 0 {
+		return body, err
+	}
+
+	members := make(map[string]any, len(p.Extensions))
+	if err := json.Unmarshal(body, &members); err != nil {
+		return nil, err
+	}
+	for k, v := range p.Extensions {
+		if _, ok := members[k]; !ok {
+			members[k] = v
+		}
+	}
+	return json.Marshal(members)
+}
+
+// NewProblem describes err. Errors of the classes in types get their own
+// status and problem type; anything else is reported with status.
+func NewProblem(status int, err error) *Problem {
+	p := &Problem{Type: "about:blank", Status: status, Detail: err.Error()}
+
+	switch {
+	case errors.Is(err, types.ErrForbidden):
+		p.Type, p.Status = "/problems/forbidden", http.StatusForbidden
+	case errors.Is(err, types.ErrNotFound):
+		p.Type, p.Status = "/problems/not-found", http.StatusNotFound
+	case errors.Is(err, types.ErrConflict), errors.Is(err, types.ErrInvalidTransition):
+		p.Type, p.Status = "/problems/conflict", http.StatusConflict
+	case errors.Is(err, types.ErrInsufficientBalance):
+		p.Type, p.Status = "/problems/insufficient-balance", http.StatusUnprocessableEntity
+	case errors.Is(err, types.ErrValidation):
+		p.Type, p.Status = "/problems/validation", http.StatusUnprocessableEntity
+	}
+	p.Title = http.StatusText(p.Status)
+
+	var validation *types.ValidationError
+	if errors.As(err, &validation) {
+		p.Errors = validation.Fields
+	}
+
+	var overlap *types.OverlapError
+	if errors.As(err, &overlap) {
+		p.Extensions = map[string]any{"conflicts": overlap.VacationIDs}
+	}
+
+	var violation *types.RuleViolationError
+	if errors.As(err, &violation) {
+		p.Extensions = map[string]any{"violations": violation.Violations, "needsOverride": violation.NeedsOverride}
+	}
+
+	return p
+}
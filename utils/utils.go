@@ -12,8 +12,14 @@ func WriteJSON(w http.ResponseWriter, status int, v any) error {
 	return json.NewEncoder(w).Encode(v)
 }
 
+// WriteError reports err as an application/problem+json body. Errors of the
+// classes in types carry their own status; status covers everything else.
 func WriteError(w http.ResponseWriter, status int, err error) {
-	WriteJSON(w, status, map[string]string{"error": err.Error()})
+	problem := NewProblem(status, err)
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(problem.Status)
+	json.NewEncoder(w).Encode(problem)
 }
 
 func ParseJSON(r *http.Request, v any) error {
@@ -0,0 +1,53 @@
+package validation
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Form reads the numbers of a submitted HTML form. Values that do not parse
+// are recorded as errors of the field named like the input, so they are
+// reported together with the rule violations.
+type Form struct {
+	values url.Values
+	errs   Errors
+}
+
+// NewForm reads from a parsed request form.
+func NewForm(values url.Values) *Form {
+	return &Form{values: values}
+}
+
+// Int parses the named input. An empty input is zero.
+func (f *Form) Int(name string) int {
+	v := strings.TrimSpace(f.values.Get(name))
+	if v == "" {
+		return 0
+	}
+
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		f.errs.Add(name, "%q is not a whole number", v)
+	}
+	return n
+}
+
+// Float parses the named input. An empty input is zero.
+func (f *Form) Float(name string) float64 {
+	v := strings.TrimSpace(f.values.Get(name))
+	if v == "" {
+		return 0
+	}
+
+	n, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		f.errs.Add(name, "%q is not a number", v)
+	}
+	return n
+}
+
+// Err returns the inputs that did not parse as a *types.ValidationError, or nil.
+func (f *Form) Err() error {
+	return f.errs.Err()
+}
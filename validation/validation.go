@@ -0,0 +1,167 @@
+// Package validation checks users and vacations before they reach the stores.
+// Every rule runs, so a single *types.ValidationError lists all field errors
+// at once.
+package validation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+
+	"github.com/georgiwritescode/vacation-tool/calendar"
+	"github.com/georgiwritescode/vacation-tool/types"
+)
+
+// Age limits of employees.
+const (
+	MinAge = 18
+	MaxAge = 100
+)
+
+// MinPasswordLength is the shortest password accepted.
+const MinPasswordLength = 8
+
+// Errors collects field errors.
+type Errors struct {
+	fields []types.FieldError
+}
+
+// Add records a field error.
+func (e *Errors) Add(field, format string, args ...any) {
+	e.fields = append(e.fields, types.FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// Err returns the collected errors as a *types.ValidationError, or nil if there are none.
+func (e *Errors) Err() error {
+	if len(e.fields) == 0 {
+		return nil
+	}
+	return &types.ValidationError{Fields: e.fields}
+}
+
+// Join merges the field errors of several validation errors into one. Any
+// other error is returned as it is, since it is not the input's fault.
+func Join(errs ...error) error {
+	var all Errors
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+
+		var invalid *types.ValidationError
+		if !errors.As(err, &invalid) {
+			return err
+		}
+		all.fields = append(all.fields, invalid.Fields...)
+	}
+	return all.Err()
+}
+
+// User checks a user's profile.
+func User(user *types.User) error {
+	var errs Errors
+
+	if strings.TrimSpace(user.FirstName) == "" {
+		errs.Add("first_name", "first name is required")
+	}
+	if strings.TrimSpace(user.LastName) == "" {
+		errs.Add("last_name", "last name is required")
+	}
+	if user.Email == "" {
+		errs.Add("email", "email is required")
+	} else if addr, err := mail.ParseAddress(user.Email); err != nil || addr.Address != user.Email {
+		errs.Add("email", "%q is not a valid email address", user.Email)
+	}
+	if user.Age < MinAge || user.Age > MaxAge {
+		errs.Add("age", "age must be between %d and %d", MinAge, MaxAge)
+	}
+	if user.VacationDays < 0 {
+		errs.Add("vacation_days", "vacation days must not be negative")
+	}
+	if user.NonPaidLeave < 0 {
+		errs.Add("non_paid_leave", "non-paid leave must not be negative")
+	}
+	if user.HireDate != "" {
+		if _, err := calendar.ParseInputDate(user.HireDate); err != nil {
+			errs.Add("hire_date", "%v", err)
+		}
+	}
+	if _, err := calendar.ParseSchedule(user.WorkDays); err != nil {
+		errs.Add("work_days", "%v", err)
+	}
+	if user.FTE < 0 || user.FTE > types.FullTime {
+		errs.Add("fte", "fte must be between 1 and %d", types.FullTime)
+	}
+	if user.ManagerID < 0 {
+		errs.Add("manager_id", "invalid manager")
+	} else if user.ManagerID != 0 && user.ManagerID == user.ID {
+		errs.Add("manager_id", "a user cannot manage themselves")
+	}
+	if user.TeamID < 0 {
+		errs.Add("team_id", "invalid team")
+	}
+	switch user.Role {
+	case "", types.RoleEmployee, types.RoleManager, types.RoleHRAdmin:
+	default:
+		errs.Add("role", "role must be %q, %q or %q", types.RoleEmployee, types.RoleManager, types.RoleHRAdmin)
+	}
+	if user.Password != "" && len(user.Password) < MinPasswordLength {
+		errs.Add("password", "password must be at least %d characters", MinPasswordLength)
+	}
+
+	return errs.Err()
+}
+
+// Password checks a new password given in field.
+func Password(field, password string) error {
+	if len(password) < MinPasswordLength {
+		return types.Invalid(field, "password must be at least %d characters", MinPasswordLength)
+	}
+	return nil
+}
+
+// Vacation checks a vacation request and that the person it is for exists.
+func Vacation(ctx context.Context, users types.UserStore, vacation *types.Vacation) error {
+	var errs Errors
+
+	if strings.TrimSpace(vacation.Label) == "" {
+		errs.Add("label", "label is required")
+	}
+
+	if vacation.PersonId <= 0 {
+		errs.Add("personId", "employee is required")
+	} else if _, err := users.FindById(ctx, vacation.PersonId); errors.Is(err, types.ErrNotFound) {
+		errs.Add("personId", "employee %d does not exist", vacation.PersonId)
+	} else if err != nil {
+		return err
+	}
+
+	from, fromErr := calendar.ParseInputDate(vacation.FromDate)
+	if fromErr != nil {
+		errs.Add("fromDate", "%v", fromErr)
+	}
+	to, toErr := calendar.ParseInputDate(vacation.ToDate)
+	if toErr != nil {
+		errs.Add("toDate", "%v", toErr)
+	}
+	if fromErr == nil && toErr == nil && to.Before(from) {
+		errs.Add("toDate", "to date must not be before from date")
+	}
+
+	if vacation.DaysUsed < 0 {
+		errs.Add("daysUsed", "days used must not be negative")
+	}
+	switch vacation.Portion {
+	case "", types.PortionAM, types.PortionPM:
+	case types.PortionHours:
+		if vacation.Hours <= 0 || vacation.Hours > types.HoursPerDay {
+			errs.Add("hours", "hours must be between 0 and %d", types.HoursPerDay)
+		}
+	default:
+		errs.Add("portion", "portion must be %q, %q or %q", types.PortionAM, types.PortionPM, types.PortionHours)
+	}
+
+	return errs.Err()
+}